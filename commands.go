@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/therealphatmike/squeal/util/databases"
+	"github.com/therealphatmike/squeal/util/export"
+	"github.com/therealphatmike/squeal/util/formatter"
+	"github.com/therealphatmike/squeal/util/guardrails"
+	"github.com/therealphatmike/squeal/util/queries"
+	"github.com/therealphatmike/squeal/util/statements"
+)
+
+const usage = `usage:
+  squeal                              start the TUI
+  squeal fmt [-engine e] [-case c] [-indent n]
+                                      format SQL read from stdin
+  squeal queries export <dir>         write saved queries to <dir> as .sql files
+  squeal queries import <dir>         save every .sql file in <dir> as a saved query
+  squeal query -conn name [-format f] [-o file] [-header=false] [-delimiter d]
+               [-quote q] [-null s] [-table t] [-force] [sql]
+                                      run sql (or stdin) and write the results as
+                                      csv, tsv, json, ndjson, markdown, html, sql,
+                                      parquet or xlsx; statements the guardrails
+                                      flag only run with -force`
+
+// runCommand handles the headless subcommands. It reports false when args
+// don't name a subcommand and the TUI should start instead.
+func runCommand(args []string) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+
+	switch args[0] {
+	case "queries":
+		return true, runQueriesCommand(args[1:])
+	case "fmt":
+		return true, runFmtCommand(args[1:])
+	case "query":
+		return true, runQueryCommand(args[1:])
+	case "help", "-h", "--help":
+		fmt.Println(usage)
+		return true, nil
+	}
+
+	return true, fmt.Errorf("unknown command %q\n%s", args[0], usage)
+}
+
+func runQueriesCommand(args []string) error {
+	if len(args) != 2 {
+		return errors.New(usage)
+	}
+
+	switch args[0] {
+	case "export":
+		saved, err := queries.ReadSavedQueries()
+		if err != nil {
+			return err
+		}
+		written, err := queries.ExportSQLDir(saved, args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "exported %d queries to %s\n", len(written), args[1])
+	case "import":
+		imported, err := queries.ImportSQLDir(args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "imported %d queries from %s\n", len(imported), args[1])
+	default:
+		return errors.New(usage)
+	}
+
+	return nil
+}
+
+func runFmtCommand(args []string) error {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	engine := flags.String("engine", databases.EnginePostgres, "SQL dialect: postgres, mysql or maria")
+	keywordCase := flags.String("case", formatter.UpperCase, "keyword case: upper, lower or preserve")
+	indent := flags.Int("indent", 2, "spaces per indent level")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	switch *engine {
+	case databases.EnginePostgres, databases.EngineMySQL, databases.EngineMariaDB:
+	default:
+		return fmt.Errorf("unknown engine %q, use postgres, mysql or maria", *engine)
+	}
+	switch *keywordCase {
+	case formatter.UpperCase, formatter.LowerCase, formatter.PreserveCase:
+	default:
+		return fmt.Errorf("unknown keyword case %q", *keywordCase)
+	}
+
+	src, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	formatted := formatter.FormatWith(string(src), *engine, formatter.Options{
+		KeywordCase: *keywordCase,
+		Indent:      strings.Repeat(" ", max(*indent, 0)),
+	})
+	_, err = io.WriteString(os.Stdout, formatted)
+
+	return err
+}
+
+func runQueryCommand(args []string) (err error) {
+	defaults := export.Defaults(export.FormatCSV)
+	flags := flag.NewFlagSet("query", flag.ContinueOnError)
+	connectionName := flags.String("conn", "", "name of the connection to run against")
+	format := flags.String("format", defaults.Format, "output format: "+strings.Join(export.Formats, ", "))
+	output := flags.String("o", "", "file to write to instead of stdout")
+	header := flags.Bool("header", defaults.Header, "write column names first (csv and tsv)")
+	delimiter := flags.String("delimiter", defaults.Delimiter, "csv field delimiter")
+	quoting := flags.String("quote", defaults.Quoting, "csv and tsv quoting: "+strings.Join(export.Quotings, ", "))
+	null := flags.String("null", defaults.Null, "text written for NULL")
+	table := flags.String("table", defaults.Table, "table name for sql INSERT statements")
+	force := flags.Bool("force", false, "run statements the guardrails flag, such as DELETE without WHERE")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	configs, err := databases.ReadDatabaseConfigs()
+	if err != nil {
+		return err
+	}
+	var database *databases.Database
+	for i := range configs {
+		if configs[i].ConnectionName == *connectionName {
+			database = &configs[i]
+		}
+	}
+	if database == nil {
+		return fmt.Errorf("no connection named %q\n%s", *connectionName, usage)
+	}
+
+	src := strings.Join(flags.Args(), " ")
+	if src == "" {
+		stdin, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		src = string(stdin)
+	}
+
+	stmts := statements.Split(src, database.Engine)
+	texts := []string{}
+	for _, stmt := range stmts {
+		texts = append(texts, stmt.Text)
+	}
+	// there's nobody to ask for bind parameters here, and sent as they are the
+	// server would fail on them or read them as its own bind syntax
+	for i, stmt := range stmts {
+		placeholders, err := statements.Placeholders(stmt.Text, database.Engine)
+		if err != nil {
+			return fmt.Errorf("statement %d: %w", i+1, err)
+		}
+		if len(placeholders) > 0 {
+			return fmt.Errorf("statement %d has bind parameters, which can only be filled in from the TUI: %s", i+1, placeholders[0].Label())
+		}
+	}
+
+	// flagged statements need -force up front for the same reason
+	if risks := guardrails.CheckAll(texts, database.Engine); len(risks) > 0 && !*force {
+		reasons := []string{}
+		for _, risk := range risks {
+			reasons = append(reasons, "  "+risk.Reason+": "+risk.Statement)
+		}
+		where := *connectionName
+		if database.IsProduction() {
+			where += " (production)"
+		}
+		return fmt.Errorf("not running on %s without -force:\n%s", where, strings.Join(reasons, "\n"))
+	}
+
+	if export.Binary(*format) && *output == "" && isTerminal(os.Stdout) {
+		return fmt.Errorf("%s is a binary format, write it to a file with -o", *format)
+	}
+	if export.SingleResult(*format) {
+		results := 0
+		for _, stmt := range stmts {
+			if statements.ReturnsRows(stmt.Text, database.Engine) {
+				results++
+			}
+		}
+		if results > 1 {
+			return fmt.Errorf("a %s file holds one result, run one query at a time", *format)
+		}
+	}
+
+	opts := export.Options{
+		Format:    *format,
+		Header:    *header,
+		Delimiter: *delimiter,
+		Quoting:   *quoting,
+		Null:      *null,
+		Table:     *table,
+		Engine:    database.Engine,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	conn, err := databases.Connect(ctx, *database)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// the file is only created once connected, so a failed connection
+	// doesn't leave an empty one behind
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		f, createErr := os.Create(*output)
+		if createErr != nil {
+			return createErr
+		}
+		defer func() {
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		out = f
+	}
+
+	for _, stmt := range stmts {
+		if !statements.ReturnsRows(stmt.Text, database.Engine) {
+			result, err := conn.Query(ctx, stmt.Text, false)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "%d rows affected\n", result.RowsAffected)
+			continue
+		}
+		if err := exportStatement(ctx, conn, stmt.Text, out, opts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportStatement streams a statement's rows into the writer a page at a
+// time, so results larger than memory can be exported.
+func exportStatement(ctx context.Context, conn *databases.Connection, query string, out io.Writer, opts export.Options) error {
+	stream, err := conn.Stream(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	writer, err := export.NewWriter(out, stream.Columns, opts)
+	if err != nil {
+		return err
+	}
+	for !stream.Done() {
+		rows, err := stream.Fetch(500)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := writer.WriteRow(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	return writer.Close()
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
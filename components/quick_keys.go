@@ -22,23 +22,27 @@ var (
         Foreground(lipgloss.Color("#FFFDF5"))
 )
 
+// QuickKey is a key hint shown in the quick keys bar.
+type QuickKey struct {
+    Key   string
+    Label string
+}
+
 func NewQuickKeys(width int) string {
-    quitKey := keyStyle.Render("^c")
-    quitLabel := labelStyle.Render("Quit")
-    newKey := keyStyle.Render("^n")
-    newLabel := labelStyle.Render("New")
-    disconnectKey := keyStyle.Render("^d")
-    disconnectLabel := labelStyle.Render("Disconnect")
-
-    bar := lipgloss.JoinHorizontal(
-        lipgloss.Top,
-        quitKey,
-        quitLabel,
-        newKey,
-        newLabel,
-        disconnectKey,
-        disconnectLabel,
+    return NewQuickKeysFor(width,
+        QuickKey{Key: "^c", Label: "Quit"},
+        QuickKey{Key: "^n", Label: "New"},
+        QuickKey{Key: "^d", Label: "Disconnect"},
     )
+}
+
+func NewQuickKeysFor(width int, keys ...QuickKey) string {
+    items := []string{}
+    for _, k := range keys {
+        items = append(items, keyStyle.Render(k.Key), labelStyle.Render(k.Label))
+    }
+
+    bar := lipgloss.JoinHorizontal(lipgloss.Top, items...)
 
-    return quickKeysStyle.Width(width).Render(bar)
+    return quickKeysStyle.Width(width).MaxHeight(1).Render(bar)
 }
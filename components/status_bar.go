@@ -27,21 +27,47 @@ var (
 	statusText = lipgloss.NewStyle().Inherit(statusBarStyle)
 
 	fishCakeStyle = statusNugget.Background(lipgloss.Color("#6124DF"))
+
+	extraNuggetStyle = statusNugget.Background(lipgloss.Color("#874BFD"))
+
+	alertNuggetStyle = statusNugget.Background(lipgloss.Color("#E5A50A")).Foreground(lipgloss.Color("#1A1A1A")).Bold(true)
 )
 
-func NewStatusBar(width int, status string) string {
+// NewStatusBar renders the bottom status line. Any nuggets, such as the active
+// tab name, are shown between the status text and the encoding.
+func NewStatusBar(width int, status string, nuggets ...string) string {
+	return NewStatusBarWithAlert(width, status, "", nuggets...)
+}
+
+// NewStatusBarWithAlert is NewStatusBar with an eye-catching nugget in front of
+// the others, for state the user must not forget about such as an open
+// transaction.
+func NewStatusBarWithAlert(width int, status string, alert string, nuggets ...string) string {
 	w := lipgloss.Width
 
 	statusKey := statusStyle.Render("STATUS")
 	encoding := encodingStyle.Render("UTF-8")
 	fishCake := fishCakeStyle.Render("😱 SQueaL")
+
+	extras := []string{}
+	if alert != "" {
+		extras = append(extras, alertNuggetStyle.Render(alert))
+	}
+	for _, nugget := range nuggets {
+		if nugget != "" {
+			extras = append(extras, extraNuggetStyle.Render(nugget))
+		}
+	}
+	extra := lipgloss.JoinHorizontal(lipgloss.Top, extras...)
+
 	statusVal := statusText.
-		Width(width - w(statusKey) - w(encoding) - w(fishCake)).
+		Width(max(width-w(statusKey)-w(extra)-w(encoding)-w(fishCake), 0)).
 		Render(status)
 
 	bar := lipgloss.JoinHorizontal(lipgloss.Top,
 		statusKey,
 		statusVal,
+		extra,
 		encoding,
 		fishCake,
 	)
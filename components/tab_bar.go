@@ -0,0 +1,47 @@
+package components
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	tabBarStyle = lipgloss.NewStyle().
+			Background(lipgloss.AdaptiveColor{Light: "#D9DCCF", Dark: "#353533"})
+
+	tabStyle = lipgloss.NewStyle().
+			Inherit(tabBarStyle).
+			Foreground(lipgloss.AdaptiveColor{Light: "#343433", Dark: "#C1C6B2"}).
+			Padding(0, 1)
+
+	activeTabStyle = tabStyle.
+			Foreground(lipgloss.Color("#FFFDF5")).
+			Background(lipgloss.Color("#874BFD")).
+			Bold(true)
+
+	tabNumberStyle = lipgloss.NewStyle().
+			Inherit(tabStyle).
+			Foreground(lipgloss.Color("#A550DF")).
+			Padding(0)
+)
+
+func NewTabBar(width int, names []string, active int) string {
+	tabs := []string{}
+	for i, name := range names {
+		label := fmt.Sprintf("%d %s", i+1, name)
+		if i == active {
+			tabs = append(tabs, activeTabStyle.Render(label))
+		} else {
+			tabs = append(tabs, tabStyle.Render(label))
+		}
+	}
+
+	bar := lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
+	if lipgloss.Width(bar) > width {
+		// keep the active tab visible when there are more tabs than room
+		bar = lipgloss.JoinHorizontal(lipgloss.Top, tabNumberStyle.Render("… "), tabs[active])
+	}
+
+	return tabBarStyle.Width(width).Render(bar)
+}
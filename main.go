@@ -4,6 +4,7 @@ package main
 
 import (
 	"log"
+	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
 
@@ -17,6 +18,13 @@ func main() {
 		log.Fatal(err)
 	}
 
+	if handled, err := runCommand(os.Args[1:]); handled {
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	m, _ := models.InitSqueal()
 	p := tea.NewProgram(m, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
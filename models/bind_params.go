@@ -0,0 +1,184 @@
+package models
+
+import (
+	"errors"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/therealphatmike/squeal/util/params"
+	"github.com/therealphatmike/squeal/util/statements"
+)
+
+// BindParamsReadyMsg carries a statement rewritten into the engine's bind
+// syntax together with the driver arguments, ready to execute.
+type BindParamsReadyMsg struct {
+	Query string
+	Args  []any
+}
+
+type BindParamsCancelledMsg struct{}
+
+type boundParam struct {
+	label    string
+	raw      *string
+	typeHint *string
+}
+
+type BindParams struct {
+	width  int
+	height int
+	query  string
+	engine string
+	params []boundParam
+	form   *huh.Form
+	err    error
+}
+
+// NeedsBindParams reports whether the statement has placeholders that have to
+// be filled in before it can run, or why they can't be.
+func NeedsBindParams(query string, engine string) (bool, error) {
+	placeholders, err := statements.Placeholders(query, engine)
+	return len(placeholders) > 0, err
+}
+
+func NewBindParamsForm(width int, height int, query string, engine string) BindParams {
+	last, err := params.LastValues(query)
+
+	// the statement was checked by NeedsBindParams
+	placeholders, _ := statements.Placeholders(query, engine)
+
+	bound := []boundParam{}
+	for _, p := range statements.UniquePlaceholders(placeholders) {
+		previous := last[p.Label()]
+		raw := previous.Raw
+		typeHint := previous.Type
+		if typeHint == "" {
+			typeHint = params.GuessType(raw)
+		}
+		bound = append(bound, boundParam{label: p.Label(), raw: &raw, typeHint: &typeHint})
+	}
+
+	return BindParams{
+		width:  width,
+		height: height,
+		query:  query,
+		engine: engine,
+		params: bound,
+		err:    err,
+		form:   newBindForm(bound),
+	}
+}
+
+// newBindForm asks for a type and value for each parameter, starting from the
+// ones they hold.
+func newBindForm(bound []boundParam) *huh.Form {
+	typeOptions := []huh.Option[string]{}
+	for _, t := range params.Types {
+		typeOptions = append(typeOptions, huh.NewOption(t, t))
+	}
+
+	groups := []*huh.Group{}
+	for _, param := range bound {
+		groups = append(groups, huh.NewGroup(
+			huh.NewSelect[string]().
+				Title(param.label+" type").
+				Options(typeOptions...).
+				Value(param.typeHint),
+			huh.NewInput().
+				Title(param.label).
+				Description("Leave the type as null to send NULL.").
+				Value(param.raw).
+				Validate(func(s string) error {
+					_, err := params.Convert(params.Value{Raw: s, Type: *param.typeHint})
+					return err
+				}),
+		))
+	}
+
+	return huh.NewForm(groups...).
+		WithShowHelp(true).
+		WithShowErrors(true)
+}
+
+func (m BindParams) Init() tea.Cmd {
+	return m.form.Init()
+}
+
+func (m BindParams) Update(msg tea.Msg) (BindParams, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+	case tea.KeyMsg:
+		if msg.String() == "esc" {
+			return m, func() tea.Msg { return BindParamsCancelledMsg{} }
+		}
+	}
+
+	form, cmd := m.form.Update(msg)
+	if f, ok := form.(*huh.Form); ok {
+		m.form = f
+	}
+
+	switch m.form.State {
+	case huh.StateAborted:
+		return m, func() tea.Msg { return BindParamsCancelledMsg{} }
+	case huh.StateCompleted:
+		query, args, err := m.bind()
+		if err != nil {
+			// start the form over, keeping what was entered, so it can be fixed
+			m.err = err
+			m.form = newBindForm(m.params)
+			return m, m.form.Init()
+		}
+		return m, func() tea.Msg { return BindParamsReadyMsg{Query: query, Args: args} }
+	}
+
+	return m, cmd
+}
+
+func (m BindParams) bind() (string, []any, error) {
+	values := map[string]any{}
+	remembered := map[string]params.Value{}
+	for _, p := range m.params {
+		value := params.Value{Raw: *p.raw, Type: *p.typeHint}
+		converted, err := params.Convert(value)
+		if err != nil {
+			return "", nil, errors.New(p.label + ": " + err.Error())
+		}
+		values[p.label] = converted
+		remembered[p.label] = value
+	}
+
+	// failing to remember values shouldn't stop the query from running
+	_ = params.RememberValues(m.query, remembered)
+
+	return statements.Bind(m.query, m.engine, values)
+}
+
+func (m BindParams) View() string {
+	width := min(max(m.width-10, 40), 80)
+	body := m.form.View()
+	if m.err != nil {
+		body = lipgloss.JoinVertical(lipgloss.Left, body, historyErrorStyle.Render(m.err.Error()))
+	}
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		dialogBoxStyle.Width(width).Render(
+			lipgloss.JoinVertical(
+				lipgloss.Left,
+				savedQueryNameStyle.Render("Bind Parameters"),
+				// a script asks for each statement's in turn
+				historyMetaStyle.Render(truncate(singleLine(m.query), width-4)),
+				body,
+			),
+		),
+		lipgloss.WithWhitespaceChars(" "),
+		lipgloss.WithWhitespaceForeground(subtle),
+	)
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/therealphatmike/squeal/util/paging"
+	"github.com/therealphatmike/squeal/util/presets"
+)
+
+type BrowsePresetsClosedMsg struct{}
+
+// BrowsePresets lists the conditions and sorts saved for the table being
+// browsed, to read it again with one.
+type BrowsePresets struct {
+	width      int
+	height     int
+	connection string
+	table      []string
+	available  []presets.Preset
+	cursor     int
+	err        error
+}
+
+func NewBrowsePresets(width int, height int, connection string, table []string) BrowsePresets {
+	m := BrowsePresets{
+		width:      width,
+		height:     height,
+		connection: connection,
+		table:      table,
+	}
+	m.reload()
+
+	return m
+}
+
+func (m *BrowsePresets) reload() {
+	m.available, m.err = presets.Load(m.connection, m.table)
+	if m.cursor >= len(m.available) {
+		m.cursor = max(len(m.available)-1, 0)
+	}
+}
+
+func (m BrowsePresets) Init() tea.Cmd {
+	return nil
+}
+
+func (m BrowsePresets) Update(msg tea.Msg) (BrowsePresets, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "q":
+			return m, func() tea.Msg { return BrowsePresetsClosedMsg{} }
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.available)-1 {
+				m.cursor++
+			}
+		case "ctrl+x":
+			if len(m.available) > 0 {
+				if err := presets.Delete(m.connection, m.table, m.available[m.cursor].Name); err != nil {
+					m.err = err
+					return m, nil
+				}
+				m.reload()
+			}
+		case "enter":
+			if len(m.available) == 0 {
+				return m, nil
+			}
+			p := m.available[m.cursor]
+			refine := refineBrowseMsg{where: p.Where, order: p.Order}
+			return m, func() tea.Msg { return refine }
+		}
+	}
+
+	return m, nil
+}
+
+// describeRefinement is the condition and sort as they read in the query.
+func describeRefinement(where string, order []paging.Order) string {
+	parts := []string{}
+	if where != "" {
+		parts = append(parts, "WHERE "+where)
+	}
+	if len(order) > 0 {
+		columns := []string{}
+		for _, o := range order {
+			column := o.Column
+			if o.Descending {
+				column += " DESC"
+			}
+			columns = append(columns, column)
+		}
+		parts = append(parts, "ORDER BY "+strings.Join(columns, ", "))
+	}
+	if len(parts) == 0 {
+		return "every row, by key"
+	}
+	return strings.Join(parts, " ")
+}
+
+func (m BrowsePresets) View() string {
+	width := min(max(m.width-10, 40), 100)
+
+	list := []string{}
+	for i, p := range m.available {
+		prefix := "  "
+		if i == m.cursor {
+			prefix = historyCursor.Render("> ")
+		}
+		list = append(list,
+			prefix+savedQueryNameStyle.Render(truncate(p.Name, width-4)),
+			"    "+historyMetaStyle.Render(truncate(singleLine(describeRefinement(p.Where, p.Order)), width-6)),
+		)
+	}
+
+	switch {
+	case m.err != nil:
+		list = append(list, historyErrorStyle.Render(m.err.Error()))
+	case len(m.available) == 0:
+		list = append(list, historyMetaStyle.Render("No presets yet, save one with P while browsing"))
+	default:
+		list = append(list, "", historyMetaStyle.Render("enter to apply · ctrl+x to delete · esc to close"))
+	}
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		dialogBoxStyle.Width(width).Render(
+			lipgloss.JoinVertical(
+				lipgloss.Left,
+				savedQueryNameStyle.Render("Presets for "+strings.Join(m.table, ".")),
+				"",
+				strings.Join(list, "\n"),
+			),
+		),
+		lipgloss.WithWhitespaceChars(" "),
+		lipgloss.WithWhitespaceForeground(subtle),
+	)
+}
@@ -0,0 +1,245 @@
+package models
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/therealphatmike/squeal/util/databases"
+)
+
+type cellKind int
+
+const (
+	cellText cellKind = iota
+	cellJSON
+	cellXML
+	cellBinary
+)
+
+func (k cellKind) String() string {
+	switch k {
+	case cellJSON:
+		return "JSON"
+	case cellXML:
+		return "XML"
+	case cellBinary:
+		return "binary"
+	}
+	return "text"
+}
+
+var (
+	syntaxKeyStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#874BFD"))
+	syntaxStringStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#32A852"))
+	syntaxNumberStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFB86C"))
+	syntaxLiteralStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#F25D94"))
+	syntaxCommentStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#888B7E")).Italic(true)
+)
+
+// formatCell works out what a value holds and lays it out for reading: JSON
+// and XML are indented, binary becomes a hex dump and anything else is
+// returned as is.
+func formatCell(column databases.Column, value any) (cellKind, string) {
+	if b, ok := value.([]byte); ok && (!utf8.Valid(b) || bytes.IndexByte(b, 0) >= 0) {
+		return cellBinary, strings.TrimRight(hex.Dump(b), "\n")
+	}
+
+	text := displayValue(value)
+	if value == nil {
+		return cellText, text
+	}
+
+	typeName := strings.ToUpper(column.DatabaseType)
+	trimmed := strings.TrimSpace(text)
+	if strings.Contains(typeName, "JSON") || strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		var out bytes.Buffer
+		if err := json.Indent(&out, []byte(trimmed), "", "  "); err == nil {
+			return cellJSON, out.String()
+		}
+	}
+	if typeName == "XML" || strings.HasPrefix(trimmed, "<") {
+		if out, err := indentXML(trimmed); err == nil {
+			return cellXML, out
+		}
+	}
+
+	return cellText, text
+}
+
+// indentXML re-indents a document. Raw tokens keep namespace prefixes as
+// written; the encoder would otherwise turn them into xmlns attributes.
+func indentXML(src string) (string, error) {
+	decoder := xml.NewDecoder(strings.NewReader(src))
+	var out strings.Builder
+	encoder := xml.NewEncoder(&out)
+	encoder.Indent("", "  ")
+
+	prefixed := func(name xml.Name) xml.Name {
+		if name.Space == "" {
+			return name
+		}
+		return xml.Name{Local: name.Space + ":" + name.Local}
+	}
+
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch t := token.(type) {
+		case xml.CharData:
+			if strings.TrimSpace(string(t)) == "" {
+				continue
+			}
+		case xml.StartElement:
+			t.Name = prefixed(t.Name)
+			attrs := make([]xml.Attr, len(t.Attr))
+			for i, attr := range t.Attr {
+				attrs[i] = xml.Attr{Name: prefixed(attr.Name), Value: attr.Value}
+			}
+			t.Attr = attrs
+			token = t
+		case xml.EndElement:
+			t.Name = prefixed(t.Name)
+			token = t
+		}
+
+		if err := encoder.EncodeToken(xml.CopyToken(token)); err != nil {
+			return "", err
+		}
+	}
+	if err := encoder.Flush(); err != nil {
+		return "", err
+	}
+
+	return out.String(), nil
+}
+
+func highlightCell(kind cellKind, text string) string {
+	switch kind {
+	case cellJSON:
+		return highlightJSON(text)
+	case cellXML:
+		return highlightXML(text)
+	}
+	return text
+}
+
+// highlightJSON colours indented JSON. Strings followed by a colon are keys.
+func highlightJSON(src string) string {
+	var out strings.Builder
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '"':
+			end := i + 1
+			for end < len(src) && src[end] != '"' {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(src))
+			style := syntaxStringStyle
+			if strings.HasPrefix(strings.TrimLeft(src[end:], " "), ":") {
+				style = syntaxKeyStyle
+			}
+			out.WriteString(style.Render(src[i:end]))
+			i = end
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(src) && strings.IndexByte("+-.0123456789eE", src[end]) >= 0 {
+				end++
+			}
+			out.WriteString(syntaxNumberStyle.Render(src[i:end]))
+			i = end
+		case strings.HasPrefix(src[i:], "true"), strings.HasPrefix(src[i:], "null"):
+			out.WriteString(syntaxLiteralStyle.Render(src[i : i+4]))
+			i += 4
+		case strings.HasPrefix(src[i:], "false"):
+			out.WriteString(syntaxLiteralStyle.Render(src[i : i+5]))
+			i += 5
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+	return out.String()
+}
+
+// highlightXML colours tag names, attribute values and comments, leaving text
+// content plain.
+func highlightXML(src string) string {
+	var out strings.Builder
+	for i := 0; i < len(src); {
+		switch {
+		case strings.HasPrefix(src[i:], "<!--"):
+			end := strings.Index(src[i:], "-->")
+			if end < 0 {
+				end = len(src) - i - 3
+			}
+			out.WriteString(syntaxCommentStyle.Render(src[i : i+end+3]))
+			i += end + 3
+		case src[i] == '<':
+			end := strings.IndexByte(src[i:], '>')
+			if end < 0 {
+				end = len(src) - i - 1
+			}
+			out.WriteString(highlightTag(src[i : i+end+1]))
+			i += end + 1
+		default:
+			end := strings.IndexByte(src[i:], '<')
+			if end < 0 {
+				end = len(src) - i
+			}
+			out.WriteString(src[i : i+end])
+			i += end
+		}
+	}
+	return out.String()
+}
+
+func highlightTag(tag string) string {
+	var out strings.Builder
+	name := true
+	for i := 0; i < len(tag); {
+		c := tag[i]
+		switch {
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(tag[i+1:], c)
+			if end < 0 {
+				end = len(tag) - i - 2
+			}
+			out.WriteString(syntaxStringStyle.Render(tag[i : i+end+2]))
+			i += end + 2
+		case strings.IndexByte("<>/?!= \n\t", c) >= 0:
+			if c == ' ' {
+				name = false
+			}
+			out.WriteByte(c)
+			i++
+		default:
+			end := i + 1
+			for end < len(tag) && strings.IndexByte("<>/?!=\"' \n\t", tag[end]) < 0 {
+				end++
+			}
+			style := syntaxNumberStyle
+			if name {
+				style = syntaxKeyStyle
+			}
+			out.WriteString(style.Render(tag[i:end]))
+			i = end
+		}
+	}
+	return out.String()
+}
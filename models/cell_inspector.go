@@ -0,0 +1,288 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/therealphatmike/squeal/util/databases"
+)
+
+// InspectorClosedMsg is sent when the cell inspector is dismissed.
+type InspectorClosedMsg struct{}
+
+// inspectCellMsg asks for a cell to be opened in the inspector.
+type inspectCellMsg struct {
+	column databases.Column
+	value  any
+}
+
+var (
+	inspectorMatchStyle   = lipgloss.NewStyle().Background(lipgloss.Color("#FFB86C")).Foreground(lipgloss.Color("#1A1A1A"))
+	inspectorCurrentStyle = lipgloss.NewStyle().Background(lipgloss.Color("#F25D94")).Foreground(lipgloss.Color("#FFFDF5"))
+)
+
+// CellInspector shows a single value full-screen, formatted for its kind and
+// wrapped to the screen, with search.
+type CellInspector struct {
+	width  int
+	height int
+	column databases.Column
+	kind   cellKind
+	size   string
+	// text is formatted and highlighted; lines is text wrapped to the width
+	text   string
+	lines  []string
+	offset int
+
+	input     textinput.Model
+	searching bool
+	term      string
+	// matches are the lines containing term, match the one last jumped to
+	matches []int
+	match   int
+}
+
+func NewCellInspector(width int, height int, column databases.Column, value any) CellInspector {
+	kind, text := formatCell(column, value)
+
+	size := fmt.Sprintf("%s chars", formatCount(len([]rune(displayValue(value)))))
+	if b, ok := value.([]byte); ok && kind == cellBinary {
+		size = fmt.Sprintf("%s bytes", formatCount(len(b)))
+	}
+
+	input := textinput.New()
+	input.Prompt = "/"
+	input.Placeholder = "search..."
+
+	m := CellInspector{
+		width:  width,
+		height: height,
+		column: column,
+		kind:   kind,
+		size:   size,
+		text:   highlightCell(kind, text),
+		input:  input,
+	}
+	m.wrap()
+
+	return m
+}
+
+func (m CellInspector) Init() tea.Cmd {
+	return nil
+}
+
+func (m CellInspector) textWidth() int {
+	// dialogBoxStyle's margin and border
+	return max(m.width-12, 20)
+}
+
+func (m CellInspector) visibleLines() int {
+	// the header and footer each take a line and a blank, plus the border
+	return max(m.height-6, 3)
+}
+
+func (m *CellInspector) wrap() {
+	m.lines = strings.Split(ansi.Wrap(m.text, m.textWidth(), ""), "\n")
+	m.find()
+}
+
+// find collects the lines containing the search term, ignoring case.
+func (m *CellInspector) find() {
+	m.matches = nil
+	if m.term == "" {
+		return
+	}
+
+	term := strings.ToLower(m.term)
+	for i, line := range m.lines {
+		if strings.Contains(strings.ToLower(ansi.Strip(line)), term) {
+			m.matches = append(m.matches, i)
+		}
+	}
+	m.match = min(m.match, max(len(m.matches)-1, 0))
+}
+
+// jump moves to the next match at or after the line, or the previous one at
+// or before it, wrapping around the value.
+func (m *CellInspector) jump(line int, forward bool) {
+	if len(m.matches) == 0 {
+		return
+	}
+
+	m.match = 0
+	if !forward {
+		m.match = len(m.matches) - 1
+	}
+	for i, match := range m.matches {
+		if forward && match >= line {
+			m.match = i
+			break
+		}
+		if !forward && match <= line {
+			m.match = i
+		}
+	}
+	m.offset = m.matches[m.match] - m.visibleLines()/2
+}
+
+func (m CellInspector) Update(msg tea.Msg) (CellInspector, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.wrap()
+	case tea.KeyMsg:
+		if m.searching {
+			switch msg.String() {
+			case "esc":
+				m.searching = false
+				m.input.Blur()
+				return m, nil
+			case "enter":
+				m.searching = false
+				m.input.Blur()
+				m.term = m.input.Value()
+				m.find()
+				m.jump(m.offset, true)
+				m.clamp()
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.input, cmd = m.input.Update(msg)
+			return m, cmd
+		}
+
+		page := max(m.visibleLines()-1, 1)
+		switch msg.String() {
+		case "esc", "q":
+			return m, func() tea.Msg { return InspectorClosedMsg{} }
+		case "/":
+			m.searching = true
+			m.input.SetValue(m.term)
+			m.input.CursorEnd()
+			return m, m.input.Focus()
+		case "n", "N":
+			if len(m.matches) > 0 {
+				next := m.matches[m.match] + 1
+				if msg.String() == "N" {
+					next = m.matches[m.match] - 1
+				}
+				m.jump(next, msg.String() == "n")
+			}
+		case "up", "k":
+			m.offset--
+		case "down", "j":
+			m.offset++
+		case "pgup", "ctrl+b":
+			m.offset -= page
+		case "pgdown", "ctrl+f", " ":
+			m.offset += page
+		case "home", "g":
+			m.offset = 0
+		case "end", "G":
+			m.offset = len(m.lines)
+		}
+	}
+
+	m.clamp()
+	return m, nil
+}
+
+func (m *CellInspector) clamp() {
+	m.offset = max(min(m.offset, len(m.lines)-m.visibleLines()), 0)
+}
+
+// highlightMatches marks each occurrence of the term in a line. The line's
+// own colours are dropped so the matches stand out.
+func (m CellInspector) highlightMatches(line string, current bool) string {
+	plain := ansi.Strip(line)
+	lower := strings.ToLower(plain)
+	term := strings.ToLower(m.term)
+	style := inspectorMatchStyle
+	if current {
+		style = inspectorCurrentStyle
+	}
+	if len(lower) != len(plain) {
+		// lowercasing changed the length, so offsets wouldn't line up
+		return style.Render(plain)
+	}
+
+	var out strings.Builder
+	for {
+		at := strings.Index(lower, term)
+		if at < 0 {
+			out.WriteString(plain)
+			break
+		}
+		out.WriteString(plain[:at])
+		out.WriteString(style.Render(plain[at : at+len(term)]))
+		plain, lower = plain[at+len(term):], lower[at+len(term):]
+	}
+	return out.String()
+}
+
+func (m CellInspector) View() string {
+	width := m.textWidth()
+
+	meta := []string{m.kind.String(), m.size}
+	if m.column.DatabaseType != "" {
+		meta = append([]string{m.column.DatabaseType}, meta...)
+	}
+	header := savedQueryNameStyle.Render(m.column.Name) + " " + historyMetaStyle.Render(strings.Join(meta, " · "))
+	if plain := m.column.Name + " " + strings.Join(meta, " · "); lipgloss.Width(plain) > width {
+		header = truncate(plain, width)
+	}
+
+	matched := map[int]bool{}
+	for _, line := range m.matches {
+		matched[line] = true
+	}
+
+	body := []string{}
+	end := min(m.offset+m.visibleLines(), len(m.lines))
+	for i := m.offset; i < end; i++ {
+		line := m.lines[i]
+		if matched[i] {
+			line = m.highlightMatches(line, m.matches[m.match] == i)
+		}
+		body = append(body, line)
+	}
+
+	footer := historyMetaStyle.Render("↑/↓ scroll • / search • n/N next/previous • esc close")
+	switch {
+	case m.searching:
+		footer = m.input.View()
+	case m.term != "" && len(m.matches) == 0:
+		footer = historyErrorStyle.Render(fmt.Sprintf("no lines match %q", m.term)) + "  " + footer
+	case m.term != "":
+		footer = historyMetaStyle.Render(fmt.Sprintf("match %d of %d", m.match+1, len(m.matches))) + "  " + footer
+	}
+	if len(m.lines) > m.visibleLines() {
+		position := fmt.Sprintf("lines %d-%d of %d", m.offset+1, end, len(m.lines))
+		footer = historyMetaStyle.Render(position) + "  " + footer
+	}
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		dialogBoxStyle.Width(width).Render(
+			lipgloss.JoinVertical(
+				lipgloss.Left,
+				header,
+				"",
+				lipgloss.NewStyle().Height(m.visibleLines()).Render(strings.Join(body, "\n")),
+				"",
+				lipgloss.NewStyle().MaxWidth(width).Render(footer),
+			),
+		),
+		lipgloss.WithWhitespaceChars(" "),
+		lipgloss.WithWhitespaceForeground(subtle),
+	)
+}
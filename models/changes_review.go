@@ -0,0 +1,137 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/therealphatmike/squeal/util/changes"
+)
+
+// ChangesReviewClosedMsg is sent when the review is dismissed, leaving the
+// changes pending.
+type ChangesReviewClosedMsg struct{}
+
+// applyChangesMsg asks for the reviewed changes to be written to the table.
+// Inserted is set for a row from the insert form, which leaves the grid's
+// pending edits alone.
+type applyChangesMsg struct {
+	table    changes.Table
+	changes  []changes.Change
+	inserted bool
+}
+
+// discardChangesMsg asks for the pending changes to be forgotten.
+type discardChangesMsg struct{}
+
+// ChangesReview lists pending edits to a result with the statements that will
+// make them, before they're applied together.
+type ChangesReview struct {
+	width     int
+	height    int
+	table     changes.Table
+	changes   []changes.Change
+	summaries []string
+	cursor    int
+	offset    int
+}
+
+func NewChangesReview(width int, height int, msg reviewChangesMsg) ChangesReview {
+	return ChangesReview{
+		width:     width,
+		height:    height,
+		table:     msg.table,
+		changes:   msg.changes,
+		summaries: msg.summaries,
+	}
+}
+
+func (m ChangesReview) Init() tea.Cmd {
+	return nil
+}
+
+// visibleChanges is how many changes fit, each taking a line for its summary
+// and one for its statement.
+func (m ChangesReview) visibleChanges() int {
+	return max((m.height-12)/2, 3)
+}
+
+func (m ChangesReview) Update(msg tea.Msg) (ChangesReview, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "q":
+			return m, func() tea.Msg { return ChangesReviewClosedMsg{} }
+		case "enter":
+			apply := applyChangesMsg{table: m.table, changes: m.changes}
+			return m, func() tea.Msg { return apply }
+		case "x":
+			return m, func() tea.Msg { return discardChangesMsg{} }
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.changes)-1 {
+				m.cursor++
+			}
+		}
+	}
+
+	visible := m.visibleChanges()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+visible {
+		m.offset = m.cursor - visible + 1
+	}
+
+	return m, nil
+}
+
+func (m ChangesReview) View() string {
+	width := min(max(m.width-10, 40), 160)
+
+	rendered := []string{}
+	end := min(m.offset+m.visibleChanges(), len(m.changes))
+	for i := m.offset; i < end; i++ {
+		prefix := "  "
+		if i == m.cursor {
+			prefix = historyCursor.Render("> ")
+		}
+		statement := strings.Join(strings.Fields(m.table.Preview(m.changes[i])), " ")
+		rendered = append(rendered,
+			prefix+truncate(m.summaries[i], width-2),
+			"  "+historyMetaStyle.Render(truncate(statement, width-2)),
+		)
+	}
+
+	title := fmt.Sprintf("%d pending changes to %s", len(m.changes), strings.Join(m.table.Name, "."))
+	if len(m.changes) == 1 {
+		title = "1 pending change to " + strings.Join(m.table.Name, ".")
+	}
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		dialogBoxStyle.Width(width).Render(
+			lipgloss.JoinVertical(
+				lipgloss.Left,
+				savedQueryNameStyle.Render(title),
+				historyMetaStyle.Render("They're applied together, in one transaction."),
+				"",
+				strings.Join(rendered, "\n"),
+				"",
+				historyMetaStyle.Render("↑/↓ move • enter apply • x discard all • esc keep editing"),
+			),
+		),
+		lipgloss.WithWhitespaceChars(" "),
+		lipgloss.WithWhitespaceForeground(subtle),
+	)
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// DiscardResolvedMsg is sent once the user has decided whether to leave the
+// session with changes that haven't been applied.
+type DiscardResolvedMsg struct {
+	discard bool
+	then    tea.Msg
+}
+
+// DiscardPrompt asks before leaving a session whose results hold edits that
+// haven't been applied, since leaving throws them away. Then is sent once
+// they're discarded.
+type DiscardPrompt struct {
+	width   int
+	height  int
+	then    tea.Msg
+	discard *bool
+	form    *huh.Form
+}
+
+func NewDiscardPrompt(width int, height int, pending int, then tea.Msg) DiscardPrompt {
+	// staying is the default so a hasty enter doesn't lose the edits
+	discard := false
+
+	leaving := "Disconnect"
+	if _, ok := then.(QuitMsg); ok {
+		leaving = "Quit"
+	}
+
+	return DiscardPrompt{
+		width:   width,
+		height:  height,
+		then:    then,
+		discard: &discard,
+		form: huh.NewForm(
+			huh.NewGroup(
+				huh.NewConfirm().
+					Title(fmt.Sprintf("%d changes to the results haven't been applied", pending)).
+					Description(leaving + " and throw them away?").
+					Affirmative("Discard").
+					Negative("Stay").
+					Value(&discard),
+			),
+		).WithShowHelp(false),
+	}
+}
+
+func (m DiscardPrompt) Init() tea.Cmd {
+	return m.form.Init()
+}
+
+func (m DiscardPrompt) Update(msg tea.Msg) (DiscardPrompt, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+	case tea.KeyMsg:
+		if msg.String() == "esc" {
+			return m, func() tea.Msg { return DiscardResolvedMsg{} }
+		}
+	}
+
+	form, cmd := m.form.Update(msg)
+	if f, ok := form.(*huh.Form); ok {
+		m.form = f
+	}
+
+	switch m.form.State {
+	case huh.StateAborted:
+		return m, func() tea.Msg { return DiscardResolvedMsg{} }
+	case huh.StateCompleted:
+		resolved := DiscardResolvedMsg{discard: *m.discard, then: m.then}
+		return m, func() tea.Msg { return resolved }
+	}
+
+	return m, cmd
+}
+
+func (m DiscardPrompt) View() string {
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		dialogBoxStyle.Width(min(max(m.width-10, 40), 80)).Render(
+			lipgloss.JoinVertical(
+				lipgloss.Left,
+				savedQueryNameStyle.Render("Unapplied Changes"),
+				m.form.View(),
+			),
+		),
+		lipgloss.WithWhitespaceChars(" "),
+		lipgloss.WithWhitespaceForeground(subtle),
+	)
+}
@@ -0,0 +1,253 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/therealphatmike/squeal/util/explain"
+)
+
+// ExplainClosedMsg is sent when the plan viewer is dismissed.
+type ExplainClosedMsg struct{}
+
+type explainLine struct {
+	node  *explain.Node
+	depth int
+}
+
+// ExplainView shows a query plan as a tree that can be folded node by node.
+type ExplainView struct {
+	width     int
+	height    int
+	query     string
+	plan      explain.Plan
+	collapsed map[*explain.Node]bool
+	cursor    int
+	offset    int
+}
+
+var (
+	explainHotspotStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5F87")).Bold(true)
+	explainMisestimateStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFB86C"))
+	explainRelationStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#874BFD"))
+)
+
+func NewExplainView(width int, height int, query string, plan explain.Plan) ExplainView {
+	return ExplainView{
+		width:     width,
+		height:    height,
+		query:     query,
+		plan:      plan,
+		collapsed: map[*explain.Node]bool{},
+	}
+}
+
+func (m ExplainView) Init() tea.Cmd {
+	return nil
+}
+
+// lines lists the nodes that aren't hidden under a collapsed parent.
+func (m ExplainView) lines() []explainLine {
+	lines := []explainLine{}
+	var walk func(n *explain.Node, depth int)
+	walk = func(n *explain.Node, depth int) {
+		lines = append(lines, explainLine{node: n, depth: depth})
+		if m.collapsed[n] {
+			return
+		}
+		for _, child := range n.Children {
+			walk(child, depth+1)
+		}
+	}
+	if m.plan.Root != nil {
+		walk(m.plan.Root, 0)
+	}
+
+	return lines
+}
+
+func (m ExplainView) visibleRows() int {
+	return max(m.height-14, 5)
+}
+
+func (m ExplainView) Update(msg tea.Msg) (ExplainView, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+	case tea.KeyMsg:
+		lines := m.lines()
+		if len(lines) == 0 {
+			return m, func() tea.Msg { return ExplainClosedMsg{} }
+		}
+		current := lines[m.cursor].node
+
+		switch msg.String() {
+		case "esc", "q":
+			return m, func() tea.Msg { return ExplainClosedMsg{} }
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(lines)-1 {
+				m.cursor++
+			}
+		case "enter", " ":
+			if len(current.Children) > 0 {
+				m.collapsed[current] = !m.collapsed[current]
+			}
+		case "left", "h":
+			if m.collapsed[current] || len(current.Children) == 0 {
+				// already folded, so move up to the parent instead
+				for i := m.cursor - 1; i >= 0; i-- {
+					if lines[i].depth < lines[m.cursor].depth {
+						m.cursor = i
+						break
+					}
+				}
+			} else {
+				m.collapsed[current] = true
+			}
+		case "right", "l":
+			delete(m.collapsed, current)
+		case "e":
+			// expand everything
+			m.collapsed = map[*explain.Node]bool{}
+		}
+	}
+
+	visible := m.visibleRows()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+visible {
+		m.offset = m.cursor - visible + 1
+	}
+
+	return m, nil
+}
+
+func formatPlanNumber(value float64) string {
+	if value >= 1e6 {
+		return fmt.Sprintf("%.1fM", value/1e6)
+	}
+	if value >= 1e4 {
+		return fmt.Sprintf("%.1fk", value/1e3)
+	}
+	if value == float64(int64(value)) {
+		return fmt.Sprintf("%d", int64(value))
+	}
+	return fmt.Sprintf("%.2f", value)
+}
+
+func (m ExplainView) renderNode(line explainLine, selected bool, width int) string {
+	n := line.node
+
+	marker := "  "
+	if len(n.Children) > 0 {
+		marker = "▾ "
+		if m.collapsed[n] {
+			marker = "▸ "
+		}
+	}
+
+	metrics := []string{"cost " + formatPlanNumber(n.TotalCost)}
+	rows := "rows " + formatPlanNumber(n.EstimatedRows)
+	if n.ActualRows != nil {
+		rows += " → " + formatPlanNumber(*n.ActualRows)
+	}
+	if n.Misestimate > 0 {
+		rows = explainMisestimateStyle.Render(fmt.Sprintf("%s (%sx off)", rows, formatPlanNumber(n.Misestimate)))
+	}
+	metrics = append(metrics, rows)
+	if n.ActualTime != nil {
+		metrics = append(metrics, formatPlanNumber(*n.ActualTime)+"ms")
+	}
+	if n.Loops > 1 {
+		metrics = append(metrics, "×"+formatPlanNumber(n.Loops)+" loops")
+	}
+	meta := historyMetaStyle.Render(strings.Join(metrics, "  "))
+
+	prefix := "  "
+	if selected {
+		prefix = historyCursor.Render("> ")
+	}
+
+	indent := strings.Repeat("  ", line.depth) + marker
+	available := width - lipgloss.Width(meta) - 3
+	operation := n.Operation
+	if n.Hotspot {
+		operation = explainHotspotStyle.Render(operation)
+	}
+
+	label := indent + operation
+	if n.Relation != "" {
+		label += " " + explainRelationStyle.Render(n.Relation)
+	}
+	// truncating styled text would cut its escape codes, so fall back to plain
+	if plain := strings.TrimRight(indent+n.Operation+" "+n.Relation, " "); lipgloss.Width(plain) > available {
+		label = truncate(plain, available)
+	}
+
+	padding := strings.Repeat(" ", max(available-lipgloss.Width(label), 0))
+
+	return prefix + label + padding + " " + meta
+}
+
+func (m ExplainView) View() string {
+	width := min(max(m.width-10, 40), 160)
+	lines := m.lines()
+
+	title := "Plan"
+	if m.plan.Analyzed {
+		title = "Plan (analyzed)"
+	}
+	timings := []string{}
+	if m.plan.PlanningTime != nil {
+		timings = append(timings, "planning "+formatPlanNumber(*m.plan.PlanningTime)+"ms")
+	}
+	if m.plan.ExecutionTime != nil {
+		timings = append(timings, "execution "+formatPlanNumber(*m.plan.ExecutionTime)+"ms")
+	}
+	header := lipgloss.JoinVertical(
+		lipgloss.Left,
+		historyTimeStyle.Render(title)+" "+historyMetaStyle.Render(strings.Join(timings, ", ")),
+		historyMetaStyle.Render(truncate(strings.Join(strings.Fields(m.query), " "), width)),
+		"",
+	)
+
+	rendered := []string{}
+	end := min(m.offset+m.visibleRows(), len(lines))
+	for i := m.offset; i < end; i++ {
+		rendered = append(rendered, m.renderNode(lines[i], i == m.cursor, width))
+	}
+
+	detail := ""
+	if len(lines) > 0 {
+		if d := lines[m.cursor].node.Detail; d != "" {
+			detail = historyMetaStyle.Render(truncate(d, width))
+		}
+	}
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		dialogBoxStyle.Width(width).Render(
+			lipgloss.JoinVertical(
+				lipgloss.Left,
+				header,
+				strings.Join(rendered, "\n"),
+				"",
+				detail,
+				historyMetaStyle.Render("↑/↓ move • enter fold • ←/→ collapse/expand • e expand all • esc close"),
+			),
+		),
+		lipgloss.WithWhitespaceChars(" "),
+		lipgloss.WithWhitespaceForeground(subtle),
+	)
+}
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/therealphatmike/squeal/util/export"
+)
+
+// ExportCancelledMsg is sent when the export prompt is dismissed.
+type ExportCancelledMsg struct{}
+
+// exportRequestedMsg carries the choices made in the export prompt.
+type exportRequestedMsg struct {
+	path string
+	opts export.Options
+}
+
+// ExportPrompt asks where and how to write the results. Options that don't
+// apply to the chosen format are skipped.
+type ExportPrompt struct {
+	width  int
+	height int
+	rows   int
+	name   string
+	opts   *export.Options
+	path   *string
+	form   *huh.Form
+}
+
+func NewExportPrompt(width int, height int, name string, engine string, rows int) ExportPrompt {
+	opts := export.Defaults(export.FormatCSV)
+	opts.Engine = engine
+	path := ""
+
+	formats := []huh.Option[string]{}
+	for _, format := range export.Formats {
+		formats = append(formats, huh.NewOption(format, format))
+	}
+	hiddenUnless := func(formats ...string) func() bool {
+		return func() bool {
+			for _, format := range formats {
+				if opts.Format == format {
+					return false
+				}
+			}
+			return true
+		}
+	}
+
+	return ExportPrompt{
+		width:  width,
+		height: height,
+		rows:   rows,
+		name:   name,
+		opts:   &opts,
+		path:   &path,
+		form: huh.NewForm(
+			huh.NewGroup(
+				huh.NewSelect[string]().
+					Title("Format").
+					Options(formats...).
+					Value(&opts.Format),
+				huh.NewInput().
+					Title("File").
+					Description("Leave empty to write to the current directory.").
+					Value(&path),
+			),
+			huh.NewGroup(
+				huh.NewConfirm().
+					Title("Column names on the first line?").
+					Value(&opts.Header),
+				huh.NewInput().
+					Title("Delimiter").
+					Description("TSV always uses tabs.").
+					Value(&opts.Delimiter),
+				huh.NewSelect[string]().
+					Title("Quoting").
+					Options(huh.NewOptions(export.Quotings...)...).
+					Value(&opts.Quoting),
+			).WithHideFunc(hiddenUnless(export.FormatCSV, export.FormatTSV)),
+			huh.NewGroup(
+				huh.NewInput().
+					Title("Write NULL as").
+					Value(&opts.Null),
+			).WithHideFunc(hiddenUnless(export.FormatCSV, export.FormatTSV, export.FormatMarkdown, export.FormatHTML)),
+			huh.NewGroup(
+				huh.NewInput().
+					Title("Table to INSERT into").
+					Value(&opts.Table).
+					Validate(func(s string) error {
+						if strings.TrimSpace(s) == "" {
+							return errors.New("a table name is needed")
+						}
+						return nil
+					}),
+			).WithHideFunc(hiddenUnless(export.FormatSQL)),
+		).WithShowHelp(false).WithShowErrors(true),
+	}
+}
+
+func (m ExportPrompt) Init() tea.Cmd {
+	return m.form.Init()
+}
+
+func (m ExportPrompt) Update(msg tea.Msg) (ExportPrompt, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+	case tea.KeyMsg:
+		if msg.String() == "esc" {
+			return m, func() tea.Msg { return ExportCancelledMsg{} }
+		}
+	}
+
+	form, cmd := m.form.Update(msg)
+	if f, ok := form.(*huh.Form); ok {
+		m.form = f
+	}
+
+	switch m.form.State {
+	case huh.StateAborted:
+		return m, func() tea.Msg { return ExportCancelledMsg{} }
+	case huh.StateCompleted:
+		requested := exportRequestedMsg{path: m.destination(), opts: *m.opts}
+		return m, func() tea.Msg { return requested }
+	}
+
+	return m, cmd
+}
+
+// destination is the file typed, with ~ expanded, or a name made up from the
+// tab and the time when nothing was typed.
+func (m ExportPrompt) destination() string {
+	path := strings.TrimSpace(*m.path)
+	if path == "" {
+		name := strings.Join(strings.Fields(strings.ToLower(m.name)), "-")
+		return fmt.Sprintf("%s-%s.%s", name, time.Now().Format("20060102-150405"), export.ExtensionFor(m.opts.Format))
+	}
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
+func (m ExportPrompt) View() string {
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		dialogBoxStyle.Width(min(max(m.width-10, 40), 80)).Render(
+			lipgloss.JoinVertical(
+				lipgloss.Left,
+				savedQueryNameStyle.Render("Export Results")+" "+historyMetaStyle.Render(formatCount(m.rows)+" rows"),
+				m.form.View(),
+			),
+		),
+		lipgloss.WithWhitespaceChars(" "),
+		lipgloss.WithWhitespaceForeground(subtle),
+	)
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"errors"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/therealphatmike/squeal/util/databases"
+	"github.com/therealphatmike/squeal/util/guardrails"
+)
+
+// GuardCancelledMsg is sent when a destructive statement isn't confirmed.
+type GuardCancelledMsg struct{}
+
+// GuardPrompt asks before running destructive statements. Production
+// connections have to confirm by typing the connection name; everything else
+// just confirms. Then is sent once confirmed.
+type GuardPrompt struct {
+	width  int
+	height int
+	risks  []guardrails.Risk
+	then   tea.Msg
+	strict bool
+	typed  *string
+	ok     *bool
+	form   *huh.Form
+}
+
+func NewGuardPrompt(width int, height int, database databases.Database, risks []guardrails.Risk, then tea.Msg) GuardPrompt {
+	typed := ""
+	ok := false
+	strict := database.IsProduction()
+
+	var field huh.Field = huh.NewConfirm().
+		Title("Run it anyway?").
+		Affirmative("Run").
+		Negative("Cancel").
+		Value(&ok)
+	if strict {
+		field = huh.NewInput().
+			Title(database.ConnectionName + " is a production connection").
+			Description("Type the connection name to run it.").
+			Value(&typed).
+			Validate(func(s string) error {
+				if s != database.ConnectionName {
+					return errors.New("that isn't the connection name")
+				}
+				return nil
+			})
+	}
+
+	return GuardPrompt{
+		width:  width,
+		height: height,
+		risks:  risks,
+		then:   then,
+		strict: strict,
+		typed:  &typed,
+		ok:     &ok,
+		form: huh.NewForm(huh.NewGroup(field)).
+			WithShowHelp(false).
+			WithShowErrors(true),
+	}
+}
+
+func (m GuardPrompt) Init() tea.Cmd {
+	return m.form.Init()
+}
+
+func (m GuardPrompt) Update(msg tea.Msg) (GuardPrompt, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+	case tea.KeyMsg:
+		if msg.String() == "esc" {
+			return m, func() tea.Msg { return GuardCancelledMsg{} }
+		}
+	}
+
+	form, cmd := m.form.Update(msg)
+	if f, ok := form.(*huh.Form); ok {
+		m.form = f
+	}
+
+	switch m.form.State {
+	case huh.StateAborted:
+		return m, func() tea.Msg { return GuardCancelledMsg{} }
+	case huh.StateCompleted:
+		if !m.strict && !*m.ok {
+			return m, func() tea.Msg { return GuardCancelledMsg{} }
+		}
+		then := m.then
+		return m, func() tea.Msg { return then }
+	}
+
+	return m, cmd
+}
+
+func (m GuardPrompt) View() string {
+	width := min(max(m.width-10, 40), 100)
+
+	reasons := []string{}
+	for _, risk := range m.risks {
+		statement := strings.Join(strings.Fields(risk.Statement), " ")
+		reasons = append(reasons, lipgloss.JoinVertical(
+			lipgloss.Left,
+			historyErrorStyle.Render("• "+risk.Reason),
+			historyMetaStyle.Render("  "+truncate(statement, width-4)),
+		))
+	}
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		dialogBoxStyle.Width(width).Render(
+			lipgloss.JoinVertical(
+				lipgloss.Left,
+				savedQueryNameStyle.Render("Destructive Statement"),
+				strings.Join(reasons, "\n"),
+				"",
+				m.form.View(),
+			),
+		),
+		lipgloss.WithWhitespaceChars(" "),
+		lipgloss.WithWhitespaceForeground(subtle),
+	)
+}
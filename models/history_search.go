@@ -0,0 +1,180 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/therealphatmike/squeal/util/history"
+)
+
+// HistorySelectedMsg is sent when a past query is picked from the history
+// search overlay so the editor can paste it into its buffer.
+type HistorySelectedMsg struct {
+	Query string
+}
+
+// HistoryClosedMsg is sent when the overlay is dismissed without a selection.
+type HistoryClosedMsg struct{}
+
+type HistorySearch struct {
+	width          int
+	height         int
+	connectionName string
+	input          textinput.Model
+	entries        []history.Entry
+	matches        []history.Entry
+	cursor         int
+	err            error
+}
+
+var (
+	historyTimeStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#874BFD"))
+	historyMetaStyle  = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#888B7E", Dark: "#888B7E"})
+	historyErrorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5F87"))
+	historyCursor     = lipgloss.NewStyle().Foreground(lipgloss.Color("#F25D94")).Bold(true)
+)
+
+func NewHistorySearch(width int, height int, connectionName string) HistorySearch {
+	input := textinput.New()
+	input.Placeholder = "search history..."
+	input.Prompt = "(reverse-i-search) "
+	input.Focus()
+
+	entries, err := history.Read(connectionName)
+
+	return HistorySearch{
+		width:          width,
+		height:         height,
+		connectionName: connectionName,
+		input:          input,
+		entries:        entries,
+		matches:        entries,
+		err:            err,
+	}
+}
+
+func (m HistorySearch) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m HistorySearch) Update(msg tea.Msg) (HistorySearch, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "ctrl+g":
+			return m, func() tea.Msg { return HistoryClosedMsg{} }
+		case "enter":
+			if len(m.matches) == 0 {
+				return m, func() tea.Msg { return HistoryClosedMsg{} }
+			}
+			query := m.matches[m.cursor].Query
+			return m, func() tea.Msg { return HistorySelectedMsg{Query: query} }
+		case "up", "ctrl+p", "ctrl+r":
+			if m.cursor < len(m.matches)-1 {
+				m.cursor++
+			}
+			return m, nil
+		case "down", "ctrl+n":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	previous := m.input.Value()
+	m.input, cmd = m.input.Update(msg)
+	if m.input.Value() != previous {
+		m.matches = history.Search(m.entries, m.input.Value())
+		m.cursor = 0
+	}
+
+	return m, cmd
+}
+
+func (m HistorySearch) View() string {
+	width := min(max(m.width-10, 40), 120)
+	visible := max(m.height-12, 5)
+
+	lines := []string{}
+	for i, entry := range m.matches {
+		if i >= visible {
+			break
+		}
+
+		query := strings.Join(strings.Fields(entry.Query), " ")
+		meta := fmt.Sprintf("%s rows in %s", formatRowCount(entry.RowCount), entry.Duration.Round(1e6))
+		if entry.Error != "" {
+			meta = historyErrorStyle.Render("error: " + entry.Error)
+		}
+
+		prefix := "  "
+		if i == m.cursor {
+			prefix = historyCursor.Render("> ")
+		}
+
+		line := lipgloss.JoinHorizontal(
+			lipgloss.Top,
+			prefix,
+			historyTimeStyle.Render(entry.ExecutedAt.Format("2006-01-02 15:04")),
+			" ",
+			truncate(query, width-lipgloss.Width(meta)-24),
+			" ",
+			historyMetaStyle.Render(meta),
+		)
+		lines = append(lines, line)
+	}
+
+	// the newest entries sit at the bottom, next to the prompt, like a shell
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+
+	body := strings.Join(lines, "\n")
+	if m.err != nil {
+		body = historyErrorStyle.Render("Unable to read history: " + m.err.Error())
+	} else if len(m.matches) == 0 {
+		body = historyMetaStyle.Render("No matching queries for " + m.connectionName)
+	}
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		dialogBoxStyle.Width(width).Render(
+			lipgloss.JoinVertical(
+				lipgloss.Left,
+				body,
+				"",
+				m.input.View(),
+			),
+		),
+		lipgloss.WithWhitespaceChars(" "),
+		lipgloss.WithWhitespaceForeground(subtle),
+	)
+}
+
+func formatRowCount(rows int64) string {
+	if rows < 0 {
+		return "?"
+	}
+	return fmt.Sprintf("%d", rows)
+}
+
+func truncate(s string, width int) string {
+	if width <= 1 {
+		return ""
+	}
+
+	// cut between grapheme clusters so wide characters and emoji aren't split
+	return ansi.Truncate(s, width, "…")
+}
@@ -0,0 +1,331 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/therealphatmike/squeal/util/changes"
+	"github.com/therealphatmike/squeal/util/databases"
+	"github.com/therealphatmike/squeal/util/params"
+)
+
+// foreignValueLimit is how many referenced values are offered for a foreign
+// key column; past that they're suggestions for typing instead.
+const foreignValueLimit = 1000
+
+// select options that stand for leaving the column out and for NULL, which
+// can't be mistaken for values read from the database
+const (
+	useDefault = "\x00default"
+	useNull    = "\x00null"
+)
+
+// InsertRowCancelledMsg is sent when the insert form is dismissed.
+type InsertRowCancelledMsg struct{}
+
+// insertRowMsg asks for a form to insert a row into the table a query reads.
+type insertRowMsg struct {
+	query string
+}
+
+// foreignChoices are the values a foreign key column can take; all is false
+// when there were too many to list.
+type foreignChoices struct {
+	values []string
+	all    bool
+}
+
+type insertRowReadyMsg struct {
+	table   changes.Table
+	columns []databases.TableColumn
+	foreign map[string]foreignChoices
+	err     error
+}
+
+type insertField struct {
+	column databases.TableColumn
+	kind   string
+	value  *string
+}
+
+// InsertRow is a form for a new row, built from the table's definition, that
+// shows the INSERT it makes before running it.
+type InsertRow struct {
+	width   int
+	height  int
+	table   changes.Table
+	fields  []insertField
+	foreign map[string]foreignChoices
+	form    *huh.Form
+	// once the form is filled in, change is the row and confirm asks
+	// before inserting it
+	change  changes.Change
+	confirm *huh.Form
+	insert  *bool
+	err     error
+}
+
+func NewInsertRow(width int, height int, msg insertRowReadyMsg) InsertRow {
+	m := InsertRow{
+		width:   width,
+		height:  height,
+		table:   msg.table,
+		foreign: msg.foreign,
+	}
+	for _, column := range msg.columns {
+		if column.Generated {
+			continue
+		}
+		value := ""
+		m.fields = append(m.fields, insertField{column: column, kind: paramType(column.Type), value: &value})
+	}
+	m.form = m.buildForm()
+	return m
+}
+
+// paramType is the type hint a column's values are converted with, text for
+// anything the server is better at parsing.
+func paramType(columnType string) string {
+	base, _, _ := strings.Cut(strings.ToLower(columnType), "(")
+	base = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(base), " unsigned"))
+
+	switch base {
+	case "smallint", "integer", "bigint", "int", "tinyint", "mediumint", "int2", "int4", "int8",
+		"smallserial", "serial", "bigserial":
+		return params.TypeInteger
+	case "numeric", "decimal", "real", "double precision", "double", "float":
+		return params.TypeNumeric
+	case "boolean", "bool":
+		return params.TypeBoolean
+	}
+	return params.TypeText
+}
+
+func (m InsertRow) buildForm() *huh.Form {
+	fields := []huh.Field{}
+	for _, f := range m.fields {
+		fields = append(fields, m.field(f))
+	}
+
+	// a group per screenful keeps wide tables usable
+	groups := []*huh.Group{}
+	perGroup := max((m.height-12)/3, 3)
+	for start := 0; start < len(fields); start += perGroup {
+		groups = append(groups, huh.NewGroup(fields[start:min(start+perGroup, len(fields))]...))
+	}
+	if len(groups) == 0 {
+		groups = append(groups, huh.NewGroup(huh.NewNote().Title("Every column is generated by the server")))
+	}
+
+	return huh.NewForm(groups...).
+		WithWidth(m.boxWidth() - 2).
+		WithShowHelp(true).
+		WithShowErrors(true)
+}
+
+func (m InsertRow) boxWidth() int {
+	return min(max(m.width-10, 40), 100)
+}
+
+func (m InsertRow) field(f insertField) huh.Field {
+	column := f.column
+	about := []string{column.Type}
+	if !column.Nullable {
+		about = append(about, "not null")
+	}
+	if column.HasDefault {
+		about = append(about, "default "+column.Default)
+	}
+	if column.References != nil {
+		about = append(about, "references "+strings.Join(column.References.Table, ".")+"."+column.References.Column)
+	}
+
+	choices, hasChoices := m.foreign[column.Name]
+	if f.kind == params.TypeBoolean || hasChoices && choices.all {
+		options := []huh.Option[string]{}
+		if column.HasDefault {
+			options = append(options, huh.NewOption("(default)", useDefault))
+		}
+		if column.Nullable {
+			options = append(options, huh.NewOption("NULL", useNull))
+		}
+		values := choices.values
+		if f.kind == params.TypeBoolean {
+			values = []string{"true", "false"}
+		}
+		for _, value := range values {
+			options = append(options, huh.NewOption(value, value))
+		}
+
+		return huh.NewSelect[string]().
+			Title(column.Name).
+			Description(strings.Join(about, " · ")).
+			Options(options...).
+			Filtering(len(options) > 8).
+			Height(min(len(options), 8) + 2).
+			Value(f.value)
+	}
+
+	switch {
+	case column.HasDefault:
+		about = append(about, "leave empty for the default")
+	case column.Nullable:
+		about = append(about, "leave empty for NULL")
+	}
+	input := huh.NewInput().
+		Title(column.Name).
+		Description(strings.Join(about, " · ")).
+		Value(f.value).
+		Validate(func(s string) error {
+			_, _, err := f.resolve(s)
+			return err
+		})
+	if hasChoices {
+		input = input.Suggestions(choices.values)
+	}
+	return input
+}
+
+// resolve is what the typed text puts in the column: nothing, leaving it to
+// its default, NULL or a value converted for its type.
+func (f insertField) resolve(s string) (value any, omit bool, err error) {
+	switch {
+	case s == useDefault:
+		return nil, true, nil
+	case s == useNull:
+		return nil, false, nil
+	case s == "" && f.column.HasDefault:
+		return nil, true, nil
+	case s == "" && f.column.Nullable:
+		return nil, false, nil
+	case s == "" && f.kind != params.TypeText:
+		return nil, false, errors.New("a value is needed")
+	}
+	value, err = params.Convert(params.Value{Raw: s, Type: f.kind})
+	return value, false, err
+}
+
+func (m InsertRow) row() (changes.Change, error) {
+	change := changes.Change{Kind: changes.Insert}
+	for _, f := range m.fields {
+		value, omit, err := f.resolve(*f.value)
+		if err != nil {
+			return change, fmt.Errorf("%s: %w", f.column.Name, err)
+		}
+		if !omit {
+			change.Columns = append(change.Columns, f.column.Name)
+			change.Values = append(change.Values, value)
+		}
+	}
+	return change, nil
+}
+
+func (m InsertRow) Init() tea.Cmd {
+	return m.form.Init()
+}
+
+func (m InsertRow) Update(msg tea.Msg) (InsertRow, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+	case tea.KeyMsg:
+		if msg.String() == "esc" {
+			return m, func() tea.Msg { return InsertRowCancelledMsg{} }
+		}
+	}
+
+	if m.confirm != nil {
+		return m.updateConfirm(msg)
+	}
+
+	form, cmd := m.form.Update(msg)
+	if f, ok := form.(*huh.Form); ok {
+		m.form = f
+	}
+
+	switch m.form.State {
+	case huh.StateAborted:
+		return m, func() tea.Msg { return InsertRowCancelledMsg{} }
+	case huh.StateCompleted:
+		change, err := m.row()
+		if err != nil {
+			m.err = err
+			m.form = m.buildForm()
+			return m, m.form.Init()
+		}
+
+		insert := true
+		m.change, m.insert, m.err = change, &insert, nil
+		m.confirm = huh.NewForm(huh.NewGroup(
+			huh.NewConfirm().
+				Title("Insert this row?").
+				Affirmative("Insert").
+				Negative("Back").
+				Value(m.insert),
+		)).WithShowHelp(false)
+		return m, m.confirm.Init()
+	}
+
+	return m, cmd
+}
+
+func (m InsertRow) updateConfirm(msg tea.Msg) (InsertRow, tea.Cmd) {
+	form, cmd := m.confirm.Update(msg)
+	if f, ok := form.(*huh.Form); ok {
+		m.confirm = f
+	}
+
+	switch m.confirm.State {
+	case huh.StateAborted:
+		return m, func() tea.Msg { return InsertRowCancelledMsg{} }
+	case huh.StateCompleted:
+		if !*m.insert {
+			// back to the form, which still has what was typed
+			m.confirm = nil
+			m.form = m.buildForm()
+			return m, m.form.Init()
+		}
+		apply := applyChangesMsg{table: m.table, changes: []changes.Change{m.change}, inserted: true}
+		return m, func() tea.Msg { return apply }
+	}
+
+	return m, cmd
+}
+
+func (m InsertRow) View() string {
+	width := m.boxWidth()
+
+	body := m.form.View()
+	if m.confirm != nil {
+		body = lipgloss.JoinVertical(
+			lipgloss.Left,
+			historyMetaStyle.Width(width-4).Render(m.table.Preview(m.change)),
+			"",
+			m.confirm.View(),
+		)
+	}
+	if m.err != nil {
+		body = lipgloss.JoinVertical(lipgloss.Left, body, historyErrorStyle.Render(m.err.Error()))
+	}
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		dialogBoxStyle.Width(width).Render(
+			lipgloss.JoinVertical(
+				lipgloss.Left,
+				savedQueryNameStyle.Render("Insert into "+strings.Join(m.table.Name, ".")),
+				body,
+			),
+		),
+		lipgloss.WithWhitespaceChars(" "),
+		lipgloss.WithWhitespaceForeground(subtle),
+	)
+}
@@ -33,9 +33,9 @@ func NewDatabaseForm(width int, height int) NewDatabase {
 				huh.NewSelect[string]().
 					Key("engine").
 					Options(
-						huh.NewOption("PostgreSQL", "postgres"),
-						huh.NewOption("MySQL", "mysql"),
-						huh.NewOption("MariaDB", "maria"),
+						huh.NewOption("PostgreSQL", databases.EnginePostgres),
+						huh.NewOption("MySQL", databases.EngineMySQL),
+						huh.NewOption("MariaDB", databases.EngineMariaDB),
 					).
 					Title("Database Engine"),
 
@@ -66,6 +66,11 @@ func NewDatabaseForm(width int, height int) NewDatabase {
 					Title("Default Database").
 					Key("defaultDatabase"),
 
+				huh.NewInput().
+					Title("Tags").
+					Description("Comma separated. Tag a connection production to confirm destructive statements by typing its name.").
+					Key("tags"),
+
 				huh.NewConfirm().
 					Key("submit").
 					Affirmative("Create").
@@ -110,6 +115,7 @@ func (m NewDatabase) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			Host:            m.form.GetString("host"),
 			Port:            m.form.GetString("port"),
 			DefaultDatabase: m.form.GetString("defaultDatabase"),
+			Tags:            strings.Join(databases.ParseTags(m.form.GetString("tags")), ", "),
 		}
 		if err := databases.AddDatabaseConnection(db); err != nil {
 			cmds = append(cmds, tea.Quit)
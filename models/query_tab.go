@@ -0,0 +1,143 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/therealphatmike/squeal/util/history"
+)
+
+// QueryTab is one editor tab of a session with its own buffer, last result
+// and position in the connection's query history.
+type QueryTab struct {
+	id     int
+	name   string
+	editor textarea.Model
+
+	grid ResultGrid
+	// browse is the table the grid is a page of, nil for query results
+	browse *tableBrowse
+
+	// historyCursor is the index of the history entry shown in the editor,
+	// or -1 while editing the tab's own draft
+	historyCursor int
+	history       []history.Entry
+	draft         string
+
+	// savedName is the saved query the buffer was loaded from, which :w
+	// writes back to
+	savedName string
+	vim       vimState
+}
+
+func NewQueryTab(number int, width int, height int) QueryTab {
+	editor := textarea.New()
+	editor.Placeholder = "Write a query..."
+	editor.ShowLineNumbers = true
+	editor.CharLimit = 0
+	editor.MaxHeight = 0
+	editor.SetWidth(width)
+	editor.SetHeight(height)
+
+	// the session owns these keys, so keep only their non-ctrl bindings here
+	editor.KeyMap.DeleteCharacterForward = key.NewBinding(key.WithKeys("delete"))
+	editor.KeyMap.DeleteWordBackward = key.NewBinding(key.WithKeys("alt+backspace"))
+	editor.KeyMap.LineEnd = key.NewBinding(key.WithKeys("end"))
+	editor.KeyMap.LineNext = key.NewBinding(key.WithKeys("down"))
+	editor.KeyMap.LinePrevious = key.NewBinding(key.WithKeys("up"))
+	editor.KeyMap.WordForward = key.NewBinding(key.WithKeys("alt+right"))
+	editor.KeyMap.TransposeCharacterBackward = key.NewBinding(key.WithDisabled())
+	editor.Focus()
+
+	return QueryTab{
+		id:            number,
+		name:          fmt.Sprintf("query %d", number),
+		editor:        editor,
+		historyCursor: -1,
+	}
+}
+
+func (t QueryTab) Name() string {
+	return t.name
+}
+
+func (t *QueryTab) SetSize(width int, height int) {
+	t.editor.SetWidth(width)
+	t.editor.SetHeight(height)
+}
+
+// SetQuery replaces the buffer, for example with a query picked from history.
+func (t *QueryTab) SetQuery(query string) {
+	t.editor.SetValue(query)
+	t.historyCursor = -1
+}
+
+// StepHistory moves through the connection's history, newest first. Stepping
+// back past the newest entry restores the draft the user was writing.
+func (t *QueryTab) StepHistory(connectionName string, older bool) error {
+	if t.historyCursor == -1 {
+		entries, err := history.Read(connectionName)
+		if err != nil {
+			return err
+		}
+		t.history = entries
+		t.draft = t.editor.Value()
+	}
+
+	next := t.historyCursor - 1
+	if older {
+		next = t.historyCursor + 1
+	}
+	if next >= len(t.history) || next < -1 {
+		return nil
+	}
+
+	t.historyCursor = next
+	if next == -1 {
+		t.editor.SetValue(t.draft)
+	} else {
+		t.editor.SetValue(t.history[next].Query)
+	}
+
+	return nil
+}
+
+// CursorOffset is the byte offset of the editor cursor in the buffer.
+func (t QueryTab) CursorOffset() int {
+	lines := strings.Split(t.editor.Value(), "\n")
+	row := min(t.editor.Line(), len(lines)-1)
+
+	offset := 0
+	for _, line := range lines[:row] {
+		offset += len(line) + 1
+	}
+
+	info := t.editor.LineInfo()
+	runes := []rune(lines[row])
+	col := min(info.StartColumn+info.ColumnOffset, len(runes))
+
+	return offset + len(string(runes[:col]))
+}
+
+func (t QueryTab) runeCursor() int {
+	return len([]rune(t.editor.Value()[:t.CursorOffset()]))
+}
+
+// setRuneCursor moves the editor cursor to a rune offset in the buffer.
+func (t *QueryTab) setRuneCursor(offset int) {
+	buf := []rune(t.editor.Value())
+	offset = max(min(offset, len(buf)), 0)
+	row := lineNumber(buf, offset)
+	col := offset - lineStart(buf, offset)
+
+	// the textarea only moves a (possibly wrapped) line at a time
+	for i := 0; t.editor.Line() < row && i < len(buf); i++ {
+		t.editor.CursorDown()
+	}
+	for i := 0; t.editor.Line() > row && i < len(buf); i++ {
+		t.editor.CursorUp()
+	}
+	t.editor.SetCursor(col)
+}
@@ -0,0 +1,842 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/therealphatmike/squeal/util/changes"
+	"github.com/therealphatmike/squeal/util/databases"
+	"github.com/therealphatmike/squeal/util/layouts"
+	"github.com/therealphatmike/squeal/util/resultset"
+)
+
+// gridPageSize is how many rows are read from the server at a time.
+const gridPageSize = 500
+
+// minColumnWidth is as narrow as a column can be made, room for a character
+// and the truncation marker.
+const minColumnWidth = 2
+
+var (
+	gridGutterStyle   = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#888B7E", Dark: "#5C5C5C"})
+	gridCursorRow     = lipgloss.NewStyle().Background(lipgloss.AdaptiveColor{Light: "#EEEEEE", Dark: "#2A2A2A"})
+	gridCursorCell    = lipgloss.NewStyle().Reverse(true)
+	gridFooterStyle   = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#888B7E", Dark: "#888B7E"})
+	gridSeparator     = gridGutterStyle.Render(" │ ")
+	gridFrozenEdge    = gridGutterStyle.Render(" ┃ ")
+	gridSeparatorSize = 3
+)
+
+// gridPageMsg carries rows read from a result stream.
+type gridPageMsg struct {
+	stream *databases.RowStream
+	rows   [][]any
+	done   bool
+	err    error
+}
+
+func fetchPage(stream *databases.RowStream) tea.Cmd {
+	return func() tea.Msg {
+		rows, err := stream.Fetch(gridPageSize)
+		return gridPageMsg{stream: stream, rows: rows, done: stream.Done() || err != nil, err: err}
+	}
+}
+
+// ResultGrid shows a result set a window at a time. Rows are read from the
+// stream as the cursor nears the end of what has been fetched, and only the
+// last maxRows rows are kept, so results of any size use bounded memory.
+type ResultGrid struct {
+	columns []databases.Column
+	// fitted is the width each column needs for what's been fetched, capped
+	// at maxColumnWidth; sizes are widths the user picked, 0 to fit
+	fitted []int
+	sizes  []int
+	// order is the column indexes in display order, frozen how many of the
+	// leading visible columns stay put when scrolling sideways
+	order  []int
+	hidden []bool
+	frozen int
+	// query is what produced the result, the layout is remembered for it
+	query string
+
+	rows [][]any
+	// first is the row number of rows[0]; rows before it have been released
+	first    int
+	maxRows  int
+	stream   *databases.RowStream
+	fetching bool
+	fetchErr error
+
+	// shown is the indexes into rows left by sorting and filtering, in
+	// order, or nil when neither is in use
+	shown      []int
+	sorted     bool
+	sortColumn int
+	descending bool
+	nullsFirst bool
+	filters    []resultset.Filter
+
+	// search highlights matching cells; searchFrom is the cursor when the
+	// search started
+	search     string
+	searchFrom [2]int
+	prompting  gridPrompt
+	prompt     textinput.Model
+	promptErr  error
+
+	// a selection spans from anchor to the cursor, both positions; copyAs is
+	// the format cells are copied in, TSV when empty
+	selecting bool
+	anchor    [2]int
+	copyAs    string
+
+	// table is where edits are written back to, nil when the result can't
+	// be edited for the reason in notEditable; keyColumns are the indexes of
+	// its primary key columns. Edits are kept by row key in the order the
+	// rows were first touched, inserts are rows added by copying.
+	table       *changes.Table
+	notEditable string
+	keyColumns  []int
+	edits       map[string]*rowEdit
+	editOrder   []string
+	inserts     []changes.Change
+
+	// err and message are shown instead of a table for failed statements
+	// and statements that don't return rows
+	err     error
+	message string
+
+	// expanded shows the row under the cursor as a record instead of the
+	// table
+	expanded bool
+	// page is set when the grid shows a page of a table being browsed
+	page *pageInfo
+
+	row int
+	// column and left are positions among the visible columns
+	column int
+	top    int
+	left   int
+	vim    bool
+	render cellRenderer
+	width  int
+	height int
+}
+
+// NewResultGrid shows the outcome of a statement. Stream is the rest of the
+// result when the first page didn't exhaust it, layout is how the query's
+// columns were last arranged and render draws the values.
+func NewResultGrid(result databases.QueryResult, stream *databases.RowStream, err error, query string, layout layouts.Layout, maxRows int, vim bool, render cellRenderer) ResultGrid {
+	g := ResultGrid{
+		columns: result.Columns,
+		query:   query,
+		maxRows: max(maxRows, gridPageSize*2),
+		stream:  stream,
+		err:     err,
+		vim:     vim,
+		render:  render,
+	}
+
+	// statements like CALL or SELECT FROM t can return rows without any
+	// columns, which there's nothing to draw in a grid for
+	switch {
+	case err != nil:
+	case len(result.Columns) == 0 && len(result.Rows) > 0:
+		g.columns = nil
+		g.message = fmt.Sprintf("%s rows with no columns", formatCount(len(result.Rows)))
+		if stream != nil {
+			g.message = fmt.Sprintf("%s+ rows with no columns", formatCount(len(result.Rows)))
+		}
+	case len(result.Columns) == 0 && result.RowsAffected >= 0:
+		g.columns = nil
+		g.message = fmt.Sprintf("%d rows affected", result.RowsAffected)
+	case len(result.Columns) == 0:
+		g.columns = nil
+		g.message = "Done"
+	default:
+		g.fitted = make([]int, len(result.Columns))
+		for i, column := range result.Columns {
+			g.fitted[i] = min(lipgloss.Width(column.Name), maxColumnWidth)
+		}
+		g.applyLayout(layout)
+		g.appendRows(result.Rows)
+		g.prompt = textinput.New()
+	}
+
+	return g
+}
+
+func (g *ResultGrid) SetSize(width int, height int) {
+	g.width = width
+	g.height = height
+	g.scroll()
+}
+
+func (g ResultGrid) HasRows() bool {
+	return len(g.columns) > 0 && g.err == nil
+}
+
+// Streaming reports whether more rows can still be read from the server.
+func (g ResultGrid) Streaming() bool {
+	return g.stream != nil
+}
+
+// Close stops reading the rest of the result.
+func (g ResultGrid) Close() tea.Cmd {
+	stream := g.stream
+	if stream == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		// the result is being thrown away, so there's nobody to tell
+		_ = stream.Close()
+		return nil
+	}
+}
+
+func (g *ResultGrid) appendRows(rows [][]any) {
+	for _, row := range rows {
+		for i, value := range row {
+			g.fitted[i] = max(g.fitted[i], min(lipgloss.Width(g.render.render(g.columns[i], value)), maxColumnWidth))
+		}
+	}
+	g.rows = append(g.rows, rows...)
+
+	if drop := len(g.rows) - g.maxRows; drop > 0 {
+		g.rows = g.rows[drop:]
+		g.first += drop
+		if !g.arranged() {
+			g.row = max(g.row, g.first)
+			g.top = max(g.top, g.first)
+		}
+		for k := range g.shown {
+			// rearrange finds the cursor's row from these
+			g.shown[k] -= drop
+		}
+	}
+	if g.arranged() {
+		g.rearrange()
+	}
+	g.scroll()
+}
+
+// last is the row number after the last fetched row.
+func (g ResultGrid) last() int {
+	return g.first + len(g.rows)
+}
+
+func (g ResultGrid) Update(msg tea.Msg) (ResultGrid, tea.Cmd) {
+	switch msg := msg.(type) {
+	case gridPageMsg:
+		if msg.stream != g.stream {
+			return g, nil
+		}
+		g.fetching = false
+		g.appendRows(msg.rows)
+		if msg.done {
+			g.stream = nil
+		}
+		if msg.err != nil {
+			g.fetchErr = msg.err
+		}
+		return g, g.maybeFetch()
+	case tea.KeyMsg:
+		if !g.HasRows() {
+			return g, nil
+		}
+		if g.Typing() {
+			return g.updatePrompt(msg)
+		}
+		if cmd, ok := g.copyKey(msg.String()); ok {
+			return g, cmd
+		}
+		if cmd, ok := g.pageKey(msg.String()); ok {
+			return g, cmd
+		}
+		if cmd, ok := g.editKey(msg.String()); ok {
+			return g, cmd
+		}
+
+		switch msg.String() {
+		case "enter":
+			return g, g.inspect()
+		case "/":
+			return g, g.startPrompt(searchPrompt)
+		case "F":
+			return g, g.startPrompt(filterPrompt)
+		case "s":
+			if i, ok := g.cursorColumn(); ok {
+				g.sortBy(i)
+			}
+			return g, nil
+		case "S":
+			g.nullsFirst = !g.nullsFirst
+			g.rearrange()
+			return g, nil
+		case "r":
+			g.expanded = !g.expanded
+			return g, nil
+		case "n", "N":
+			g.findMatch(msg.String() == "n", true)
+			g.scroll()
+			return g, g.maybeFetch()
+		}
+
+		if g.arrange(msg.String()) {
+			g.selecting = false
+			g.scroll()
+			return g, g.saveLayout()
+		}
+		g.move(msg.String())
+		return g, g.maybeFetch()
+	}
+
+	return g, nil
+}
+
+func (g *ResultGrid) move(key string) {
+	if g.vim {
+		switch key {
+		case "h":
+			key = "left"
+		case "j":
+			key = "down"
+		case "k":
+			key = "up"
+		case "l":
+			key = "right"
+		case "0", "^":
+			key = "home"
+		case "$":
+			key = "end"
+		case "g":
+			key = "ctrl+home"
+		case "G":
+			key = "ctrl+end"
+		case "ctrl+f":
+			key = "pgdown"
+		case "ctrl+b":
+			key = "pgup"
+		}
+	}
+
+	if g.expanded {
+		key = recordKey(key)
+	}
+
+	page := max(g.visibleRows()-1, 1)
+	switch key {
+	case "up":
+		g.row--
+	case "down":
+		g.row++
+	case "left":
+		g.column--
+	case "right":
+		g.column++
+	case "pgup":
+		g.row -= page
+	case "pgdown":
+		g.row += page
+	case "home":
+		g.column = 0
+	case "end":
+		g.column = len(g.visible()) - 1
+	case "ctrl+home":
+		g.row = g.firstRow()
+	case "ctrl+end":
+		g.row = g.endRow() - 1
+	}
+
+	g.row = max(min(g.row, g.endRow()-1), g.firstRow())
+	g.column = max(min(g.column, len(g.visible())-1), 0)
+	g.scroll()
+}
+
+// Rows is what the grid shows: the visible columns in display order and the
+// fetched rows that pass the filters, in sorted order.
+func (g ResultGrid) Rows() ([]databases.Column, [][]any) {
+	visible := g.visible()
+	columns := make([]databases.Column, len(visible))
+	for c, i := range visible {
+		columns[c] = g.columns[i]
+	}
+
+	rows := make([][]any, 0, g.endRow()-g.firstRow())
+	for r := g.firstRow(); r < g.endRow(); r++ {
+		row := g.rowAt(r)
+		projected := make([]any, len(visible))
+		for c, i := range visible {
+			projected[c] = row[i]
+		}
+		rows = append(rows, projected)
+	}
+	return columns, rows
+}
+
+// Partial reports whether the result has rows the grid doesn't hold, either
+// released or not yet fetched.
+func (g ResultGrid) Partial() bool {
+	return g.first > 0 || g.stream != nil
+}
+
+// inspect opens the cell under the cursor in the inspector.
+func (g ResultGrid) inspect() tea.Cmd {
+	i, ok := g.cursorColumn()
+	if !ok || g.endRow() == g.firstRow() {
+		return nil
+	}
+
+	column, value := g.columns[i], g.rowAt(g.row)[i]
+	return func() tea.Msg { return inspectCellMsg{column: column, value: value} }
+}
+
+// arrange handles the keys that change the column layout: - and + narrow and
+// widen the column, = fits it to everything fetched so far, < and > move it,
+// x hides it and X shows hidden columns again, and f freezes the columns up
+// to and including it (or unfreezes them when they already are).
+func (g *ResultGrid) arrange(key string) bool {
+	visible := g.visible()
+	i, ok := g.cursorColumn()
+	if !ok {
+		return false
+	}
+
+	switch key {
+	case "-":
+		g.sizes[i] = max(g.columnWidth(i)-2, minColumnWidth)
+	case "+":
+		g.sizes[i] = g.columnWidth(i) + 2
+	case "=":
+		g.sizes[i] = min(g.contentWidth(i), max(g.width-g.gutterWidth()-gridSeparatorSize*2, minColumnWidth))
+	case "<", ">":
+		to := g.column - 1
+		if key == ">" {
+			to = g.column + 1
+		}
+		if to < 0 || to >= len(visible) {
+			return false
+		}
+		a, b := g.orderOf(visible[g.column]), g.orderOf(visible[to])
+		g.order[a], g.order[b] = g.order[b], g.order[a]
+		g.column = to
+	case "x":
+		if len(visible) == 1 {
+			return false
+		}
+		g.hidden[i] = true
+		if g.column < g.frozen {
+			g.frozen--
+		}
+		g.column = min(g.column, len(visible)-2)
+	case "X":
+		for c := range g.hidden {
+			g.hidden[c] = false
+		}
+		g.column = g.positionOf(i)
+	case "f":
+		if g.frozen == g.column+1 {
+			g.frozen = 0
+		} else {
+			g.frozen = g.column + 1
+		}
+	default:
+		return false
+	}
+
+	return true
+}
+
+// visible is the indexes of the columns that aren't hidden, in display order.
+func (g ResultGrid) visible() []int {
+	visible := []int{}
+	for _, i := range g.order {
+		if !g.hidden[i] {
+			visible = append(visible, i)
+		}
+	}
+	return visible
+}
+
+// cursorColumn is the index of the column under the cursor, false when no
+// column shows.
+func (g ResultGrid) cursorColumn() (int, bool) {
+	visible := g.visible()
+	if g.column < 0 || g.column >= len(visible) {
+		return 0, false
+	}
+	return visible[g.column], true
+}
+
+func (g ResultGrid) orderOf(column int) int {
+	for position, i := range g.order {
+		if i == column {
+			return position
+		}
+	}
+	return -1
+}
+
+func (g ResultGrid) positionOf(column int) int {
+	for position, i := range g.visible() {
+		if i == column {
+			return position
+		}
+	}
+	return 0
+}
+
+func (g ResultGrid) columnWidth(i int) int {
+	if g.sizes[i] > 0 {
+		return g.sizes[i]
+	}
+	return g.fitted[i]
+}
+
+// contentWidth is the width of the widest value fetched for the column,
+// ignoring maxColumnWidth.
+func (g ResultGrid) contentWidth(i int) int {
+	width := lipgloss.Width(g.columns[i].Name)
+	for _, row := range g.rows {
+		width = max(width, lipgloss.Width(g.render.render(g.columns[i], row[i])))
+	}
+	return width
+}
+
+// applyLayout arranges the columns as they were last time. Columns are matched
+// by name; any the layout doesn't mention keep their place after the rest.
+func (g *ResultGrid) applyLayout(layout layouts.Layout) {
+	g.sizes = make([]int, len(g.columns))
+	g.hidden = make([]bool, len(g.columns))
+
+	byName := map[string][]int{}
+	for i, column := range g.columns {
+		byName[column.Name] = append(byName[column.Name], i)
+	}
+
+	placed := make([]bool, len(g.columns))
+	for _, name := range layout.Order {
+		if indexes := byName[name]; len(indexes) > 0 {
+			g.order = append(g.order, indexes[0])
+			placed[indexes[0]] = true
+			byName[name] = indexes[1:]
+		}
+	}
+	for i := range g.columns {
+		if !placed[i] {
+			g.order = append(g.order, i)
+		}
+	}
+
+	hidden := map[string]bool{}
+	for _, name := range layout.Hidden {
+		hidden[name] = true
+	}
+	for i, column := range g.columns {
+		g.hidden[i] = hidden[column.Name]
+		g.sizes[i] = max(layout.Widths[column.Name], 0)
+	}
+	if len(g.visible()) == 0 {
+		g.hidden = make([]bool, len(g.columns))
+	}
+
+	g.frozen = min(max(layout.Frozen, 0), len(g.visible()))
+}
+
+func (g ResultGrid) layout() layouts.Layout {
+	layout := layouts.Layout{Frozen: g.frozen, Widths: map[string]int{}}
+	for _, i := range g.order {
+		name := g.columns[i].Name
+		layout.Order = append(layout.Order, name)
+		if g.hidden[i] {
+			layout.Hidden = append(layout.Hidden, name)
+		}
+		if g.sizes[i] > 0 {
+			layout.Widths[name] = g.sizes[i]
+		}
+	}
+	return layout
+}
+
+func (g ResultGrid) saveLayout() tea.Cmd {
+	if g.query == "" {
+		return nil
+	}
+
+	// stamped now, in the order the changes were made, since the saves
+	// themselves can run in any order
+	query, layout := g.query, g.layout()
+	layout.SavedAt = time.Now()
+	return func() tea.Msg {
+		// the layout is a convenience, not worth interrupting anyone over
+		_ = layouts.Save(query, layout)
+		return nil
+	}
+}
+
+func (g ResultGrid) gutterWidth() int {
+	return len(formatCount(g.last()))
+}
+
+// scroll moves the window just enough to keep the cursor in view.
+func (g *ResultGrid) scroll() {
+	if !g.HasRows() {
+		return
+	}
+
+	rows := g.visibleRows()
+	if g.row < g.top {
+		g.top = g.row
+	}
+	if g.row >= g.top+rows {
+		g.top = g.row - rows + 1
+	}
+	g.top = max(g.top, g.firstRow())
+
+	visible := g.visible()
+	g.frozen = min(g.frozen, len(visible))
+	g.left = max(g.left, g.frozen)
+	if g.column < g.frozen {
+		return
+	}
+
+	available := g.width - g.gutterWidth() - gridSeparatorSize - g.columnsWidth(visible[:g.frozen])
+	if g.column < g.left {
+		g.left = g.column
+	}
+	for g.left < g.column && g.columnsWidth(visible[g.left:g.column+1]) > available {
+		g.left++
+	}
+}
+
+// maybeFetch reads the next page once the cursor is within half a page of
+// the end of the fetched rows.
+func (g *ResultGrid) maybeFetch() tea.Cmd {
+	if g.stream == nil || g.fetching || g.arranged() || g.row < g.last()-gridPageSize/2 {
+		return nil
+	}
+	g.fetching = true
+	return fetchPage(g.stream)
+}
+
+func (g ResultGrid) visibleRows() int {
+	// the header and footer take a line each
+	return max(g.bodyHeight()-2, 1)
+}
+
+// bodyHeight is the height left for the rows and footer, less the query bar
+// over a browsed table.
+func (g ResultGrid) bodyHeight() int {
+	if g.page != nil {
+		return g.height - 1
+	}
+	return g.height
+}
+
+func formatCount(n int) string {
+	digits := strconv.Itoa(n)
+	var out strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			out.WriteByte(',')
+		}
+		out.WriteRune(digit)
+	}
+	return out.String()
+}
+
+func (g ResultGrid) footer() string {
+	total := formatCount(g.last())
+	if g.stream != nil {
+		total += "+"
+	}
+
+	if g.Typing() {
+		footer := g.prompt.View()
+		if g.promptErr != nil {
+			footer += "  " + historyErrorStyle.Render(g.promptErr.Error())
+		}
+		return footer
+	}
+
+	parts := []string{fmt.Sprintf("row %s of %s", formatCount(g.row+1), total)}
+	switch {
+	case g.arranged() && len(g.shown) == 0:
+		parts = []string{"no fetched rows match"}
+	case g.arranged():
+		parts = []string{fmt.Sprintf("row %s of %s", formatCount(g.row+1), formatCount(len(g.shown)))}
+	case g.last() == 0 && g.stream == nil:
+		parts = []string{"no rows"}
+	}
+	if g.page != nil {
+		parts = append(parts, g.pageFooter())
+	}
+	if visible := g.visible(); len(visible) > 0 {
+		column := fmt.Sprintf("col %d of %d %s", g.column+1, len(visible), g.columns[visible[g.column]].Name)
+		if g.selecting {
+			top, left, bottom, right := g.selection()
+			column += " · selecting " + describeCells(bottom-top+1, right-left+1)
+		}
+		if hidden := len(g.columns) - len(visible); hidden > 0 {
+			column += fmt.Sprintf(" · %d hidden", hidden)
+		}
+		if g.frozen > 0 {
+			column += fmt.Sprintf(" · %d frozen", g.frozen)
+		}
+		parts = append(parts, column)
+	}
+	if g.first > 0 {
+		parts = append(parts, fmt.Sprintf("rows before %s released", formatCount(g.first+1)))
+	}
+	if g.fetching {
+		parts = append(parts, "fetching...")
+	}
+	switch {
+	case errors.Is(g.fetchErr, databases.ErrSuperseded):
+		parts = append(parts, "stopped fetching, a later statement closed the result")
+	case g.fetchErr != nil:
+		parts = append(parts, historyErrorStyle.Render("fetch failed: "+g.fetchErr.Error()))
+	}
+
+	if g.expanded {
+		parts = append(parts, "record view, ←/→ for other rows")
+	}
+	parts = append(parts, g.arrangement()...)
+	if g.copyAs != "" {
+		parts = append(parts, "copy as "+g.copyAs)
+	}
+	if pending := g.Pending(); pending > 0 {
+		parts = append(parts, gridEditedStyle.Render(fmt.Sprintf("%d pending changes · w to review", pending)))
+	}
+
+	return gridFooterStyle.Render(strings.Join(parts, " · "))
+}
+
+// View draws the visible window of the result.
+func (g ResultGrid) View() string {
+	switch {
+	case g.err != nil:
+		return historyErrorStyle.Width(g.width).Render(g.err.Error())
+	case g.message != "":
+		return g.message
+	case len(g.columns) == 0:
+		return resultNullStyle.Render("Run a query with alt+enter, or the whole buffer with F5")
+	}
+
+	var view string
+	if g.expanded {
+		view = g.recordView()
+	} else {
+		view = g.gridView()
+	}
+	if g.page != nil {
+		return lipgloss.JoinVertical(lipgloss.Left, g.sqlBar(), view)
+	}
+	return view
+}
+
+// gridView draws the rows as a table.
+func (g ResultGrid) gridView() string {
+	width, height := g.width, g.bodyHeight()
+	rows := g.visibleRows()
+	gutter := g.gutterWidth()
+	visible := g.visible()
+	current := visible[g.column]
+
+	// frozen columns always show, then the rest from left until the width
+	// runs out
+	shown := append([]int{}, visible[:g.frozen]...)
+	used := g.columnsWidth(shown)
+	available := width - gutter - gridSeparatorSize
+	for position := g.left; position < len(visible); position++ {
+		i := visible[position]
+		if len(shown) > 0 && used+g.columnWidth(i) > available {
+			break
+		}
+		shown = append(shown, i)
+		used += g.columnWidth(i) + gridSeparatorSize
+	}
+
+	join := func(cells []string) string {
+		line := ""
+		for n, c := range cells {
+			switch {
+			case n == 0:
+			case n == g.frozen:
+				line += gridFrozenEdge
+			default:
+				line += gridSeparator
+			}
+			line += c
+		}
+		return line
+	}
+
+	cell := func(s string, w int, right bool) string {
+		// padded by hand since word wrapping measures emoji sequences
+		// differently and would break the line
+		s = truncate(s, w)
+		padding := strings.Repeat(" ", max(w-lipgloss.Width(s), 0))
+		if right {
+			return padding + s
+		}
+		return s + padding
+	}
+
+	header := []string{}
+	for _, i := range shown {
+		header = append(header, resultHeaderStyle.Render(cell(singleLine(g.columns[i].Name), g.columnWidth(i), g.render.alignRight(g.columns[i]))))
+	}
+	lines := []string{
+		strings.Repeat(" ", gutter) + gridSeparator + join(header),
+	}
+
+	end := min(g.top+rows, g.endRow())
+	for r := g.top; r < end; r++ {
+		row := g.rowAt(r)
+		edit := g.editOf(row)
+		cells := []string{}
+		for _, i := range shown {
+			value := g.currentValue(row, i)
+			_, edited := edit.valueOf(i)
+			text := cell(g.render.render(g.columns[i], value), g.columnWidth(i), value != nil && g.render.alignRight(g.columns[i]))
+			switch {
+			case r == g.row && i == current:
+				text = gridCursorCell.Render(text)
+			case edit != nil && edit.deleted:
+				text = gridDeletedStyle.Render(text)
+			case edited:
+				text = gridEditedStyle.Render(text)
+			case g.selected(r, g.positionOf(i)):
+				text = gridSelected.Render(text)
+			case g.cellMatches(value):
+				text = gridSearchMatch.Render(text)
+			case value == nil:
+				text = resultNullStyle.Render(text)
+			case r == g.row:
+				text = gridCursorRow.Render(text)
+			}
+			cells = append(cells, text)
+		}
+		number := gridGutterStyle.Render(fmt.Sprintf("%*s", gutter, formatCount(g.rowNumber(r)+1)))
+		lines = append(lines, number+gridSeparator+join(cells))
+	}
+
+	table := lipgloss.NewStyle().MaxWidth(width).Height(height - 1).Render(strings.Join(lines, "\n"))
+	return lipgloss.JoinVertical(lipgloss.Left, table, lipgloss.NewStyle().MaxWidth(width).Render(g.footer()))
+}
+
+// columnsWidth is the width taken by the columns and their separators.
+func (g ResultGrid) columnsWidth(columns []int) int {
+	width := 0
+	for _, i := range columns {
+		width += g.columnWidth(i) + gridSeparatorSize
+	}
+	return width
+}
@@ -0,0 +1,179 @@
+package models
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/therealphatmike/squeal/util/databases"
+	"github.com/therealphatmike/squeal/util/export"
+	"github.com/therealphatmike/squeal/util/statements"
+)
+
+// copyFormats are what c cycles the grid's copy format through.
+var copyFormats = []string{export.FormatTSV, export.FormatCSV, export.FormatJSON, export.FormatSQL}
+
+var gridSelected = lipgloss.NewStyle().Background(lipgloss.AdaptiveColor{Light: "#D6E4FF", Dark: "#2F3B57"})
+
+// copyCellsMsg asks the session to put cells on the clipboard; what
+// describes them for the status line.
+type copyCellsMsg struct {
+	columns []databases.Column
+	rows    [][]any
+	format  string
+	query   string
+	what    string
+}
+
+type copiedMsg struct {
+	what   string
+	format string
+	err    error
+}
+
+// Selecting reports whether a block of cells is being selected, in which case
+// esc clears the selection rather than leaving the grid.
+func (g ResultGrid) Selecting() bool {
+	return g.selecting
+}
+
+// copyKey handles the keys for selecting and copying: v starts or drops a
+// selection, y copies the selection or the cell, Y the rows it covers, C the
+// columns it covers, and c picks the format.
+func (g *ResultGrid) copyKey(key string) (tea.Cmd, bool) {
+	switch key {
+	case "v":
+		g.selecting = !g.selecting
+		g.anchor = [2]int{g.row, g.column}
+		return nil, true
+	case "esc":
+		if !g.selecting {
+			return nil, false
+		}
+		g.selecting = false
+		return nil, true
+	case "c":
+		for i, format := range copyFormats {
+			if format == g.copyFormat() {
+				g.copyAs = copyFormats[(i+1)%len(copyFormats)]
+				break
+			}
+		}
+		return nil, true
+	case "y", "Y", "C":
+	default:
+		return nil, false
+	}
+
+	if g.endRow() == g.firstRow() || len(g.visible()) == 0 {
+		return nil, true
+	}
+	top, left, bottom, right := g.selection()
+	switch key {
+	case "Y":
+		left, right = 0, len(g.visible())-1
+	case "C":
+		top, bottom = g.firstRow(), g.endRow()-1
+	}
+	g.selecting = false
+
+	visible := g.visible()[left : right+1]
+	columns := make([]databases.Column, len(visible))
+	for c, i := range visible {
+		columns[c] = g.columns[i]
+	}
+	rows := [][]any{}
+	for r := top; r <= bottom; r++ {
+		row := g.rowAt(r)
+		copied := make([]any, len(visible))
+		for c, i := range visible {
+			copied[c] = row[i]
+		}
+		rows = append(rows, copied)
+	}
+
+	msg := copyCellsMsg{columns: columns, rows: rows, format: g.copyFormat(), query: g.query, what: describeCells(len(rows), len(columns))}
+	return func() tea.Msg { return msg }, true
+}
+
+// selection is the block of cells covered by the selection, or just the cursor
+// without one, as row positions and visible column positions.
+func (g ResultGrid) selection() (top int, left int, bottom int, right int) {
+	if !g.selecting {
+		return g.row, g.column, g.row, g.column
+	}
+	return min(g.anchor[0], g.row), min(g.anchor[1], g.column), max(g.anchor[0], g.row), max(g.anchor[1], g.column)
+}
+
+func (g ResultGrid) selected(r int, position int) bool {
+	if !g.selecting {
+		return false
+	}
+	top, left, bottom, right := g.selection()
+	return r >= top && r <= bottom && position >= left && position <= right
+}
+
+func (g ResultGrid) copyFormat() string {
+	if g.copyAs == "" {
+		return export.FormatTSV
+	}
+	return g.copyAs
+}
+
+func describeCells(rows int, columns int) string {
+	switch {
+	case rows == 1 && columns == 1:
+		return "cell"
+	case rows == 1:
+		return fmt.Sprintf("row of %d cells", columns)
+	case columns == 1:
+		return fmt.Sprintf("%s values", formatCount(rows))
+	}
+	return fmt.Sprintf("%s rows × %d columns", formatCount(rows), columns)
+}
+
+// clipboardText formats cells for pasting. A single cell copied as TSV or CSV
+// is just its text so it can be pasted anywhere; anything bigger gets a
+// header line. INSERTs target the table the query read from when there's
+// just the one.
+func clipboardText(msg copyCellsMsg, engine string) (string, error) {
+	opts := export.Defaults(msg.format)
+	opts.Engine = engine
+	if name, ok := statements.SourceTable(msg.query, engine); ok {
+		opts.Table = strings.Join(name, ".")
+	}
+	if len(msg.rows) == 1 && len(msg.columns) == 1 && (msg.format == export.FormatTSV || msg.format == export.FormatCSV) {
+		opts.Header = false
+		opts.Quoting = export.QuoteNone
+	}
+
+	var out strings.Builder
+	if err := export.Write(&out, msg.columns, msg.rows, opts); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(out.String(), "\n"), nil
+}
+
+// copyToClipboard sets the clipboard both ways: OSC52 asks the terminal to do
+// it, which works over SSH, and the local clipboard is set too for terminals
+// that ignore OSC52. Only when neither can be tried is it an error.
+func copyToClipboard(msg copyCellsMsg, engine string) tea.Cmd {
+	return func() tea.Msg {
+		copied := copiedMsg{what: msg.what, format: msg.format}
+		text, err := clipboardText(msg, engine)
+		if err != nil {
+			copied.err = err
+			return copied
+		}
+
+		_, osc52 := os.Stdout.WriteString(ansi.SetSystemClipboard(text))
+		if local := clipboard.WriteAll(text); local != nil && osc52 != nil {
+			copied.err = local
+		}
+		return copied
+	}
+}
@@ -0,0 +1,347 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/therealphatmike/squeal/util/changes"
+)
+
+var (
+	gridEditedStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFB86C")).Bold(true)
+	gridDeletedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5F87")).Strikethrough(true)
+)
+
+// gridStatusMsg is something the grid wants said in the status line.
+type gridStatusMsg struct {
+	text string
+}
+
+// reviewChangesMsg asks for the pending edits to be shown before they're
+// applied. Summaries describe each change with the values it replaces.
+type reviewChangesMsg struct {
+	table     changes.Table
+	changes   []changes.Change
+	summaries []string
+}
+
+// rowEdit is what's pending for a row of the result, which is found again by
+// its primary key since rows move when sorted and are released as more are
+// fetched.
+type rowEdit struct {
+	key     []any
+	values  map[int]any
+	deleted bool
+}
+
+// valueOf is the column's new value, if it's been edited; it's safe to call on
+// a nil edit.
+func (e *rowEdit) valueOf(column int) (any, bool) {
+	if e == nil {
+		return nil, false
+	}
+	value, ok := e.values[column]
+	return value, ok
+}
+
+// Edits are held in the grid until they're reviewed and applied; nothing is
+// sent to the server before then.
+
+// startEdit fills the prompt with the cell under the cursor.
+func (g *ResultGrid) startEdit() {
+	i, ok := g.cursorColumn()
+	if !ok {
+		return
+	}
+	g.prompt.Prompt = "set " + g.columns[i].Name + " = "
+	g.prompt.Placeholder = "ctrl+n for NULL"
+	g.prompt.SetValue("")
+	if value := g.currentValue(g.rowAt(g.row), i); value != nil {
+		g.prompt.SetValue(displayValue(value))
+	}
+}
+
+// SetTable makes the result editable, writing changes back to the table, when
+// the result has its primary key columns. Reason says why it can't be edited
+// when table is nil.
+func (g *ResultGrid) SetTable(table *changes.Table, reason string) {
+	g.table, g.notEditable = nil, reason
+	if table == nil {
+		return
+	}
+
+	g.keyColumns = []int{}
+	for _, name := range table.Key {
+		found := -1
+		for i, column := range g.columns {
+			if column.Name == name || found < 0 && strings.EqualFold(column.Name, name) {
+				found = i
+			}
+		}
+		if found < 0 {
+			g.notEditable = fmt.Sprintf("the result doesn't include %s, part of the primary key", name)
+			return
+		}
+		g.keyColumns = append(g.keyColumns, found)
+	}
+	g.table = table
+	g.edits = map[string]*rowEdit{}
+}
+
+// Pending is how many changes are waiting to be applied.
+func (g ResultGrid) Pending() int {
+	pending := len(g.inserts)
+	for _, edit := range g.edits {
+		if edit.deleted || len(edit.values) > 0 {
+			pending++
+		}
+	}
+	return pending
+}
+
+func (g ResultGrid) rowKey(row []any) string {
+	parts := make([]string, len(g.keyColumns))
+	for n, i := range g.keyColumns {
+		parts[n] = displayValue(row[i])
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// editOf is what's pending for the row, or nil.
+func (g ResultGrid) editOf(row []any) *rowEdit {
+	if len(g.edits) == 0 {
+		return nil
+	}
+	return g.edits[g.rowKey(row)]
+}
+
+// editFor is the row's edit, started when there isn't one yet.
+func (g *ResultGrid) editFor(row []any) *rowEdit {
+	key := g.rowKey(row)
+	if edit, ok := g.edits[key]; ok {
+		return edit
+	}
+
+	edit := &rowEdit{values: map[int]any{}}
+	for _, i := range g.keyColumns {
+		edit.key = append(edit.key, row[i])
+	}
+	g.edits[key] = edit
+	g.editOrder = append(g.editOrder, key)
+	return edit
+}
+
+// editKey handles the keys that change rows: e edits the cell, d marks the row
+// for deletion, D copies it as a new row, u undoes its changes and w reviews
+// everything pending. i opens a form for a new row, which only needs the
+// result to come from a single table.
+func (g *ResultGrid) editKey(key string) (tea.Cmd, bool) {
+	switch key {
+	case "i":
+		msg := insertRowMsg{query: g.query}
+		return func() tea.Msg { return msg }, true
+	case "e", "d", "D", "u", "w":
+	default:
+		return nil, false
+	}
+
+	if g.table == nil {
+		return g.status("This result can't be edited: " + g.notEditable), true
+	}
+	if key == "w" {
+		return g.review(), true
+	}
+	if g.endRow() == g.firstRow() {
+		return nil, true
+	}
+
+	row := g.rowAt(g.row)
+	switch key {
+	case "e":
+		if edit := g.editOf(row); edit != nil && edit.deleted {
+			return g.status("The row is marked for deletion, u undoes that"), true
+		}
+		return g.startPrompt(editPrompt), true
+	case "d":
+		edit := g.editFor(row)
+		edit.deleted = !edit.deleted
+	case "D":
+		insert := changes.Change{Kind: changes.Insert}
+		for i := range g.columns {
+			if !g.isKey(i) {
+				insert.Columns = append(insert.Columns, g.columns[i].Name)
+				insert.Values = append(insert.Values, g.currentValue(row, i))
+			}
+		}
+		g.inserts = append(g.inserts, insert)
+		return g.status("Copied the row as a new one, leaving out the primary key; w to review"), true
+	case "u":
+		if edit := g.editOf(row); edit != nil {
+			edit.deleted = false
+			edit.values = map[int]any{}
+		}
+	}
+	return nil, true
+}
+
+func (g ResultGrid) status(text string) tea.Cmd {
+	return func() tea.Msg { return gridStatusMsg{text: text} }
+}
+
+func (g ResultGrid) isKey(column int) bool {
+	for _, i := range g.keyColumns {
+		if i == column {
+			return true
+		}
+	}
+	return false
+}
+
+// currentValue is the cell's value with any pending edit.
+func (g ResultGrid) currentValue(row []any, column int) any {
+	if value, ok := g.editOf(row).valueOf(column); ok {
+		return value
+	}
+	return row[column]
+}
+
+// setCell records a new value for the cell under the cursor, forgetting the
+// edit when it puts back what was read.
+func (g *ResultGrid) setCell(value any) {
+	i, ok := g.cursorColumn()
+	if !ok {
+		return
+	}
+	row := g.rowAt(g.row)
+	edit := g.editFor(row)
+
+	original := row[i]
+	unchanged := value == nil && original == nil ||
+		value != nil && original != nil && value.(string) == displayValue(original)
+	if unchanged {
+		delete(edit.values, i)
+		return
+	}
+	edit.values[i] = value
+}
+
+// Changes are the pending edits as statements would make them: deletes and
+// updates in the order the rows were first touched, then inserts. Summaries
+// describe each one for review.
+func (g ResultGrid) Changes() ([]changes.Change, []string) {
+	found := map[string][]any{}
+	for _, row := range g.rows {
+		if len(g.edits) > 0 {
+			found[g.rowKey(row)] = row
+		}
+	}
+
+	list, summaries := []changes.Change{}, []string{}
+	for _, key := range g.editOrder {
+		edit := g.edits[key]
+		where := g.describeKey(edit.key)
+		switch {
+		case edit.deleted:
+			list = append(list, changes.Change{Kind: changes.Delete, Key: edit.key})
+			summaries = append(summaries, "delete the row where "+where)
+		case len(edit.values) > 0:
+			columns := []int{}
+			for i := range edit.values {
+				columns = append(columns, i)
+			}
+			sort.Ints(columns)
+
+			update := changes.Change{Kind: changes.Update, Key: edit.key}
+			diffs := []string{}
+			for _, i := range columns {
+				update.Columns = append(update.Columns, g.columns[i].Name)
+				update.Values = append(update.Values, edit.values[i])
+				before := "?"
+				if row, ok := found[key]; ok {
+					before = describeValue(row[i])
+				}
+				diffs = append(diffs, fmt.Sprintf("%s: %s → %s", g.columns[i].Name, before, describeValue(edit.values[i])))
+			}
+			list = append(list, update)
+			summaries = append(summaries, "update the row where "+where+": "+strings.Join(diffs, ", "))
+		}
+	}
+
+	for _, insert := range g.inserts {
+		values := []string{}
+		for n, column := range insert.Columns {
+			values = append(values, column+" = "+describeValue(insert.Values[n]))
+		}
+		list = append(list, insert)
+		summaries = append(summaries, "insert a row with "+strings.Join(values, ", "))
+	}
+	return list, summaries
+}
+
+func (g ResultGrid) describeKey(key []any) string {
+	parts := []string{}
+	for n, i := range g.keyColumns {
+		parts = append(parts, g.columns[i].Name+" = "+describeValue(key[n]))
+	}
+	return strings.Join(parts, " and ")
+}
+
+func describeValue(value any) string {
+	switch value.(type) {
+	case nil:
+		return "NULL"
+	case string, []byte:
+		return fmt.Sprintf("%q", truncate(displayValue(value), 40))
+	}
+	return truncate(displayValue(value), 40)
+}
+
+func (g ResultGrid) review() tea.Cmd {
+	list, summaries := g.Changes()
+	if len(list) == 0 {
+		return g.status("No changes to review; e edits a cell, d deletes a row and D copies one")
+	}
+
+	msg := reviewChangesMsg{table: *g.table, changes: list, summaries: summaries}
+	return func() tea.Msg { return msg }
+}
+
+// DiscardChanges forgets everything pending.
+func (g *ResultGrid) DiscardChanges() {
+	if g.table != nil {
+		g.edits = map[string]*rowEdit{}
+	}
+	g.editOrder = nil
+	g.inserts = nil
+}
+
+// ChangesApplied brings the fetched rows in line with the changes once
+// they've been written: edited values are filled in and deleted rows
+// dropped. Inserted rows only show when the query is run again.
+func (g *ResultGrid) ChangesApplied() {
+	kept := [][]any{}
+	for _, row := range g.rows {
+		edit := g.editOf(row)
+		switch {
+		case edit == nil:
+		case edit.deleted:
+			continue
+		default:
+			for i, value := range edit.values {
+				row[i] = value
+			}
+		}
+		kept = append(kept, row)
+	}
+	g.rows = kept
+	g.DiscardChanges()
+
+	if g.arranged() {
+		g.rearrange()
+	}
+	g.row = max(min(g.row, g.endRow()-1), g.firstRow())
+	g.scroll()
+}
@@ -0,0 +1,320 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/therealphatmike/squeal/util/resultset"
+)
+
+type gridPrompt int
+
+const (
+	notPrompting gridPrompt = iota
+	searchPrompt
+	filterPrompt
+	editPrompt
+	pagePrompt
+	wherePrompt
+	presetPrompt
+)
+
+var gridSearchMatch = lipgloss.NewStyle().Background(lipgloss.Color("#FFB86C")).Foreground(lipgloss.Color("#1A1A1A"))
+
+// Sorting, filtering and searching only look at the fetched rows; nothing is
+// sent to the server. Rows aren't fetched automatically while sorted or
+// filtered since the new ones would land out of order.
+
+// Typing reports whether the grid is reading a search, filter or new value, in which
+// case it wants every key.
+func (g ResultGrid) Typing() bool {
+	return g.prompting != notPrompting
+}
+
+// arranged reports whether sorting or filtering has reordered the rows. The
+// cursor and window then count positions in shown rather than row numbers.
+func (g ResultGrid) arranged() bool {
+	return g.shown != nil
+}
+
+// firstRow and endRow bound where the cursor can go.
+func (g ResultGrid) firstRow() int {
+	if g.arranged() {
+		return 0
+	}
+	return g.first
+}
+
+func (g ResultGrid) endRow() int {
+	if g.arranged() {
+		return len(g.shown)
+	}
+	return g.last()
+}
+
+func (g ResultGrid) rowAt(r int) []any {
+	if g.arranged() {
+		return g.rows[g.shown[r]]
+	}
+	return g.rows[r-g.first]
+}
+
+// rowNumber is the position of the row in the result as the server sent it.
+func (g ResultGrid) rowNumber(r int) int {
+	if g.arranged() {
+		return g.first + g.shown[r]
+	}
+	return r
+}
+
+func (g ResultGrid) passes(row []any) bool {
+	for _, f := range g.filters {
+		if !f.Match(row[f.Column], displayValue(row[f.Column])) {
+			return false
+		}
+	}
+	return true
+}
+
+// rearrange works out which fetched rows show and in what order, keeping the
+// cursor on the same row when it's still there.
+func (g *ResultGrid) rearrange() {
+	// the rows under a selection are about to move
+	g.selecting = false
+
+	current := -1
+	if g.row < g.endRow() && g.row >= g.firstRow() {
+		current = g.rowNumber(g.row)
+	}
+
+	if !g.sorted && len(g.filters) == 0 {
+		g.shown = nil
+		g.row = max(current, g.first)
+		g.scroll()
+		return
+	}
+
+	shown := []int{}
+	for i, row := range g.rows {
+		if g.passes(row) {
+			shown = append(shown, i)
+		}
+	}
+	if g.sorted {
+		resultset.Sort(g.rows, shown, g.sortColumn, g.descending, g.nullsFirst)
+	}
+
+	g.shown = shown
+	g.row = 0
+	for position, i := range shown {
+		if g.first+i == current {
+			g.row = position
+		}
+	}
+	g.top = min(g.top, g.row)
+	g.scroll()
+}
+
+// sortBy cycles the column through ascending, descending and unsorted.
+func (g *ResultGrid) sortBy(column int) {
+	switch {
+	case !g.sorted || g.sortColumn != column:
+		g.sorted, g.sortColumn, g.descending = true, column, false
+	case !g.descending:
+		g.descending = true
+	default:
+		g.sorted = false
+	}
+	g.rearrange()
+}
+
+// setFilter replaces the column's filter, or removes it when expr is empty.
+func (g *ResultGrid) setFilter(column int, expr string) error {
+	filters := []resultset.Filter{}
+	for _, f := range g.filters {
+		if f.Column != column {
+			filters = append(filters, f)
+		}
+	}
+
+	if strings.TrimSpace(expr) != "" {
+		f, err := resultset.ParseFilter(column, expr)
+		if err != nil {
+			return err
+		}
+		filters = append(filters, f)
+	}
+
+	g.filters = filters
+	g.rearrange()
+	return nil
+}
+
+func (g *ResultGrid) startPrompt(prompt gridPrompt) tea.Cmd {
+	g.prompting = prompt
+	g.promptErr = nil
+
+	switch prompt {
+	case searchPrompt:
+		g.prompt.Prompt = "/"
+		g.prompt.Placeholder = "search fetched rows..."
+		g.prompt.SetValue(g.search)
+		g.searchFrom = [2]int{g.row, g.column}
+	case filterPrompt:
+		i, ok := g.cursorColumn()
+		if !ok {
+			g.prompting = notPrompting
+			return nil
+		}
+		g.prompt.Prompt = "filter " + g.columns[i].Name + ": "
+		g.prompt.Placeholder = "text, =v, !=v, <v, >=v, a..b or /regexp/"
+		g.prompt.SetValue("")
+		for _, f := range g.filters {
+			if f.Column == i {
+				g.prompt.SetValue(f.Expr)
+			}
+		}
+	case editPrompt:
+		g.startEdit()
+	case pagePrompt:
+		g.prompt.Prompt = "go to page: "
+		g.prompt.Placeholder = "number"
+		g.prompt.SetValue("")
+	case wherePrompt:
+		g.prompt.Prompt = "WHERE "
+		g.prompt.Placeholder = "condition, empty for every row"
+		g.prompt.SetValue(g.page.where)
+	case presetPrompt:
+		g.prompt.Prompt = "save preset as: "
+		g.prompt.Placeholder = "name"
+		g.prompt.SetValue("")
+	}
+
+	g.prompt.CursorEnd()
+	return g.prompt.Focus()
+}
+
+func (g ResultGrid) updatePrompt(msg tea.KeyMsg) (ResultGrid, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		if g.prompting == searchPrompt {
+			g.search = ""
+			g.row, g.column = g.searchFrom[0], g.searchFrom[1]
+			g.scroll()
+		}
+		g.prompting = notPrompting
+		g.prompt.Blur()
+		return g, nil
+	case "ctrl+n":
+		if g.prompting == editPrompt {
+			g.setCell(nil)
+			g.prompting = notPrompting
+			g.prompt.Blur()
+			return g, nil
+		}
+	case "enter":
+		if g.prompting == editPrompt {
+			g.setCell(g.prompt.Value())
+		}
+		var cmd tea.Cmd
+		var err error
+		switch g.prompting {
+		case pagePrompt:
+			cmd, err = g.jumpToPage(g.prompt.Value())
+		case wherePrompt:
+			cmd, err = g.setWhere(g.prompt.Value())
+		case presetPrompt:
+			cmd, err = g.savePreset(g.prompt.Value())
+		}
+		if err != nil {
+			g.promptErr = err
+			return g, nil
+		}
+		if i, ok := g.cursorColumn(); ok && g.prompting == filterPrompt {
+			if err := g.setFilter(i, g.prompt.Value()); err != nil {
+				g.promptErr = err
+				return g, nil
+			}
+		}
+		g.prompting = notPrompting
+		g.prompt.Blur()
+		return g, cmd
+	}
+
+	var cmd tea.Cmd
+	previous := g.prompt.Value()
+	g.prompt, cmd = g.prompt.Update(msg)
+	g.promptErr = nil
+	if g.prompting == searchPrompt && g.prompt.Value() != previous {
+		// search again from where it started, so backspacing goes back
+		g.search = g.prompt.Value()
+		g.row, g.column = g.searchFrom[0], g.searchFrom[1]
+		g.findMatch(true, false)
+		g.scroll()
+	}
+
+	return g, cmd
+}
+
+func (g ResultGrid) cellMatches(value any) bool {
+	return g.search != "" && strings.Contains(strings.ToLower(displayValue(value)), strings.ToLower(g.search))
+}
+
+// findMatch moves the cursor to the next cell containing the search term,
+// reading across then down, and wrapping around. With skipCurrent the cell
+// under the cursor is checked last.
+func (g *ResultGrid) findMatch(forward bool, skipCurrent bool) bool {
+	visible := g.visible()
+	first := g.firstRow()
+	total := (g.endRow() - first) * len(visible)
+	if total == 0 || g.search == "" {
+		return false
+	}
+
+	step := 1
+	if !forward {
+		step = -1
+	}
+	start := 0
+	if skipCurrent {
+		start = 1
+	}
+
+	at := (g.row-first)*len(visible) + g.column
+	for n := start; n < start+total; n++ {
+		p := ((at+n*step)%total + total) % total
+		r, c := first+p/len(visible), p%len(visible)
+		if g.cellMatches(g.rowAt(r)[visible[c]]) {
+			g.row, g.column = r, c
+			return true
+		}
+	}
+	return false
+}
+
+// arrangement describes the sort and filters for the footer.
+func (g ResultGrid) arrangement() []string {
+	parts := []string{}
+	if len(g.filters) > 0 {
+		parts = append(parts, fmt.Sprintf("%s of %s fetched rows match", formatCount(len(g.shown)), formatCount(len(g.rows))))
+		for _, f := range g.filters {
+			parts = append(parts, "where "+g.columns[f.Column].Name+" "+f.String())
+		}
+	}
+	if g.sorted {
+		direction, nulls := "↑", "nulls last"
+		if g.descending {
+			direction = "↓"
+		}
+		if g.nullsFirst {
+			nulls = "nulls first"
+		}
+		parts = append(parts, fmt.Sprintf("sorted by %s %s %s", g.columns[g.sortColumn].Name, direction, nulls))
+	}
+	if g.search != "" {
+		parts = append(parts, fmt.Sprintf("search %q", g.search))
+	}
+	return parts
+}
@@ -0,0 +1,210 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/therealphatmike/squeal/util/paging"
+)
+
+type pageTarget int
+
+const (
+	toNextPage pageTarget = iota
+	toPreviousPage
+	toFirstPage
+	toLastPage
+	toPageNumber
+)
+
+// pageRequestMsg asks for another page of the table being browsed; number is
+// the page for toPageNumber.
+type pageRequestMsg struct {
+	to     pageTarget
+	number int
+}
+
+// refineBrowseMsg asks for the table being browsed to be read again with
+// another condition and sort.
+type refineBrowseMsg struct {
+	where string
+	order []paging.Order
+}
+
+// savePresetMsg asks for the table's current condition and sort to be saved
+// under a name.
+type savePresetMsg struct {
+	name string
+}
+
+// choosePresetMsg asks for the presets saved for the table to be listed.
+type choosePresetMsg struct{}
+
+// pageInfo describes the page of a table the grid is showing. Estimated is
+// the server's guess at how many rows the table has, or match the condition,
+// -1 without one, and approximate is set when the page number was worked out
+// from it. Query is the SQL the pages are read from, shown above the grid.
+type pageInfo struct {
+	number      int
+	pages       int
+	estimated   int64
+	approximate bool
+	keyset      bool
+	last        bool
+
+	engine string
+	where  string
+	order  []paging.Order
+	query  string
+}
+
+// SetPage marks the grid as showing a page of a table, which ] and [ move
+// through.
+func (g *ResultGrid) SetPage(page pageInfo) {
+	g.page = &page
+}
+
+// pageKey handles the keys for paging through a table: ] and [ go to the next
+// and previous page, { and } to the first and last, and p asks for a page
+// number. W sets the condition rows are read with, o cycles the sort on the
+// cursor column and O clears it, and P and L save and load them as presets.
+func (g *ResultGrid) pageKey(key string) (tea.Cmd, bool) {
+	if g.page == nil {
+		return nil, false
+	}
+
+	request := pageRequestMsg{}
+	switch key {
+	case "]":
+		if g.page.last {
+			return g.status("This is the last page"), true
+		}
+		request.to = toNextPage
+	case "[":
+		if g.page.number == 1 && !g.page.approximate {
+			return g.status("This is the first page"), true
+		}
+		request.to = toPreviousPage
+	case "{":
+		request.to = toFirstPage
+	case "}":
+		request.to = toLastPage
+	case "p":
+		return g.startPrompt(pagePrompt), true
+	case "W":
+		return g.startPrompt(wherePrompt), true
+	case "P":
+		return g.startPrompt(presetPrompt), true
+	case "L":
+		return func() tea.Msg { return choosePresetMsg{} }, true
+	case "o":
+		i, ok := g.cursorColumn()
+		if !ok {
+			return nil, true
+		}
+		return g.refine(g.page.where, g.cycleOrder(g.columns[i].Name)), true
+	case "O":
+		if len(g.page.order) == 0 {
+			return g.status("The table isn't sorted"), true
+		}
+		return g.refine(g.page.where, nil), true
+	default:
+		return nil, false
+	}
+	return func() tea.Msg { return request }, true
+}
+
+// jumpToPage reads the page number typed at the prompt.
+func (g ResultGrid) jumpToPage(text string) (tea.Cmd, error) {
+	n, err := strconv.Atoi(text)
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("%q isn't a page number", text)
+	}
+
+	request := pageRequestMsg{to: toPageNumber, number: n}
+	return func() tea.Msg { return request }, nil
+}
+
+// refine asks for the table to be read again from its first page.
+func (g ResultGrid) refine(where string, order []paging.Order) tea.Cmd {
+	refine := refineBrowseMsg{where: where, order: order}
+	return func() tea.Msg { return refine }
+}
+
+// cycleOrder sorts by a column, then by it descending, then not by it. The
+// column moves to the front so the latest one picked sorts first.
+func (g ResultGrid) cycleOrder(column string) []paging.Order {
+	order := []paging.Order{}
+	next := &paging.Order{Column: column}
+	for _, o := range g.page.order {
+		if o.Column != column {
+			order = append(order, o)
+			continue
+		}
+		if o.Descending {
+			next = nil
+		} else {
+			next.Descending = true
+		}
+	}
+	if next == nil {
+		return order
+	}
+	return slices.Insert(order, 0, *next)
+}
+
+// setWhere reads the condition typed at the prompt.
+func (g ResultGrid) setWhere(text string) (tea.Cmd, error) {
+	where := strings.TrimSpace(text)
+	if err := paging.CheckWhere(where, g.page.engine); err != nil {
+		return nil, err
+	}
+	return g.refine(where, g.page.order), nil
+}
+
+// savePreset reads the preset name typed at the prompt.
+func (g ResultGrid) savePreset(text string) (tea.Cmd, error) {
+	save := savePresetMsg{name: strings.TrimSpace(text)}
+	if save.name == "" {
+		return nil, errors.New("the preset needs a name")
+	}
+	return func() tea.Msg { return save }, nil
+}
+
+// sqlBar is the line above a browsed table showing the query its pages come
+// from.
+func (g ResultGrid) sqlBar() string {
+	bar := g.page.query
+	if g.page.where == "" {
+		bar += "  W to filter"
+	}
+	return historyMetaStyle.Render(truncate(singleLine(bar), g.width))
+}
+
+// pageFooter describes the page for the footer.
+func (g ResultGrid) pageFooter() string {
+	pages := "?"
+	if g.page.estimated >= 0 {
+		pages = "~" + formatCount(g.page.pages)
+	}
+	number := formatCount(g.page.number)
+	if g.page.approximate {
+		number = "~" + number
+	}
+
+	footer := fmt.Sprintf("page %s of %s", number, pages)
+	if g.page.estimated >= 0 {
+		footer += fmt.Sprintf(" (~%s rows)", formatCount(int(g.page.estimated)))
+	}
+	switch {
+	case !g.page.keyset && len(g.page.order) > 0:
+		footer += " by offset, sorted"
+	case !g.page.keyset:
+		footer += " by offset, no primary key"
+	}
+	return footer + " · ]/[ page"
+}
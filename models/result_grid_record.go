@@ -0,0 +1,118 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// maxRecordKeyWidth caps how much room column names get in the record view,
+// so one long name doesn't squeeze every value.
+const maxRecordKeyWidth = 30
+
+// The record view shows the row under the cursor one column per line, like
+// psql's \x, for rows too wide to read across. The cursor column is the
+// highlighted field, so copying, editing and inspecting work as they do in the
+// table.
+
+// Expanded reports whether the grid shows a record at a time.
+func (g ResultGrid) Expanded() bool {
+	return g.expanded
+}
+
+// recordKey turns the keys for moving around the table into ones for the
+// record view: up and down go between fields, left and right between rows.
+func recordKey(key string) string {
+	switch key {
+	case "up":
+		return "left"
+	case "down":
+		return "right"
+	case "left", "pgup":
+		return "up"
+	case "right", "pgdown":
+		return "down"
+	}
+	return key
+}
+
+// recordView draws the row under the cursor as a list of fields, scrolled so
+// the cursor field shows.
+func (g ResultGrid) recordView() string {
+	width, height := g.width, g.bodyHeight()
+	if g.endRow() == g.firstRow() {
+		return lipgloss.JoinVertical(lipgloss.Left, resultNullStyle.Render("No rows to show"), g.footer())
+	}
+
+	visible := g.visible()
+	keyWidth := 0
+	for _, i := range visible {
+		keyWidth = max(keyWidth, lipgloss.Width(singleLine(g.columns[i].Name)))
+	}
+	keyWidth = min(keyWidth, maxRecordKeyWidth, max(width/3, minColumnWidth))
+	valueWidth := max(width-keyWidth-2, minColumnWidth)
+
+	row := g.rowAt(g.row)
+	edit := g.editOf(row)
+
+	// each field takes as many lines as its value wraps to; start and end
+	// are where the cursor field's lines are
+	lines := []string{}
+	start, end := 0, 0
+	for position, i := range visible {
+		value := g.currentValue(row, i)
+		_, edited := edit.valueOf(i)
+
+		text := ansi.Wrap(g.render.render(g.columns[i], value), valueWidth, " ")
+		style := lipgloss.NewStyle()
+		switch {
+		case position == g.column:
+			style = gridCursorCell
+		case edit != nil && edit.deleted:
+			style = gridDeletedStyle
+		case edited:
+			style = gridEditedStyle
+		case g.cellMatches(value):
+			style = gridSearchMatch
+		case value == nil:
+			style = resultNullStyle
+		}
+
+		name := truncate(singleLine(g.columns[i].Name), keyWidth)
+		key := infoKeyStyle.Render(name + strings.Repeat(" ", keyWidth-lipgloss.Width(name)))
+		if position == g.column {
+			start = len(lines)
+		}
+		for n, part := range strings.Split(text, "\n") {
+			if n > 0 {
+				key = strings.Repeat(" ", keyWidth)
+			}
+			lines = append(lines, key+"  "+style.Render(part))
+		}
+		if position == g.column {
+			end = len(lines)
+		}
+	}
+
+	// a line for the title and one for the footer
+	available := max(height-2, 1)
+	top := 0
+	if end > available {
+		top = min(start, end-available)
+	}
+	lines = lines[top:min(top+available, len(lines))]
+
+	title := fmt.Sprintf("Record %s", formatCount(g.rowNumber(g.row)+1))
+	if edit != nil && edit.deleted {
+		title += " (marked for deletion)"
+	}
+	body := lipgloss.NewStyle().MaxWidth(width).Height(available).Render(strings.Join(lines, "\n"))
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		infoHeaderStyle.Render(title),
+		body,
+		lipgloss.NewStyle().MaxWidth(width).Render(g.footer()),
+	)
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const maxColumnWidth = 40
+
+var (
+	resultHeaderStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#874BFD"))
+	resultNullStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#888B7E")).Italic(true)
+)
+
+func displayValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05.999999999 -07:00")
+	}
+	return fmt.Sprint(value)
+}
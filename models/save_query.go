@@ -0,0 +1,148 @@
+package models
+
+import (
+	"errors"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/therealphatmike/squeal/util/queries"
+)
+
+// SaveQueryCancelledMsg is sent when the save query form is dismissed.
+type SaveQueryCancelledMsg struct{}
+
+// saveQueryRequestedMsg carries the query filled in on the save query form.
+type saveQueryRequestedMsg struct {
+	query queries.SavedQuery
+}
+
+// SaveQueryForm asks for the name, description, tags and scope to save the
+// buffer under. It starts from the saved query the buffer was loaded from,
+// if there is one.
+type SaveQueryForm struct {
+	width          int
+	height         int
+	connectionName string
+	text           string
+	name           *string
+	description    *string
+	tags           *string
+	global         *bool
+	form           *huh.Form
+}
+
+func NewSaveQueryForm(width int, height int, connectionName string, savedName string, text string) SaveQueryForm {
+	name, description, tags, global := savedName, "", "", false
+	if existing, err := queries.ReadSavedQueries(); err == nil && savedName != "" {
+		if q, ok := queries.Lookup(existing, savedName, connectionName); ok {
+			description, tags, global = q.Description, strings.Join(q.Tags, ", "), q.IsGlobal()
+		}
+	}
+
+	return SaveQueryForm{
+		width:          width,
+		height:         height,
+		connectionName: connectionName,
+		text:           text,
+		name:           &name,
+		description:    &description,
+		tags:           &tags,
+		global:         &global,
+		form: huh.NewForm(
+			huh.NewGroup(
+				huh.NewInput().
+					Title("Name").
+					Value(&name).
+					Validate(func(s string) error {
+						if strings.TrimSpace(s) == "" {
+							return errors.New("saved queries need a name")
+						}
+						return nil
+					}),
+				huh.NewInput().
+					Title("Description").
+					Value(&description),
+				huh.NewInput().
+					Title("Tags").
+					Description("Separate tags with commas.").
+					Value(&tags),
+				huh.NewSelect[bool]().
+					Title("Available from").
+					Options(
+						huh.NewOption("This connection", false),
+						huh.NewOption("Every connection", true),
+					).
+					Value(&global),
+			),
+		).WithShowHelp(false).WithShowErrors(true),
+	}
+}
+
+func (m SaveQueryForm) Init() tea.Cmd {
+	return m.form.Init()
+}
+
+func (m SaveQueryForm) Update(msg tea.Msg) (SaveQueryForm, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+	case tea.KeyMsg:
+		if msg.String() == "esc" {
+			return m, func() tea.Msg { return SaveQueryCancelledMsg{} }
+		}
+	}
+
+	form, cmd := m.form.Update(msg)
+	if f, ok := form.(*huh.Form); ok {
+		m.form = f
+	}
+
+	switch m.form.State {
+	case huh.StateAborted:
+		return m, func() tea.Msg { return SaveQueryCancelledMsg{} }
+	case huh.StateCompleted:
+		requested := saveQueryRequestedMsg{query: m.query()}
+		return m, func() tea.Msg { return requested }
+	}
+
+	return m, cmd
+}
+
+// query is the saved query the form describes.
+func (m SaveQueryForm) query() queries.SavedQuery {
+	query := queries.SavedQuery{
+		Name:        strings.TrimSpace(*m.name),
+		Description: strings.TrimSpace(*m.description),
+		Query:       m.text,
+	}
+	for _, tag := range strings.Split(*m.tags, ",") {
+		if tag = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(tag), "#")); tag != "" {
+			query.Tags = append(query.Tags, tag)
+		}
+	}
+	if !*m.global {
+		query.ConnectionName = m.connectionName
+	}
+	return query
+}
+
+func (m SaveQueryForm) View() string {
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		dialogBoxStyle.Width(min(max(m.width-10, 40), 80)).Render(
+			lipgloss.JoinVertical(
+				lipgloss.Left,
+				savedQueryNameStyle.Render("Save Query"),
+				m.form.View(),
+			),
+		),
+		lipgloss.WithWhitespaceChars(" "),
+		lipgloss.WithWhitespaceForeground(subtle),
+	)
+}
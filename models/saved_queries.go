@@ -0,0 +1,259 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/therealphatmike/squeal/util/queries"
+)
+
+// SavedQuerySelectedMsg carries the chosen saved query with its snippet
+// placeholders already filled in.
+type SavedQuerySelectedMsg struct {
+	Name  string
+	Query string
+}
+
+type SavedQueriesClosedMsg struct{}
+
+type SavedQueries struct {
+	width          int
+	height         int
+	connectionName string
+	filter         textinput.Model
+	available      []queries.SavedQuery
+	matches        []queries.SavedQuery
+	cursor         int
+	placeholders   *huh.Form
+	chosen         queries.SavedQuery
+	err            error
+}
+
+var (
+	savedQueryNameStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#874BFD"))
+	savedQueryTagStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#32a852"))
+	savedQueryScope     = lipgloss.NewStyle().Foreground(lipgloss.Color("#888B7E"))
+)
+
+func NewSavedQueries(width int, height int, connectionName string) SavedQueries {
+	filter := textinput.New()
+	filter.Placeholder = "filter by name or #tag"
+	filter.Prompt = "/ "
+	filter.Focus()
+
+	m := SavedQueries{
+		width:          width,
+		height:         height,
+		connectionName: connectionName,
+		filter:         filter,
+	}
+	m.reload()
+
+	return m
+}
+
+func (m *SavedQueries) reload() {
+	all, err := queries.ReadSavedQueries()
+	m.err = err
+	m.available = queries.ForConnection(all, m.connectionName)
+	m.matches = queries.Filter(m.available, m.filter.Value())
+	if m.cursor >= len(m.matches) {
+		m.cursor = max(len(m.matches)-1, 0)
+	}
+}
+
+func (m SavedQueries) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m SavedQueries) Update(msg tea.Msg) (SavedQueries, tea.Cmd) {
+	if m.placeholders != nil {
+		return m.updatePlaceholders(msg)
+	}
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			return m, func() tea.Msg { return SavedQueriesClosedMsg{} }
+		case "up", "ctrl+p":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+			return m, nil
+		case "down", "ctrl+n":
+			if m.cursor < len(m.matches)-1 {
+				m.cursor++
+			}
+			return m, nil
+		case "ctrl+x":
+			if len(m.matches) > 0 {
+				m.err = queries.DeleteSavedQuery(m.matches[m.cursor])
+				m.reload()
+			}
+			return m, nil
+		case "enter":
+			if len(m.matches) == 0 {
+				return m, nil
+			}
+			m.chosen = m.matches[m.cursor]
+			placeholders := queries.Placeholders(m.chosen.Query)
+			if len(placeholders) == 0 {
+				return m, selectSavedQuery(m.chosen.Name, m.chosen.Query)
+			}
+			m.placeholders = newPlaceholderForm(placeholders)
+			return m, m.placeholders.Init()
+		}
+	}
+
+	var cmd tea.Cmd
+	previous := m.filter.Value()
+	m.filter, cmd = m.filter.Update(msg)
+	if m.filter.Value() != previous {
+		m.matches = queries.Filter(m.available, m.filter.Value())
+		m.cursor = 0
+	}
+
+	return m, cmd
+}
+
+func (m SavedQueries) updatePlaceholders(msg tea.Msg) (SavedQueries, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok && msg.String() == "esc" {
+		m.placeholders = nil
+		return m, nil
+	}
+
+	form, cmd := m.placeholders.Update(msg)
+	if f, ok := form.(*huh.Form); ok {
+		m.placeholders = f
+	}
+
+	switch m.placeholders.State {
+	case huh.StateCompleted:
+		values := map[string]string{}
+		for _, p := range queries.Placeholders(m.chosen.Query) {
+			values[p.Name] = m.placeholders.GetString(p.Name)
+		}
+		m.placeholders = nil
+		return m, selectSavedQuery(m.chosen.Name, queries.Expand(m.chosen.Query, values))
+	case huh.StateAborted:
+		m.placeholders = nil
+		return m, nil
+	}
+
+	return m, cmd
+}
+
+func newPlaceholderForm(placeholders []queries.Placeholder) *huh.Form {
+	fields := []huh.Field{}
+	for _, p := range placeholders {
+		input := huh.NewInput().
+			Title(p.Name).
+			Key(p.Name)
+		if p.Default != "" {
+			input = input.Placeholder(p.Default).Description("Defaults to " + p.Default)
+		}
+		fields = append(fields, input)
+	}
+
+	return huh.NewForm(huh.NewGroup(fields...)).
+		WithShowHelp(true).
+		WithShowErrors(true)
+}
+
+func selectSavedQuery(name string, query string) tea.Cmd {
+	return func() tea.Msg {
+		return SavedQuerySelectedMsg{Name: name, Query: query}
+	}
+}
+
+func (m SavedQueries) View() string {
+	width := min(max(m.width-10, 40), 120)
+
+	if m.placeholders != nil {
+		return lipgloss.Place(
+			m.width,
+			m.height,
+			lipgloss.Center,
+			lipgloss.Center,
+			dialogBoxStyle.Width(width).Render(
+				lipgloss.JoinVertical(
+					lipgloss.Left,
+					savedQueryNameStyle.Render(m.chosen.Name),
+					m.placeholders.View(),
+				),
+			),
+		)
+	}
+
+	listWidth := width / 2
+	list := []string{}
+	for i, q := range m.matches {
+		scope := "global"
+		if !q.IsGlobal() {
+			scope = q.ConnectionName
+		}
+
+		prefix := "  "
+		if i == m.cursor {
+			prefix = historyCursor.Render("> ")
+		}
+
+		tags := []string{}
+		for _, tag := range q.Tags {
+			tags = append(tags, "#"+tag)
+		}
+
+		list = append(list, lipgloss.JoinHorizontal(
+			lipgloss.Top,
+			prefix,
+			savedQueryNameStyle.Render(truncate(q.Name, listWidth-14)),
+			" ",
+			savedQueryScope.Render("("+scope+")"),
+		))
+		if len(tags) > 0 {
+			list = append(list, "    "+savedQueryTagStyle.Render(truncate(strings.Join(tags, " "), listWidth-6)))
+		}
+	}
+
+	if m.err != nil {
+		list = append(list, historyErrorStyle.Render(m.err.Error()))
+	} else if len(m.matches) == 0 {
+		list = append(list, savedQueryScope.Render("No saved queries"))
+	}
+
+	preview := ""
+	if len(m.matches) > 0 {
+		q := m.matches[m.cursor]
+		preview = lipgloss.JoinVertical(
+			lipgloss.Left,
+			savedQueryScope.Render(q.Description),
+			"",
+			q.Query,
+		)
+	}
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		lipgloss.JoinVertical(
+			lipgloss.Left,
+			lipgloss.JoinHorizontal(
+				lipgloss.Top,
+				dialogBoxStyle.Width(listWidth).MarginRight(0).Render(strings.Join(list, "\n")),
+				dialogBoxStyle.Width(width-listWidth).MarginLeft(0).Render(preview),
+			),
+			dialogBoxStyle.Width(width+2).Render(m.filter.View()),
+		),
+		lipgloss.WithWhitespaceChars(" "),
+		lipgloss.WithWhitespaceForeground(subtle),
+	)
+}
@@ -22,8 +22,24 @@ var (
 			BorderLeft(true).
 			BorderRight(true).
 			BorderBottom(true)
+
+	// infoHeaderStyle and infoKeyStyle lay out key/value details, like a
+	// connection's or a result row's
+	infoHeaderStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#874BFD"))
+
+	infoKeyStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#32a852")).
+			Align(lipgloss.Left)
 )
 
+// DatabaseSelectedMsg is sent when a connection has been picked from the list.
+type DatabaseSelectedMsg struct {
+	Database databases.Database
+}
+
 type SelectDatabase struct {
 	ready         bool
 	width         int
@@ -85,7 +101,8 @@ func (m SelectDatabase) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	if m.form.State == huh.StateCompleted {
-		return m, tea.Quit
+		selected := m.accessor.Get()
+		return m, func() tea.Msg { return DatabaseSelectedMsg{Database: selected} }
 	}
 
 	return m, tea.Batch(cmds...)
@@ -137,15 +154,6 @@ func (m SelectDatabase) View() string {
 }
 
 func getCurrentlyHighlightedDatabaseInfo(highlighted databases.Database) string {
-	headerStyle := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("#874BFD"))
-
-	infoKeyStyle := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("#32a852")).
-		Align(lipgloss.Left)
-
 	return lipgloss.JoinVertical(
 		lipgloss.Top,
 		lipgloss.Place(
@@ -153,7 +161,7 @@ func getCurrentlyHighlightedDatabaseInfo(highlighted databases.Database) string
 			1,
 			lipgloss.Center,
 			lipgloss.Center,
-			headerStyle.Render(highlighted.ConnectionName+" Connection Details\n"),
+			infoHeaderStyle.Render(highlighted.ConnectionName+" Connection Details\n"),
 		),
 		lipgloss.JoinHorizontal(lipgloss.Left, infoKeyStyle.Render("Engine: "), highlighted.Engine),
 		lipgloss.JoinHorizontal(lipgloss.Left, infoKeyStyle.Render("Host: "), highlighted.Host),
@@ -0,0 +1,1354 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/therealphatmike/squeal/components"
+	"github.com/therealphatmike/squeal/util/databases"
+	"github.com/therealphatmike/squeal/util/editor"
+	"github.com/therealphatmike/squeal/util/explain"
+	"github.com/therealphatmike/squeal/util/formatter"
+	"github.com/therealphatmike/squeal/util/guardrails"
+	"github.com/therealphatmike/squeal/util/layouts"
+	"github.com/therealphatmike/squeal/util/presets"
+	"github.com/therealphatmike/squeal/util/queries"
+	"github.com/therealphatmike/squeal/util/settings"
+	"github.com/therealphatmike/squeal/util/statements"
+)
+
+// DisconnectMsg asks the main model to close the session and go back to the
+// database list.
+type DisconnectMsg struct{}
+
+// QuitMsg asks the main model to close the session and exit.
+type QuitMsg struct{}
+
+type sessionOverlay int
+
+const (
+	noOverlay sessionOverlay = iota
+	historyOverlay
+	savedQueriesOverlay
+	bindParamsOverlay
+	explainOverlay
+	transactionOverlay
+	guardOverlay
+	inspectorOverlay
+	exportOverlay
+	changesOverlay
+	insertOverlay
+	browseOverlay
+	presetsOverlay
+	discardOverlay
+	saveQueryOverlay
+)
+
+var sessionQuickKeys = []components.QuickKey{
+	{Key: "^c", Label: "Quit"},
+	{Key: "^d", Label: "Disconnect"},
+	{Key: "M-⏎", Label: "Run"},
+	{Key: "F5", Label: "Run All"},
+	{Key: "^t", Label: "New Tab"},
+	{Key: "^w", Label: "Close Tab"},
+	{Key: "^r", Label: "History"},
+	{Key: "^o", Label: "Saved"},
+	{Key: "^s", Label: "Save Query"},
+	{Key: "^e", Label: "$EDITOR"},
+	{Key: "M-f", Label: "Format"},
+	{Key: "M-x", Label: "Explain"},
+	{Key: "M-X", Label: "Analyze"},
+	{Key: "M-b", Label: "Browse Table"},
+	{Key: "F6", Label: "Autocommit"},
+	{Key: "F7", Label: "Commit"},
+	{Key: "F8", Label: "Rollback"},
+	{Key: "M-s", Label: "Savepoint"},
+	{Key: "M-z", Label: "To Savepoint"},
+	{Key: "⇥", Label: "Results"},
+}
+
+// resultsQuickKeys replace sessionQuickKeys while the results have focus.
+var resultsQuickKeys = []components.QuickKey{
+	{Key: "⇥", Label: "Editor"},
+	{Key: "⏎", Label: "Inspect"},
+	{Key: "r", Label: "Record View"},
+	{Key: "]/[", Label: "Page"},
+	{Key: "p", Label: "Go To Page"},
+	{Key: "W", Label: "Where"},
+	{Key: "o/O", Label: "Order By/Clear"},
+	{Key: "P/L", Label: "Save/Load Preset"},
+	{Key: "/", Label: "Search"},
+	{Key: "s/S", Label: "Sort/Nulls"},
+	{Key: "F", Label: "Filter"},
+	{Key: "v", Label: "Select"},
+	{Key: "y/Y/C", Label: "Copy Cell/Row/Col"},
+	{Key: "c", Label: "Copy Format"},
+	{Key: "e", Label: "Edit"},
+	{Key: "d", Label: "Delete"},
+	{Key: "D", Label: "Duplicate"},
+	{Key: "u", Label: "Undo Row"},
+	{Key: "w", Label: "Review"},
+	{Key: "i", Label: "Insert Row"},
+	{Key: "M-e", Label: "Export"},
+	{Key: "-/+", Label: "Width"},
+	{Key: "=", Label: "Fit"},
+	{Key: "</>", Label: "Move"},
+	{Key: "x", Label: "Hide"},
+	{Key: "X", Label: "Show All"},
+	{Key: "f", Label: "Freeze"},
+}
+
+// Session is an open connection with one or more query tabs.
+type Session struct {
+	width         int
+	height        int
+	database      databases.Database
+	settings      settings.Settings
+	render        cellRenderer
+	tabs          []QueryTab
+	active        int
+	nextTabNumber int
+	overlay       sessionOverlay
+	historySearch HistorySearch
+	savedQueries  SavedQueries
+	saveQuery     SaveQueryForm
+	bindParams    BindParams
+	// binding is the statements waiting on the bind parameters form, and
+	// bindingAt the one it's asking about
+	binding       []pendingStatement
+	bindingAt     int
+	explainView   ExplainView
+	txnPrompt     TransactionPrompt
+	discardPrompt DiscardPrompt
+	guardPrompt   GuardPrompt
+	inspector     CellInspector
+	exportPrompt  ExportPrompt
+	review        ChangesReview
+	insertRow     InsertRow
+	browsePrompt  BrowsePrompt
+	presetList    BrowsePresets
+	status        string
+	// resultsFocused sends keys to the results instead of the editor
+	resultsFocused bool
+
+	ctx        context.Context
+	disconnect context.CancelFunc
+	conn       *databases.Connection
+	spinner    spinner.Model
+	running    bool
+	cancelling bool
+	runningTab int
+	startedAt  time.Time
+
+	// with autocommit off a transaction is opened before the first statement
+	// and stays open until it is committed or rolled back
+	autocommit    bool
+	inTransaction bool
+	txnStartedAt  time.Time
+	txnGeneration int
+	savepoints    []string
+	nextSavepoint int
+}
+
+func NewSession(width int, height int, database databases.Database) Session {
+	userSettings, err := settings.ReadSettings()
+	ctx, disconnect := context.WithCancel(context.Background())
+
+	m := Session{
+		width:         width,
+		height:        height,
+		database:      database,
+		settings:      userSettings,
+		nextTabNumber: 1,
+		status:        "Connecting to " + database.ConnectionName + "...",
+		ctx:           ctx,
+		disconnect:    disconnect,
+		spinner:       spinner.New(spinner.WithSpinner(spinner.Dot)),
+		autocommit:    true,
+		nextSavepoint: 1,
+	}
+	if err != nil {
+		m.status = "Unable to read settings: " + err.Error()
+	}
+	if m.render, err = newCellRenderer(userSettings); err != nil {
+		m.status = "Unable to read settings: " + err.Error()
+	}
+	m.openTab()
+
+	return m
+}
+
+func (m Session) Init() tea.Cmd {
+	return connect(m.ctx, m.database)
+}
+
+// Running reports whether a query is in flight, in which case ctrl+c cancels
+// it rather than quitting.
+func (m Session) Running() bool {
+	return m.running
+}
+
+// InTransaction reports whether a transaction is open, in which case leaving
+// the session asks what to do with it first.
+func (m Session) InTransaction() bool {
+	return m.inTransaction
+}
+
+// Unapplied is how many edits to results are waiting to be applied, which
+// leaving the session asks about first.
+func (m Session) Unapplied() int {
+	pending := 0
+	for _, tab := range m.tabs {
+		pending += tab.grid.Pending()
+	}
+	return pending
+}
+
+// Close abandons anything still running and closes the connection. The
+// server rolls back a transaction left open.
+func (m Session) Close() {
+	m.disconnect()
+	if m.conn != nil {
+		m.conn.Close()
+	}
+}
+
+func (m *Session) editorSize() (int, int) {
+	// tab bar, quick keys and status bar take a line each
+	return max(m.width-2, 10), max((m.height-3)/2, 3)
+}
+
+func (m *Session) resultsSize() (int, int) {
+	width, editorHeight := m.editorSize()
+	// the editor and results boxes have a border line above and below
+	return width, max(m.height-3-editorHeight-4, 1)
+}
+
+func (m *Session) openTab() {
+	m.resultsFocused = false
+	width, height := m.editorSize()
+	if len(m.tabs) > 0 {
+		m.tabs[m.active].editor.Blur()
+	}
+
+	m.tabs = append(m.tabs, NewQueryTab(m.nextTabNumber, width, height))
+	m.nextTabNumber++
+	m.active = len(m.tabs) - 1
+}
+
+// closeTab closes the active tab, returning a command that stops reading its
+// result if that was still streaming.
+func (m *Session) closeTab() tea.Cmd {
+	m.resultsFocused = false
+	if len(m.tabs) == 1 {
+		// there is always one tab, so closing the last one just clears it
+		m.tabs[0].SetQuery("")
+		return nil
+	}
+
+	closing := m.tabs[m.active].grid.Close()
+	m.tabs = append(m.tabs[:m.active], m.tabs[m.active+1:]...)
+	if m.active >= len(m.tabs) {
+		m.active = len(m.tabs) - 1
+	}
+	m.tabs[m.active].editor.Focus()
+
+	return closing
+}
+
+func (m *Session) switchTab(index int) {
+	if index < 0 || index >= len(m.tabs) || index == m.active {
+		return
+	}
+
+	m.tabs[m.active].editor.Blur()
+	m.resultsFocused = false
+	m.active = index
+	m.tabs[m.active].editor.Focus()
+}
+
+func (m *Session) tab() *QueryTab {
+	return &m.tabs[m.active]
+}
+
+func (m Session) Update(msg tea.Msg) (Session, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		width, height := m.editorSize()
+		resultsWidth, resultsHeight := m.resultsSize()
+		for i := range m.tabs {
+			m.tabs[i].SetSize(width, height)
+			m.tabs[i].grid.SetSize(resultsWidth, resultsHeight)
+		}
+	case HistorySelectedMsg:
+		m.overlay = noOverlay
+		m.tab().SetQuery(msg.Query)
+		return m, nil
+	case HistoryClosedMsg, SavedQueriesClosedMsg:
+		m.overlay = noOverlay
+		return m, nil
+	case saveQueryRequestedMsg:
+		m.overlay = noOverlay
+		if err := queries.SaveQuery(msg.query); err != nil {
+			m.status = "Unable to save query: " + err.Error()
+			return m, nil
+		}
+		m.tab().savedName = msg.query.Name
+		m.status = "Saved query " + msg.query.Name
+		return m, nil
+	case SaveQueryCancelledMsg:
+		m.overlay = noOverlay
+		return m, nil
+	case SavedQuerySelectedMsg:
+		m.overlay = noOverlay
+		m.tab().SetQuery(msg.Query)
+		m.tab().savedName = msg.Name
+		m.status = "Loaded saved query " + msg.Name
+		return m, nil
+	case editor.FinishedMsg:
+		if msg.Err != nil {
+			m.status = msg.Err.Error()
+		}
+		m.tab().SetQuery(msg.Text)
+		return m, nil
+	case connectedMsg:
+		if msg.err != nil {
+			m.status = "Unable to connect to " + m.database.ConnectionName + ": " + msg.err.Error()
+			return m, nil
+		}
+		m.conn = msg.conn
+		m.status = "Connected to " + m.database.ConnectionName
+		return m, nil
+	case BindParamsReadyMsg:
+		m.overlay = noOverlay
+		if m.bindingAt >= len(m.binding) {
+			return m, nil
+		}
+		pending := slices.Clone(m.binding)
+		pending[m.bindingAt].query, pending[m.bindingAt].args = msg.Query, msg.Args
+		return m.bindAndRun(pending, m.bindingAt+1)
+	case BindParamsCancelledMsg:
+		m.overlay = noOverlay
+		m.binding = nil
+		return m, nil
+	case runConfirmedMsg:
+		m.overlay = noOverlay
+		return m, m.run(msg.pending)
+	case explainConfirmedMsg:
+		m.overlay = noOverlay
+		return m.startExplain(msg.query, msg.explainQuery, msg.analyze)
+	case GuardCancelledMsg:
+		m.overlay = noOverlay
+		m.status = "Cancelled"
+		return m, nil
+	case queryFinishedMsg:
+		return m.finishQuery(msg)
+	case gridPageMsg:
+		for i := range m.tabs {
+			if m.tabs[i].grid.stream == msg.stream {
+				var cmd tea.Cmd
+				m.tabs[i].grid, cmd = m.tabs[i].grid.Update(msg)
+				return m, cmd
+			}
+		}
+		return m, nil
+	case explainFinishedMsg:
+		return m.finishExplain(msg), nil
+	case ExplainClosedMsg:
+		m.overlay = noOverlay
+		return m, nil
+	case inspectCellMsg:
+		m.overlay = inspectorOverlay
+		m.inspector = NewCellInspector(m.width, m.height, msg.column, msg.value)
+		return m, m.inspector.Init()
+	case InspectorClosedMsg:
+		m.overlay = noOverlay
+		return m, nil
+	case exportRequestedMsg:
+		m.overlay = noOverlay
+		columns, rows := m.tab().grid.Rows()
+		m.status = "Exporting " + formatCount(len(rows)) + " rows..."
+		return m, exportResults(msg.path, columns, rows, msg.opts, m.tab().grid.Partial())
+	case exportFinishedMsg:
+		switch {
+		case msg.err != nil:
+			m.status = "Export failed: " + msg.err.Error()
+		case msg.partial:
+			m.status = fmt.Sprintf("Exported the %s fetched rows to %s; use squeal query for the whole result", formatCount(msg.rows), msg.path)
+		default:
+			m.status = fmt.Sprintf("Exported %s rows to %s", formatCount(msg.rows), msg.path)
+		}
+		return m, nil
+	case ExportCancelledMsg:
+		m.overlay = noOverlay
+		return m, nil
+	case copyCellsMsg:
+		return m, copyToClipboard(msg, m.database.Engine)
+	case copiedMsg:
+		if msg.err != nil {
+			m.status = "Copy failed: " + msg.err.Error()
+		} else {
+			m.status = fmt.Sprintf("Copied the %s as %s", msg.what, strings.ToUpper(msg.format))
+		}
+		return m, nil
+	case gridStatusMsg:
+		m.status = msg.text
+		return m, nil
+	case reviewChangesMsg:
+		m.overlay = changesOverlay
+		m.review = NewChangesReview(m.width, m.height, msg)
+		return m, m.review.Init()
+	case ChangesReviewClosedMsg:
+		m.overlay = noOverlay
+		return m, nil
+	case discardChangesMsg:
+		m.overlay = noOverlay
+		m.tab().grid.DiscardChanges()
+		m.status = "Discarded the pending changes"
+		return m, nil
+	case applyChangesMsg:
+		m.overlay = noOverlay
+		if m.running {
+			m.status = "Wait for the running query to finish before applying changes"
+			return m, nil
+		}
+		begin := !m.autocommit && !m.inTransaction
+		status := fmt.Sprintf("Applying %d changes...", len(msg.changes))
+		if msg.inserted {
+			status = "Inserting the row..."
+		}
+		return m, m.markRunning(status, applyChanges(m.ctx, m.conn, m.tab().id, msg, m.inTransaction, begin))
+	case changesAppliedMsg:
+		return m.finishChanges(msg)
+	case insertRowMsg:
+		m.status = "Reading the table definition..."
+		return m, loadInsertRow(m.ctx, m.conn, msg.query)
+	case insertRowReadyMsg:
+		if msg.err != nil {
+			m.status = "Unable to insert a row: " + msg.err.Error()
+			return m, nil
+		}
+		m.status = ""
+		m.overlay = insertOverlay
+		m.insertRow = NewInsertRow(m.width, m.height, msg)
+		return m, m.insertRow.Init()
+	case InsertRowCancelledMsg:
+		m.overlay = noOverlay
+		return m, nil
+	case BrowseCancelledMsg:
+		m.overlay = noOverlay
+		return m, nil
+	case browseTableMsg:
+		m.overlay = noOverlay
+		m.status = "Opening " + msg.name + "..."
+		return m, openBrowse(m.ctx, m.conn, m.tab().id, msg.name, m.settings.BrowsePageSize)
+	case browseReadyMsg:
+		if msg.err != nil {
+			m.status = "Unable to browse the table: " + msg.err.Error()
+			return m, nil
+		}
+		for i := range m.tabs {
+			if m.tabs[i].id == msg.tabID {
+				browse := msg.browse
+				m.tabs[i].browse = &browse
+			}
+		}
+		return m.requestPage(msg.tabID, pageRequestMsg{to: toFirstPage})
+	case pageRequestMsg:
+		return m.requestPage(m.tab().id, msg)
+	case browsePageMsg:
+		return m.finishPage(msg)
+	case refineBrowseMsg:
+		m.overlay = noOverlay
+		return m.refineBrowse(msg)
+	case browseRefinedMsg:
+		for i := range m.tabs {
+			if m.tabs[i].id == msg.tabID && m.tabs[i].browse != nil && msg.page.err == nil {
+				m.tabs[i].browse.pager = msg.pager
+				m.tabs[i].browse.estimated = msg.estimated
+			}
+		}
+		return m.finishPage(msg.page)
+	case savePresetMsg:
+		browse := m.tab().browse
+		if browse == nil {
+			return m, nil
+		}
+		preset := presets.Preset{Name: msg.name, Where: browse.pager.Where, Order: browse.pager.Order}
+		if err := presets.Save(m.database.ConnectionName, browse.pager.Table, preset); err != nil {
+			m.status = "Unable to save the preset: " + err.Error()
+			return m, nil
+		}
+		m.status = "Saved preset " + msg.name + ", L to load it"
+		return m, nil
+	case choosePresetMsg:
+		if m.tab().browse == nil {
+			return m, nil
+		}
+		m.overlay = presetsOverlay
+		m.presetList = NewBrowsePresets(m.width, m.height, m.database.ConnectionName, m.tab().browse.pager.Table)
+		return m, m.presetList.Init()
+	case BrowsePresetsClosedMsg:
+		m.overlay = noOverlay
+		return m, nil
+	case DiscardResolvedMsg:
+		m.overlay = noOverlay
+		if !msg.discard {
+			m.status = "Changes kept, review and apply them with w"
+			return m, nil
+		}
+		return m.leave(msg.then)
+	case TransactionResolvedMsg:
+		m.overlay = noOverlay
+		switch msg.choice {
+		case commitTransaction:
+			return m.endTransaction(true, msg.then)
+		case rollbackTransaction:
+			return m.endTransaction(false, msg.then)
+		}
+		m.status = "Transaction still open"
+		return m, nil
+	case transactionFinishedMsg:
+		return m.finishTransaction(msg)
+	case transactionTickMsg:
+		if !m.inTransaction || msg.generation != m.txnGeneration {
+			return m, nil
+		}
+		return m, transactionTick(m.txnGeneration)
+	case cancelRequestedMsg:
+		if msg.err != nil {
+			m.cancelling = false
+			m.status = "Unable to cancel query: " + msg.err.Error()
+		}
+		return m, nil
+	case spinner.TickMsg:
+		if !m.running {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+
+	switch m.overlay {
+	case historyOverlay:
+		var cmd tea.Cmd
+		m.historySearch, cmd = m.historySearch.Update(msg)
+		return m, cmd
+	case savedQueriesOverlay:
+		var cmd tea.Cmd
+		m.savedQueries, cmd = m.savedQueries.Update(msg)
+		return m, cmd
+	case saveQueryOverlay:
+		var cmd tea.Cmd
+		m.saveQuery, cmd = m.saveQuery.Update(msg)
+		return m, cmd
+	case bindParamsOverlay:
+		var cmd tea.Cmd
+		m.bindParams, cmd = m.bindParams.Update(msg)
+		return m, cmd
+	case explainOverlay:
+		var cmd tea.Cmd
+		m.explainView, cmd = m.explainView.Update(msg)
+		return m, cmd
+	case transactionOverlay:
+		var cmd tea.Cmd
+		m.txnPrompt, cmd = m.txnPrompt.Update(msg)
+		return m, cmd
+	case discardOverlay:
+		var cmd tea.Cmd
+		m.discardPrompt, cmd = m.discardPrompt.Update(msg)
+		return m, cmd
+	case guardOverlay:
+		var cmd tea.Cmd
+		m.guardPrompt, cmd = m.guardPrompt.Update(msg)
+		return m, cmd
+	case inspectorOverlay:
+		var cmd tea.Cmd
+		m.inspector, cmd = m.inspector.Update(msg)
+		return m, cmd
+	case exportOverlay:
+		var cmd tea.Cmd
+		m.exportPrompt, cmd = m.exportPrompt.Update(msg)
+		return m, cmd
+	case changesOverlay:
+		var cmd tea.Cmd
+		m.review, cmd = m.review.Update(msg)
+		return m, cmd
+	case insertOverlay:
+		var cmd tea.Cmd
+		m.insertRow, cmd = m.insertRow.Update(msg)
+		return m, cmd
+	case browseOverlay:
+		var cmd tea.Cmd
+		m.browsePrompt, cmd = m.browsePrompt.Update(msg)
+		return m, cmd
+	case presetsOverlay:
+		var cmd tea.Cmd
+		m.presetList, cmd = m.presetList.Update(msg)
+		return m, cmd
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "ctrl+c":
+			if m.running && !m.cancelling {
+				m.cancelling = true
+				m.status = "Cancelling query..."
+				return m, cancelQuery(m.conn)
+			}
+			if !m.running && (m.inTransaction || m.Unapplied() > 0) {
+				return m.confirmLeave(QuitMsg{})
+			}
+			return m, nil
+		case "alt+enter":
+			return m.runStatementAtCursor()
+		case "f5":
+			return m.runScript()
+		case "alt+x", "alt+X":
+			return m.explainStatementAtCursor(msg.String() == "alt+X")
+		case "ctrl+d":
+			return m.confirmLeave(DisconnectMsg{})
+		case "f6":
+			return m.toggleAutocommit(), nil
+		case "f7":
+			return m.endTransaction(true, nil)
+		case "f8":
+			return m.endTransaction(false, nil)
+		case "alt+s":
+			return m.createSavepoint()
+		case "alt+z":
+			return m.rollbackToSavepoint()
+		case "ctrl+t":
+			m.openTab()
+			return m, nil
+		case "ctrl+w":
+			return m, m.closeTab()
+		case "ctrl+pgdown":
+			m.switchTab((m.active + 1) % len(m.tabs))
+			return m, nil
+		case "ctrl+pgup":
+			m.switchTab((m.active + len(m.tabs) - 1) % len(m.tabs))
+			return m, nil
+		case "alt+1", "alt+2", "alt+3", "alt+4", "alt+5", "alt+6", "alt+7", "alt+8", "alt+9":
+			m.switchTab(int(msg.String()[len("alt+")] - '1'))
+			return m, nil
+		case "ctrl+up", "ctrl+down":
+			if err := m.tab().StepHistory(m.database.ConnectionName, msg.String() == "ctrl+up"); err != nil {
+				m.status = "Unable to read history: " + err.Error()
+			}
+			return m, nil
+		case "ctrl+r":
+			m.overlay = historyOverlay
+			m.historySearch = NewHistorySearch(m.width, m.height, m.database.ConnectionName)
+			return m, m.historySearch.Init()
+		case "ctrl+o":
+			m.overlay = savedQueriesOverlay
+			m.savedQueries = NewSavedQueries(m.width, m.height, m.database.ConnectionName)
+			return m, m.savedQueries.Init()
+		case "ctrl+s":
+			m.overlay = saveQueryOverlay
+			m.saveQuery = NewSaveQueryForm(m.width, m.height, m.database.ConnectionName, m.tab().savedName, m.tab().editor.Value())
+			return m, m.saveQuery.Init()
+		case "ctrl+e":
+			return m, editor.Open(m.tab().editor.Value(), m.settings.Editor)
+		case "alt+e":
+			if !m.tab().grid.HasRows() {
+				m.status = "Nothing to export, run a query that returns rows first"
+				return m, nil
+			}
+			_, rows := m.tab().grid.Rows()
+			m.overlay = exportOverlay
+			m.exportPrompt = NewExportPrompt(m.width, m.height, m.tab().Name(), m.database.Engine, len(rows))
+			return m, m.exportPrompt.Init()
+		case "alt+b":
+			suggested := ""
+			if name, ok := statements.SourceTable(m.tab().grid.query, m.database.Engine); ok {
+				suggested = strings.Join(name, ".")
+			}
+			m.overlay = browseOverlay
+			m.browsePrompt = NewBrowsePrompt(m.width, m.height, suggested)
+			return m, m.browsePrompt.Init()
+		case "alt+f":
+			m.tab().editor.SetValue(strings.TrimRight(formatter.Format(m.tab().editor.Value(), m.database.Engine), "\n"))
+			return m, nil
+		case "tab":
+			if m.tab().vim.mode != vimCommandLine && !(m.resultsFocused && m.tab().grid.Typing()) {
+				m.focusResults(!m.resultsFocused)
+				return m, nil
+			}
+		}
+
+		if m.resultsFocused {
+			if msg.String() == "esc" && !m.tab().grid.Typing() && !m.tab().grid.Selecting() {
+				m.focusResults(false)
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.tab().grid, cmd = m.tab().grid.Update(msg)
+			return m, cmd
+		}
+
+		if m.vimKeys() {
+			handled, command := m.tab().vim.update(m.tab(), msg.String())
+			if command != nil {
+				return m.runVimCommand(*command)
+			}
+			if handled {
+				return m, nil
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	m.tab().editor, cmd = m.tab().editor.Update(msg)
+
+	return m, cmd
+}
+
+func (m Session) vimKeys() bool {
+	return m.settings.Keymap == settings.KeymapVim
+}
+
+func (m *Session) focusResults(focused bool) {
+	m.resultsFocused = focused
+	if focused {
+		m.tab().editor.Blur()
+	} else {
+		m.tab().editor.Focus()
+	}
+}
+
+// runVimCommand carries out the ex commands that reach past the editor:
+// :w [name] saves the buffer as a saved query, :q closes the tab (or quits
+// from the last one) and :wq or :x do both.
+func (m Session) runVimCommand(command vimCommand) (Session, tea.Cmd) {
+	write, quit, force := false, false, false
+	switch command.name {
+	case "w":
+		write = true
+	case "q":
+		quit = true
+	case "q!":
+		quit, force = true, true
+	case "wq", "x":
+		write, quit = true, true
+	default:
+		m.status = "Not an editor command: " + command.name
+		return m, nil
+	}
+
+	if write {
+		name := command.argument
+		if name == "" {
+			name = m.tab().savedName
+		}
+		if name == "" {
+			m.status = "No query name, use :w <name>"
+			return m, nil
+		}
+		if err := queries.SaveQueryText(name, m.database.ConnectionName, m.tab().editor.Value()); err != nil {
+			m.status = "Unable to save query: " + err.Error()
+			return m, nil
+		}
+		m.tab().savedName = name
+		m.status = "Saved query " + name
+	}
+
+	if !quit {
+		return m, nil
+	}
+	if len(m.tabs) > 1 {
+		return m, m.closeTab()
+	}
+	if force {
+		return m, func() tea.Msg { return QuitMsg{} }
+	}
+	return m.confirmLeave(QuitMsg{})
+}
+
+func (m *Session) ready() bool {
+	switch {
+	case m.conn == nil:
+		m.status = "Not connected to " + m.database.ConnectionName
+		return false
+	case m.running:
+		m.status = "A query is already running, ctrl+c cancels it"
+		return false
+	}
+	return true
+}
+
+func (m Session) runStatementAtCursor() (Session, tea.Cmd) {
+	if !m.ready() {
+		return m, nil
+	}
+
+	stmt, ok := statements.AtCursor(m.tab().editor.Value(), m.database.Engine, m.tab().CursorOffset())
+	if !ok {
+		m.status = "Nothing to run"
+		return m, nil
+	}
+
+	return m.runOrPrompt(stmt.Text)
+}
+
+// explainStatementAtCursor shows the plan for the statement under the cursor.
+// Analyzing executes the statement, so its bind parameters aren't prompted
+// for; it has to be runnable as written.
+func (m Session) explainStatementAtCursor(analyze bool) (Session, tea.Cmd) {
+	if !m.ready() {
+		return m, nil
+	}
+
+	stmt, ok := statements.AtCursor(m.tab().editor.Value(), m.database.Engine, m.tab().CursorOffset())
+	if !ok {
+		m.status = "Nothing to explain"
+		return m, nil
+	}
+
+	query, err := explain.Wrap(stmt.Text, m.database.Engine, analyze)
+	if err != nil {
+		m.status = err.Error()
+		return m, nil
+	}
+
+	// analyzing runs the statement for real
+	if risks := guardrails.Check(stmt.Text, m.database.Engine); analyze && len(risks) > 0 {
+		return m.confirm(risks, explainConfirmedMsg{query: stmt.Text, explainQuery: query, analyze: analyze})
+	}
+
+	return m.startExplain(stmt.Text, query, analyze)
+}
+
+func (m Session) startExplain(query string, explainQuery string, analyze bool) (Session, tea.Cmd) {
+	status := "Explaining..."
+	if analyze {
+		status = "Analyzing..."
+	}
+
+	return m, m.markRunning(status, explainStatement(m.ctx, m.conn, query, explainQuery))
+}
+
+func (m Session) finishExplain(msg explainFinishedMsg) Session {
+	m.running = false
+	wasCancelled := m.cancelling
+	m.cancelling = false
+
+	switch {
+	case msg.err != nil && wasCancelled:
+		m.status = "Explain cancelled after " + time.Since(m.startedAt).Round(time.Millisecond).String()
+	case msg.err != nil:
+		m.status = "Error: " + msg.err.Error()
+	default:
+		m.status = "Plan ready"
+		m.overlay = explainOverlay
+		m.explainView = NewExplainView(m.width, m.height, msg.query, msg.plan)
+	}
+
+	return m
+}
+
+func (m Session) runScript() (Session, tea.Cmd) {
+	if !m.ready() {
+		return m, nil
+	}
+
+	script := statements.Split(m.tab().editor.Value(), m.database.Engine)
+	if len(script) == 0 {
+		m.status = "Nothing to run"
+		return m, nil
+	}
+
+	pending := []pendingStatement{}
+	for _, stmt := range script {
+		pending = append(pending, pendingStatement{query: stmt.Text, original: stmt.Text})
+	}
+
+	return m.bindAndRun(pending, 0)
+}
+
+// runOrPrompt runs a single statement, asking for its bind parameters first
+// when it has any.
+func (m Session) runOrPrompt(query string) (Session, tea.Cmd) {
+	return m.bindAndRun([]pendingStatement{{query: query, original: query}}, 0)
+}
+
+// bindAndRun asks for the bind parameters of each statement from next on
+// that has any, one statement at a time, then runs them all.
+func (m Session) bindAndRun(pending []pendingStatement, next int) (Session, tea.Cmd) {
+	m.binding = nil
+	for i := next; i < len(pending); i++ {
+		needed, err := NeedsBindParams(pending[i].query, m.database.Engine)
+		if err != nil {
+			if len(pending) > 1 {
+				err = fmt.Errorf("statement %d: %w", i+1, err)
+			}
+			m.status = "Unable to run: " + err.Error()
+			return m, nil
+		}
+		if needed {
+			m.binding, m.bindingAt = pending, i
+			m.overlay = bindParamsOverlay
+			m.bindParams = NewBindParamsForm(m.width, m.height, pending[i].query, m.database.Engine)
+			return m, m.bindParams.Init()
+		}
+	}
+
+	return m.guardOrRun(pending)
+}
+
+// guardOrRun runs the statements unless any of them is destructive, in which
+// case it asks first.
+func (m Session) guardOrRun(pending []pendingStatement) (Session, tea.Cmd) {
+	queries := []string{}
+	for _, stmt := range pending {
+		queries = append(queries, stmt.query)
+	}
+
+	if risks := guardrails.CheckAll(queries, m.database.Engine); len(risks) > 0 {
+		return m.confirm(risks, runConfirmedMsg{pending: pending})
+	}
+
+	return m, m.run(pending)
+}
+
+func (m Session) confirm(risks []guardrails.Risk, then tea.Msg) (Session, tea.Cmd) {
+	m.overlay = guardOverlay
+	m.guardPrompt = NewGuardPrompt(m.width, m.height, m.database, risks, then)
+	return m, m.guardPrompt.Init()
+}
+
+// markRunning flags the session busy until the command reports back.
+func (m *Session) markRunning(status string, cmd tea.Cmd) tea.Cmd {
+	m.running = true
+	m.cancelling = false
+	m.runningTab = m.tab().id
+	m.startedAt = time.Now()
+	m.status = status
+
+	return tea.Batch(m.spinner.Tick, cmd)
+}
+
+func (m *Session) run(pending []pendingStatement) tea.Cmd {
+	status := "Running..."
+	if len(pending) > 1 {
+		status = fmt.Sprintf("Running %d statements...", len(pending))
+	}
+
+	// a statement that opens a transaction itself doesn't need one opened
+	// for it
+	begin := !m.autocommit && !m.inTransaction &&
+		statements.Transaction(pending[0].query, m.database.Engine) != statements.OpensTransaction
+
+	return m.markRunning(status, runStatements(m.ctx, m.conn, m.tab().id, pending, m.inTransaction, begin))
+}
+
+func (m Session) finishQuery(msg queryFinishedMsg) (Session, tea.Cmd) {
+	m.running = false
+	wasCancelled := m.cancelling
+	m.cancelling = false
+
+	for i := range m.tabs {
+		if m.tabs[i].id != msg.tabID {
+			continue
+		}
+		m.tabs[i].grid = NewResultGrid(msg.result, msg.stream, msg.err, msg.query, msg.layout, m.settings.ResultBufferRows, m.vimKeys(), m.render)
+		m.tabs[i].grid.SetSize(m.resultsSize())
+		m.tabs[i].grid.SetTable(msg.table, msg.notEditable)
+		m.tabs[i].browse = nil
+	}
+
+	duration := msg.result.Duration.Round(time.Millisecond)
+	switch {
+	case msg.err != nil && wasCancelled:
+		m.status = "Query cancelled after " + time.Since(m.startedAt).Round(time.Millisecond).String()
+	case msg.err != nil:
+		m.status = "Error: " + msg.err.Error()
+	case len(msg.result.Columns) > 0 && msg.stream != nil:
+		m.status = fmt.Sprintf("First %d rows in %s, more are fetched as you scroll", len(msg.result.Rows), duration)
+	case len(msg.result.Columns) > 0:
+		m.status = fmt.Sprintf("%d rows in %s", len(msg.result.Rows), duration)
+	case msg.result.RowsAffected >= 0:
+		m.status = fmt.Sprintf("%d rows affected in %s", msg.result.RowsAffected, duration)
+	default:
+		m.status = "Done in " + duration.String()
+	}
+	if msg.ran > 1 {
+		m.status = fmt.Sprintf("%d statements, last: %s", msg.ran, m.status)
+	}
+
+	return m, m.setTransaction(msg.inTransaction)
+}
+
+// requestPage starts reading a page of the table a tab is browsing.
+func (m Session) requestPage(tabID int, request pageRequestMsg) (Session, tea.Cmd) {
+	var browse *tableBrowse
+	for i := range m.tabs {
+		if m.tabs[i].id == tabID {
+			browse = m.tabs[i].browse
+		}
+	}
+	if browse == nil {
+		return m, nil
+	}
+	if m.running {
+		m.status = "Wait for the running query to finish"
+		return m, nil
+	}
+
+	fetch, page := browse.pageFetch(request)
+	return m, m.markRunning("Reading a page of "+strings.Join(browse.pager.Table, ".")+"...", fetchBrowsePage(m.ctx, m.conn, tabID, fetch, request.to, page))
+}
+
+// refineBrowse reads the table the tab is browsing again from the start with
+// another condition and sort.
+func (m Session) refineBrowse(msg refineBrowseMsg) (Session, tea.Cmd) {
+	browse := m.tab().browse
+	if browse == nil {
+		return m, nil
+	}
+	if m.running {
+		m.status = "Wait for the running query to finish"
+		return m, nil
+	}
+
+	pager := browse.pager
+	pager.Where, pager.Order = msg.where, msg.order
+	status := "Reading " + strings.Join(pager.Table, ".") + " " + describeRefinement(pager.Where, pager.Order) + "..."
+	return m, m.markRunning(status, refineBrowse(m.ctx, m.conn, m.tab().id, pager))
+}
+
+// finishPage shows a page read from a table, keeping how the last one was
+// being looked at.
+func (m Session) finishPage(msg browsePageMsg) (Session, tea.Cmd) {
+	m.running = false
+	m.cancelling = false
+
+	for i := range m.tabs {
+		tab := &m.tabs[i]
+		if tab.id != msg.tabID || tab.browse == nil {
+			continue
+		}
+		switch {
+		case msg.err != nil:
+			m.status = "Unable to read the page: " + msg.err.Error()
+			return m, nil
+		case !tab.browse.showPage(msg):
+			m.status = "There are no rows past here"
+			tab.grid.SetPage(tab.browse.pageInfo())
+			return m, nil
+		}
+
+		base := tab.browse.layoutQuery()
+		layout, _ := layouts.Load(base)
+		expanded := tab.grid.expanded
+		tab.grid = NewResultGrid(msg.result, nil, nil, base, layout, m.settings.ResultBufferRows, m.vimKeys(), m.render)
+		tab.grid.SetSize(m.resultsSize())
+		tab.grid.SetTable(tab.browse.table, tab.browse.notEditable)
+		tab.grid.SetPage(tab.browse.pageInfo())
+		tab.grid.expanded = expanded
+		m.status = browseStatus(*tab.browse, msg.result.Duration)
+		if msg.to == toFirstPage && i == m.active && !m.resultsFocused {
+			m.focusResults(true)
+		}
+	}
+	return m, nil
+}
+
+// finishChanges reports edits written back to a table, bringing the grid they
+// came from in line when they went through.
+func (m Session) finishChanges(msg changesAppliedMsg) (Session, tea.Cmd) {
+	m.running = false
+	m.cancelling = false
+
+	applied := fmt.Sprintf("Applied %d changes", msg.count)
+	if msg.inserted {
+		applied = "Inserted a row into " + strings.Join(msg.table.Name, ".") + ", run the query again to see it"
+	}
+	switch {
+	case msg.err != nil && msg.inserted:
+		m.status = "Unable to insert the row: " + msg.err.Error()
+	case msg.err != nil:
+		m.status = "Unable to apply the changes, none were made: " + msg.err.Error()
+	case msg.inTransaction:
+		m.status = applied + "; it's in the open transaction, commit to keep it"
+	default:
+		m.status = applied
+	}
+	if msg.err == nil && !msg.inserted {
+		for i := range m.tabs {
+			if m.tabs[i].id == msg.tabID {
+				m.tabs[i].grid.ChangesApplied()
+			}
+		}
+	}
+
+	return m, m.setTransaction(msg.inTransaction)
+}
+
+// setTransaction records whether a transaction is open, starting the clock
+// shown in the status bar when one has just been opened.
+func (m *Session) setTransaction(open bool) tea.Cmd {
+	wasOpen := m.inTransaction
+	m.inTransaction = open
+	if !open {
+		m.savepoints = nil
+		return nil
+	}
+	if wasOpen {
+		return nil
+	}
+
+	m.txnStartedAt = time.Now()
+	m.txnGeneration++
+	return transactionTick(m.txnGeneration)
+}
+
+func (m Session) toggleAutocommit() Session {
+	if !m.autocommit {
+		m.autocommit = true
+		m.status = "Autocommit on"
+		if m.inTransaction {
+			m.status = "Autocommit on once the open transaction is committed or rolled back"
+		}
+		return m
+	}
+
+	m.autocommit = false
+	m.status = "Autocommit off, statements run in a transaction until committed (F7) or rolled back (F8)"
+	return m
+}
+
+// confirmLeave asks about unapplied edits and then the open transaction, if
+// there are any, before sending then to leave the session.
+func (m Session) confirmLeave(then tea.Msg) (Session, tea.Cmd) {
+	if pending := m.Unapplied(); pending > 0 {
+		m.overlay = discardOverlay
+		m.discardPrompt = NewDiscardPrompt(m.width, m.height, pending, then)
+		return m, m.discardPrompt.Init()
+	}
+	return m.leave(then)
+}
+
+// leave sends then, asking what to do with the open transaction first.
+func (m Session) leave(then tea.Msg) (Session, tea.Cmd) {
+	if m.inTransaction {
+		return m.promptTransaction(then)
+	}
+	return m, func() tea.Msg { return then }
+}
+
+func (m Session) promptTransaction(then tea.Msg) (Session, tea.Cmd) {
+	m.overlay = transactionOverlay
+	m.txnPrompt = NewTransactionPrompt(m.width, m.height, m.database.ConnectionName, time.Since(m.txnStartedAt), then)
+	return m, m.txnPrompt.Init()
+}
+
+// endTransaction commits or rolls back the open transaction, then sends then
+// if it is set.
+func (m Session) endTransaction(commit bool, then tea.Msg) (Session, tea.Cmd) {
+	if !m.inTransaction {
+		m.status = "No open transaction"
+		return m, nil
+	}
+	if !m.ready() {
+		return m, nil
+	}
+
+	status := "Rolling back..."
+	if commit {
+		status = "Committing..."
+	}
+
+	return m, m.markRunning(status, closeTransaction(m.ctx, m.conn, commit, then))
+}
+
+func (m Session) createSavepoint() (Session, tea.Cmd) {
+	if !m.inTransaction && m.autocommit {
+		m.status = "Savepoints need an open transaction, turn autocommit off with F6 or run BEGIN"
+		return m, nil
+	}
+	if !m.ready() {
+		return m, nil
+	}
+
+	name := fmt.Sprintf("squeal_%d", m.nextSavepoint)
+	m.nextSavepoint++
+
+	return m, m.markRunning("Creating savepoint "+name+"...", setSavepoint(m.ctx, m.conn, name, !m.inTransaction))
+}
+
+func (m Session) rollbackToSavepoint() (Session, tea.Cmd) {
+	if len(m.savepoints) == 0 {
+		m.status = "No savepoints to roll back to"
+		return m, nil
+	}
+	if !m.ready() {
+		return m, nil
+	}
+
+	name := m.savepoints[len(m.savepoints)-1]
+	return m, m.markRunning("Rolling back to "+name+"...", restoreSavepoint(m.ctx, m.conn, name))
+}
+
+func (m Session) finishTransaction(msg transactionFinishedMsg) (Session, tea.Cmd) {
+	m.running = false
+	m.cancelling = false
+	cmd := m.setTransaction(msg.inTransaction)
+	if msg.err != nil {
+		m.status = "Error: " + msg.err.Error()
+		return m, cmd
+	}
+
+	m.status = msg.status
+	if msg.savepoint != "" {
+		m.savepoints = append(m.savepoints, msg.savepoint)
+	}
+	if msg.rolledBackTo != "" {
+		// rolling back again steps to the savepoint before this one
+		for i, name := range m.savepoints {
+			if name == msg.rolledBackTo {
+				m.savepoints = m.savepoints[:i]
+				break
+			}
+		}
+	}
+
+	if msg.then != nil {
+		then := msg.then
+		return m, func() tea.Msg { return then }
+	}
+
+	return m, cmd
+}
+
+func (m Session) transactionNugget() string {
+	if !m.inTransaction {
+		return ""
+	}
+
+	return "TXN OPEN " + time.Since(m.txnStartedAt).Truncate(time.Second).String()
+}
+
+func (m Session) autocommitNugget() string {
+	if m.autocommit || m.inTransaction {
+		return ""
+	}
+	return "AUTOCOMMIT OFF"
+}
+
+func (m Session) vimNugget() string {
+	if !m.vimKeys() || m.resultsFocused {
+		return ""
+	}
+
+	vim := m.tab().vim
+	switch vim.mode {
+	case vimVisual, vimVisualLine:
+		return vim.mode.String() + " " + vim.selection(*m.tab())
+	case vimCommandLine:
+		return ""
+	}
+	return strings.TrimSpace(vim.mode.String() + " " + vim.pending())
+}
+
+func (m Session) runningNugget() string {
+	if !m.running {
+		return ""
+	}
+
+	elapsed := time.Since(m.startedAt).Truncate(100 * time.Millisecond)
+	return m.spinner.View() + " " + elapsed.String()
+}
+
+func (m Session) View() string {
+	switch m.overlay {
+	case historyOverlay:
+		return m.historySearch.View()
+	case savedQueriesOverlay:
+		return m.savedQueries.View()
+	case saveQueryOverlay:
+		return m.saveQuery.View()
+	case bindParamsOverlay:
+		return m.bindParams.View()
+	case explainOverlay:
+		return m.explainView.View()
+	case transactionOverlay:
+		return m.txnPrompt.View()
+	case discardOverlay:
+		return m.discardPrompt.View()
+	case guardOverlay:
+		return m.guardPrompt.View()
+	case inspectorOverlay:
+		return m.inspector.View()
+	case exportOverlay:
+		return m.exportPrompt.View()
+	case changesOverlay:
+		return m.review.View()
+	case insertOverlay:
+		return m.insertRow.View()
+	case browseOverlay:
+		return m.browsePrompt.View()
+	case presetsOverlay:
+		return m.presetList.View()
+	}
+
+	names := []string{}
+	for _, t := range m.tabs {
+		names = append(names, t.Name())
+	}
+
+	width, editorHeight := m.editorSize()
+	_, resultsHeight := m.resultsSize()
+	resultsBox := dialogBoxStyle.Margin(0).Width(width).Height(resultsHeight)
+	if m.resultsFocused {
+		resultsBox = resultsBox.BorderForeground(lipgloss.Color("#F25D94"))
+	}
+
+	quickKeys := sessionQuickKeys
+	if m.resultsFocused {
+		quickKeys = resultsQuickKeys
+	}
+
+	status := m.status
+	if m.vimKeys() && m.tab().vim.mode == vimCommandLine {
+		status = m.tab().vim.pending()
+	}
+	body := lipgloss.NewStyle().
+		Height(m.height - 3).
+		MaxHeight(m.height - 3).
+		Render(lipgloss.JoinVertical(
+			lipgloss.Left,
+			dialogBoxStyle.Margin(0).Height(editorHeight).Render(m.tab().editor.View()),
+			resultsBox.Render(
+				m.tab().grid.View(),
+			),
+		))
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		components.NewTabBar(m.width, names, m.active),
+		body,
+		components.NewQuickKeysFor(m.width, quickKeys...),
+		components.NewStatusBarWithAlert(
+			m.width,
+			status,
+			m.transactionNugget(),
+			m.vimNugget(),
+			m.runningNugget(),
+			m.autocommitNugget(),
+			m.database.ConnectionName,
+			m.tab().Name(),
+		),
+	)
+}
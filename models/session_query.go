@@ -0,0 +1,386 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/therealphatmike/squeal/util/changes"
+	"github.com/therealphatmike/squeal/util/databases"
+	"github.com/therealphatmike/squeal/util/explain"
+	"github.com/therealphatmike/squeal/util/export"
+	"github.com/therealphatmike/squeal/util/history"
+	"github.com/therealphatmike/squeal/util/layouts"
+	"github.com/therealphatmike/squeal/util/statements"
+)
+
+const cancelTimeout = 5 * time.Second
+
+type connectedMsg struct {
+	conn *databases.Connection
+	err  error
+}
+
+type queryFinishedMsg struct {
+	tabID         int
+	result        databases.QueryResult
+	err           error
+	ran           int
+	inTransaction bool
+	// stream is the rest of the last result when its first page didn't
+	// exhaust it
+	stream *databases.RowStream
+	// query is the last statement that ran and layout how its columns were
+	// last arranged
+	query  string
+	layout layouts.Layout
+	// table is where edits to the result are written back to, nil when it
+	// can't be edited for the reason in notEditable
+	table       *changes.Table
+	notEditable string
+}
+
+// changesAppliedMsg reports edits written back to a result's table.
+type changesAppliedMsg struct {
+	tabID         int
+	table         changes.Table
+	count         int
+	inserted      bool
+	err           error
+	inTransaction bool
+}
+
+type transactionFinishedMsg struct {
+	status        string
+	err           error
+	inTransaction bool
+	savepoint     string
+	rolledBackTo  string
+	then          tea.Msg
+}
+
+// runConfirmedMsg and explainConfirmedMsg resume running once destructive
+// statements have been confirmed.
+type runConfirmedMsg struct {
+	pending []pendingStatement
+}
+
+type explainConfirmedMsg struct {
+	query        string
+	explainQuery string
+	analyze      bool
+}
+
+type transactionTickMsg struct {
+	generation int
+}
+
+type explainFinishedMsg struct {
+	query string
+	plan  explain.Plan
+	err   error
+}
+
+type exportFinishedMsg struct {
+	path    string
+	rows    int
+	partial bool
+	err     error
+}
+
+type cancelRequestedMsg struct {
+	err error
+}
+
+// pendingStatement is a statement ready to send to the server. Original is
+// what the user wrote, which is what history records.
+type pendingStatement struct {
+	query    string
+	original string
+	args     []any
+}
+
+func connect(ctx context.Context, database databases.Database) tea.Cmd {
+	return func() tea.Msg {
+		conn, err := databases.Connect(ctx, database)
+		return connectedMsg{conn: conn, err: err}
+	}
+}
+
+// runStatements executes the statements in order off the update loop,
+// recording each in history, and stops at the first error. The result of the
+// last statement that ran is reported, along with whether a transaction is
+// open afterwards. With begin set a transaction is opened first.
+func runStatements(ctx context.Context, conn *databases.Connection, tabID int, pending []pendingStatement, inTransaction bool, begin bool) tea.Cmd {
+	return func() tea.Msg {
+		finished := queryFinishedMsg{tabID: tabID, inTransaction: inTransaction}
+		if begin {
+			if err := conn.Begin(ctx); err != nil {
+				finished.err = err
+				return finished
+			}
+			finished.inTransaction = true
+		}
+
+		for _, stmt := range pending {
+			returnsRows := statements.ReturnsRows(stmt.query, conn.Database.Engine)
+			started := time.Now()
+
+			var result databases.QueryResult
+			var stream *databases.RowStream
+			var table *changes.Table
+			var notEditable string
+			var err error
+			if returnsRows {
+				// the table is looked up first since it's done on the session
+				// connection, which would close the result
+				table, notEditable = editableTable(ctx, conn, stmt.query)
+				result, stream, err = firstPage(ctx, conn, stmt)
+			} else {
+				result, err = conn.Query(ctx, stmt.query, false, stmt.args...)
+			}
+
+			entry := history.Entry{
+				ConnectionName: conn.Database.ConnectionName,
+				Query:          stmt.original,
+				ExecutedAt:     started,
+				Duration:       result.Duration,
+				RowCount:       result.RowsAffected,
+			}
+			if err != nil {
+				entry.Error = err.Error()
+			}
+			// losing a history entry isn't worth failing the query over
+			_ = history.Record(entry)
+
+			finished.result = result
+			finished.stream = stream
+			finished.query = stmt.original
+			if returnsRows {
+				// without a remembered layout the columns show as returned
+				finished.layout, _ = layouts.Load(stmt.original)
+			}
+			finished.err = err
+			finished.ran++
+			if err != nil {
+				break
+			}
+
+			if returnsRows {
+				finished.table, finished.notEditable = table, notEditable
+			}
+
+			switch statements.Transaction(stmt.query, conn.Database.Engine) {
+			case statements.OpensTransaction:
+				finished.inTransaction = true
+			case statements.ClosesTransaction:
+				finished.inTransaction = false
+			}
+		}
+
+		return finished
+	}
+}
+
+// editableTable looks up what edits to a query's result would be written back
+// to, which takes a plain SELECT of columns from a single table with a
+// primary key.
+func editableTable(ctx context.Context, conn *databases.Connection, query string) (*changes.Table, string) {
+	name, ok := statements.SourceTable(query, conn.Database.Engine)
+	if !ok {
+		return nil, "only results from a single table can be edited"
+	}
+	if !statements.PlainColumns(query, conn.Database.Engine) {
+		return nil, "only results of * or plain columns can be edited, not aliases or expressions"
+	}
+
+	key, err := conn.PrimaryKey(ctx, name)
+	switch {
+	case err != nil:
+		return nil, "unable to find its primary key: " + err.Error()
+	case len(key) == 0:
+		return nil, strings.Join(name, ".") + " has no primary key"
+	}
+	return &changes.Table{Name: name, Key: key, Engine: conn.Database.Engine}, ""
+}
+
+// applyChanges writes edits to a table together. With begin set a transaction
+// is opened first and left open, as it is when one already was.
+func applyChanges(ctx context.Context, conn *databases.Connection, tabID int, msg applyChangesMsg, inTransaction bool, begin bool) tea.Cmd {
+	table, list := msg.table, msg.changes
+	return func() tea.Msg {
+		applied := changesAppliedMsg{tabID: tabID, table: table, count: len(list), inserted: msg.inserted, inTransaction: inTransaction}
+		if begin {
+			if applied.err = conn.Begin(ctx); applied.err != nil {
+				return applied
+			}
+			applied.inTransaction = true
+		}
+
+		execs := make([]databases.Exec, len(list))
+		for i, change := range list {
+			execs[i] = table.Exec(change)
+		}
+		applied.err = conn.Apply(ctx, execs, applied.inTransaction)
+		return applied
+	}
+}
+
+// loadInsertRow reads what the insert form needs about the table a query reads
+// from: its columns and the values its foreign keys can take.
+func loadInsertRow(ctx context.Context, conn *databases.Connection, query string) tea.Cmd {
+	return func() tea.Msg {
+		ready := insertRowReadyMsg{foreign: map[string]foreignChoices{}}
+		name, ok := statements.SourceTable(query, conn.Database.Engine)
+		if !ok {
+			ready.err = errors.New("rows can only be inserted from the result of a query on a single table")
+			return ready
+		}
+		ready.table = changes.Table{Name: name, Engine: conn.Database.Engine}
+
+		ready.columns, ready.err = conn.Columns(ctx, name)
+		for _, column := range ready.columns {
+			if ready.err != nil {
+				break
+			}
+			if column.References == nil {
+				continue
+			}
+			var choices foreignChoices
+			choices.values, choices.all, ready.err = conn.ForeignValues(ctx, *column.References, foreignValueLimit)
+			ready.foreign[column.Name] = choices
+		}
+		return ready
+	}
+}
+
+// firstPage starts streaming a statement's rows and reads the first page.
+// The stream is only returned while there are more rows to read.
+func firstPage(ctx context.Context, conn *databases.Connection, stmt pendingStatement) (databases.QueryResult, *databases.RowStream, error) {
+	start := time.Now()
+	stream, err := conn.Stream(ctx, stmt.query, stmt.args...)
+	if err != nil {
+		return databases.QueryResult{Duration: time.Since(start)}, nil, err
+	}
+
+	rows, err := stream.Fetch(gridPageSize)
+	result := databases.QueryResult{
+		Columns:      stream.Columns,
+		Rows:         rows,
+		RowsAffected: int64(len(rows)),
+		Duration:     time.Since(start),
+	}
+	if err != nil || stream.Done() {
+		return result, nil, err
+	}
+
+	// the total isn't known until the rest has been read
+	result.RowsAffected = -1
+	return result, stream, nil
+}
+
+// explainStatement runs an EXPLAIN built by explain.Wrap and parses the JSON
+// document it returns in its only cell.
+func explainStatement(ctx context.Context, conn *databases.Connection, query string, explainQuery string) tea.Cmd {
+	return func() tea.Msg {
+		finished := explainFinishedMsg{query: query}
+		finished.plan, finished.err = readPlan(ctx, conn, explainQuery)
+		return finished
+	}
+}
+
+func readPlan(ctx context.Context, conn *databases.Connection, explainQuery string) (explain.Plan, error) {
+	result, err := conn.Query(ctx, explainQuery, true)
+	if err != nil {
+		return explain.Plan{}, err
+	}
+	if len(result.Rows) == 0 || len(result.Rows[0]) == 0 {
+		return explain.Plan{}, errors.New("the server returned no plan")
+	}
+
+	document := ""
+	switch cell := result.Rows[0][len(result.Rows[0])-1].(type) {
+	case []byte:
+		document = string(cell)
+	case string:
+		document = cell
+	default:
+		return explain.Plan{}, fmt.Errorf("unexpected plan of type %T", cell)
+	}
+
+	return explain.Parse(document, conn.Database.Engine)
+}
+
+// closeTransaction commits or rolls back, then passes then along.
+func closeTransaction(ctx context.Context, conn *databases.Connection, commit bool, then tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		if commit {
+			if err := conn.Commit(ctx); err != nil {
+				return transactionFinishedMsg{err: err, inTransaction: true}
+			}
+			return transactionFinishedMsg{status: "Committed", then: then}
+		}
+
+		if err := conn.Rollback(ctx); err != nil {
+			return transactionFinishedMsg{err: err, inTransaction: true}
+		}
+		return transactionFinishedMsg{status: "Rolled back", then: then}
+	}
+}
+
+func setSavepoint(ctx context.Context, conn *databases.Connection, name string, begin bool) tea.Cmd {
+	return func() tea.Msg {
+		if begin {
+			if err := conn.Begin(ctx); err != nil {
+				return transactionFinishedMsg{err: err}
+			}
+		}
+		if err := conn.Savepoint(ctx, name); err != nil {
+			return transactionFinishedMsg{err: err, inTransaction: true}
+		}
+		return transactionFinishedMsg{status: "Created savepoint " + name, inTransaction: true, savepoint: name}
+	}
+}
+
+func restoreSavepoint(ctx context.Context, conn *databases.Connection, name string) tea.Cmd {
+	return func() tea.Msg {
+		if err := conn.RollbackToSavepoint(ctx, name); err != nil {
+			return transactionFinishedMsg{err: err, inTransaction: true}
+		}
+		return transactionFinishedMsg{status: "Rolled back to savepoint " + name, inTransaction: true, rolledBackTo: name}
+	}
+}
+
+func transactionTick(generation int) tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return transactionTickMsg{generation: generation}
+	})
+}
+
+func cancelQuery(conn *databases.Connection) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
+		defer cancel()
+
+		return cancelRequestedMsg{err: conn.Cancel(ctx)}
+	}
+}
+
+func exportResults(path string, columns []databases.Column, rows [][]any, opts export.Options, partial bool) tea.Cmd {
+	return func() tea.Msg {
+		finished := exportFinishedMsg{path: path, rows: len(rows), partial: partial}
+
+		f, err := os.Create(path)
+		if err != nil {
+			finished.err = err
+			return finished
+		}
+		finished.err = errors.Join(export.Write(f, columns, rows, opts), f.Close())
+		return finished
+	}
+}
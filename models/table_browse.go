@@ -0,0 +1,340 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/therealphatmike/squeal/util/changes"
+	"github.com/therealphatmike/squeal/util/databases"
+	"github.com/therealphatmike/squeal/util/explain"
+	"github.com/therealphatmike/squeal/util/paging"
+	"github.com/therealphatmike/squeal/util/statements"
+)
+
+// BrowseCancelledMsg is sent when no table is picked to browse.
+type BrowseCancelledMsg struct{}
+
+// browseTableMsg asks for a table to be browsed a page at a time.
+type browseTableMsg struct {
+	name string
+}
+
+type browseReadyMsg struct {
+	tabID  int
+	browse tableBrowse
+	err    error
+}
+
+// browseRefinedMsg carries the first page of a table read with a new
+// condition or sort, and how many rows are thought to match.
+type browseRefinedMsg struct {
+	tabID     int
+	pager     paging.Pager
+	estimated int64
+	page      browsePageMsg
+}
+
+type browsePageMsg struct {
+	tabID  int
+	result databases.QueryResult
+	fetch  paging.Fetch
+	to     pageTarget
+	page   int
+	err    error
+}
+
+// tableBrowse is a table being read a page at a time in a tab, rather than
+// all at once, so tables of any size can be looked through.
+type tableBrowse struct {
+	pager paging.Pager
+	// table is where edits go, nil without a primary key for the reason in
+	// notEditable
+	table       *changes.Table
+	notEditable string
+	estimated   int64
+
+	// page is the number of the page showing, approximate when it came from
+	// the estimate; first and last are the keys of the rows at either end
+	page        int
+	approximate bool
+	last        bool
+	firstKey    []any
+	lastKey     []any
+}
+
+// openBrowse looks up what paging through a table needs: its primary key,
+// which pages are found by, and how many rows it's thought to have.
+func openBrowse(ctx context.Context, conn *databases.Connection, tabID int, name string, pageSize int) tea.Cmd {
+	return func() tea.Msg {
+		ready := browseReadyMsg{tabID: tabID}
+		table, ok := statements.SourceTable("SELECT * FROM "+name, conn.Database.Engine)
+		if !ok {
+			ready.err = errors.New(name + " isn't a table name")
+			return ready
+		}
+
+		key, err := conn.PrimaryKey(ctx, table)
+		if err != nil {
+			ready.err = err
+			return ready
+		}
+		estimated, err := conn.EstimatedRows(ctx, table)
+		if err != nil {
+			ready.err = err
+			return ready
+		}
+
+		ready.browse = tableBrowse{
+			pager:     paging.Pager{Table: table, Key: key, Engine: conn.Database.Engine, Size: max(pageSize, 1)},
+			estimated: estimated,
+		}
+		if len(key) > 0 {
+			ready.browse.table = &changes.Table{Name: table, Key: key, Engine: conn.Database.Engine}
+		} else {
+			ready.browse.notEditable = strings.Join(table, ".") + " has no primary key"
+		}
+		return ready
+	}
+}
+
+// fetchBrowsePage reads a page on the session connection, so it sees the open
+// transaction and can be cancelled like any query.
+func fetchBrowsePage(ctx context.Context, conn *databases.Connection, tabID int, fetch paging.Fetch, to pageTarget, page int) tea.Cmd {
+	return func() tea.Msg {
+		return readBrowsePage(ctx, conn, tabID, fetch, to, page)
+	}
+}
+
+func readBrowsePage(ctx context.Context, conn *databases.Connection, tabID int, fetch paging.Fetch, to pageTarget, page int) browsePageMsg {
+	result, err := conn.Query(ctx, fetch.Query, true, fetch.Args...)
+	if fetch.Reversed {
+		slices.Reverse(result.Rows)
+	}
+	return browsePageMsg{tabID: tabID, result: result, fetch: fetch, to: to, page: page, err: err}
+}
+
+// refineBrowse reads the first page of a table again with the pager's
+// condition and sort. A condition's match count comes from the plan, since
+// the table's statistics only know how many rows there are in all.
+func refineBrowse(ctx context.Context, conn *databases.Connection, tabID int, pager paging.Pager) tea.Cmd {
+	return func() tea.Msg {
+		refined := browseRefinedMsg{tabID: tabID, pager: pager, estimated: -1}
+		if pager.Where == "" {
+			refined.estimated, _ = conn.EstimatedRows(ctx, pager.Table)
+		} else if query, err := explain.Wrap(pager.Base(), conn.Database.Engine, false); err == nil {
+			if plan, err := readPlan(ctx, conn, query); err == nil {
+				refined.estimated = planRows(plan)
+			}
+		}
+
+		refined.page = readBrowsePage(ctx, conn, tabID, pager.First(), toFirstPage, 1)
+		return refined
+	}
+}
+
+// planRows is the row estimate of the outermost step of a plan that has one,
+// or -1.
+func planRows(plan explain.Plan) int64 {
+	rows := int64(-1)
+	explain.Walk(plan.Root, func(n *explain.Node, depth int) {
+		if rows < 0 && n.EstimatedRows > 0 {
+			rows = int64(n.EstimatedRows)
+		}
+	})
+	return rows
+}
+
+// pageFetch is the query for the page a request asks for and that page's
+// number.
+func (b tableBrowse) pageFetch(request pageRequestMsg) (paging.Fetch, int) {
+	p := b.pager
+	switch request.to {
+	case toNextPage:
+		if p.Keyset() {
+			return p.After(b.lastKey), b.page + 1
+		}
+		return p.Page(b.page + 1), b.page + 1
+	case toPreviousPage:
+		if p.Keyset() {
+			return p.Before(b.firstKey), max(b.page-1, 1)
+		}
+		return p.Page(max(b.page-1, 1)), max(b.page-1, 1)
+	case toLastPage:
+		if p.Keyset() {
+			return p.Last(), p.Pages(b.estimated)
+		}
+		return p.Page(p.Pages(b.estimated)), p.Pages(b.estimated)
+	case toPageNumber:
+		return p.Page(request.number), request.number
+	}
+	return p.First(), 1
+}
+
+// showPage takes in a page that's been read, reporting false when it was
+// empty and there's nothing to show.
+func (b *tableBrowse) showPage(msg browsePageMsg) bool {
+	rows := msg.result.Rows
+	if len(rows) == 0 && msg.to != toFirstPage {
+		if msg.to == toNextPage {
+			b.last = true
+		}
+		return false
+	}
+
+	b.page = msg.page
+	switch msg.to {
+	case toFirstPage, toPageNumber:
+		b.approximate = false
+	case toLastPage:
+		// with a key the last page is found exactly but its number isn't
+		b.approximate = b.pager.Keyset() || b.estimated < 0
+	}
+
+	short := len(rows) < b.pager.Size
+	b.last = msg.to == toLastPage || short && !msg.fetch.Reversed
+	if short && msg.fetch.Reversed && msg.to == toPreviousPage {
+		// reading back ran out of rows, so this is the start
+		b.page, b.approximate = 1, false
+	}
+
+	b.firstKey, b.lastKey = nil, nil
+	if len(rows) > 0 {
+		b.firstKey = keyValues(msg.result.Columns, rows[0], b.pager.Key)
+		b.lastKey = keyValues(msg.result.Columns, rows[len(rows)-1], b.pager.Key)
+	}
+	return true
+}
+
+func (b tableBrowse) pageInfo() pageInfo {
+	return pageInfo{
+		number:      b.page,
+		pages:       b.pager.Pages(b.estimated),
+		estimated:   b.estimated,
+		approximate: b.approximate,
+		keyset:      b.pager.Keyset(),
+		last:        b.last,
+		engine:      b.pager.Engine,
+		where:       b.pager.Where,
+		order:       b.pager.Order,
+		query:       b.pager.Base(),
+	}
+}
+
+// layoutQuery is the query the grid's layout is kept under, the same however
+// the table is filtered or sorted.
+func (b tableBrowse) layoutQuery() string {
+	p := b.pager
+	p.Where, p.Order = "", nil
+	return p.Base()
+}
+
+// keyValues picks the key columns' values out of a row.
+func keyValues(columns []databases.Column, row []any, key []string) []any {
+	values := []any{}
+	for _, name := range key {
+		for i, column := range columns {
+			if strings.EqualFold(column.Name, name) {
+				values = append(values, row[i])
+				break
+			}
+		}
+	}
+	return values
+}
+
+// BrowsePrompt asks which table to browse.
+type BrowsePrompt struct {
+	width  int
+	height int
+	name   *string
+	form   *huh.Form
+}
+
+func NewBrowsePrompt(width int, height int, suggested string) BrowsePrompt {
+	name := suggested
+	return BrowsePrompt{
+		width:  width,
+		height: height,
+		name:   &name,
+		form: huh.NewForm(huh.NewGroup(
+			huh.NewInput().
+				Title("Table").
+				Description("Read a page at a time, by primary key when it has one. W filters it and o sorts by a column.").
+				Placeholder("schema.table").
+				Value(&name).
+				Validate(func(s string) error {
+					if strings.TrimSpace(s) == "" {
+						return errors.New("which table?")
+					}
+					return nil
+				}),
+		)).
+			WithShowHelp(false).
+			WithShowErrors(true),
+	}
+}
+
+func (m BrowsePrompt) Init() tea.Cmd {
+	return m.form.Init()
+}
+
+func (m BrowsePrompt) Update(msg tea.Msg) (BrowsePrompt, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+	case tea.KeyMsg:
+		if msg.String() == "esc" {
+			return m, func() tea.Msg { return BrowseCancelledMsg{} }
+		}
+	}
+
+	form, cmd := m.form.Update(msg)
+	if f, ok := form.(*huh.Form); ok {
+		m.form = f
+	}
+
+	switch m.form.State {
+	case huh.StateAborted:
+		return m, func() tea.Msg { return BrowseCancelledMsg{} }
+	case huh.StateCompleted:
+		browse := browseTableMsg{name: strings.TrimSpace(*m.name)}
+		return m, func() tea.Msg { return browse }
+	}
+
+	return m, cmd
+}
+
+func (m BrowsePrompt) View() string {
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		dialogBoxStyle.Width(min(max(m.width-10, 40), 80)).Render(
+			lipgloss.JoinVertical(
+				lipgloss.Left,
+				savedQueryNameStyle.Render("Browse Table"),
+				m.form.View(),
+			),
+		),
+		lipgloss.WithWhitespaceChars(" "),
+		lipgloss.WithWhitespaceForeground(subtle),
+	)
+}
+
+// browseStatus reports a page that's been read.
+func browseStatus(b tableBrowse, duration time.Duration) string {
+	info := b.pageInfo()
+	number := formatCount(info.number)
+	if info.approximate {
+		number = "~" + number
+	}
+	return "Page " + number + " of " + strings.Join(b.pager.Table, ".") + " in " + duration.Round(time.Millisecond).String()
+}
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type transactionChoice int
+
+const (
+	stayInTransaction transactionChoice = iota
+	commitTransaction
+	rollbackTransaction
+)
+
+// TransactionResolvedMsg is sent once the user has decided what to do with
+// the open transaction before leaving the session.
+type TransactionResolvedMsg struct {
+	choice transactionChoice
+	then   tea.Msg
+}
+
+// TransactionPrompt asks whether to commit or roll back the open transaction
+// before disconnecting or quitting. Then is sent after the transaction ends.
+type TransactionPrompt struct {
+	width  int
+	height int
+	choice *transactionChoice
+	then   tea.Msg
+	form   *huh.Form
+}
+
+func NewTransactionPrompt(width int, height int, connectionName string, openFor time.Duration, then tea.Msg) TransactionPrompt {
+	// rolling back is the default so a hasty enter can't keep changes that
+	// weren't checked
+	choice := rollbackTransaction
+
+	leaving := "disconnecting"
+	if _, ok := then.(QuitMsg); ok {
+		leaving = "quitting"
+	}
+
+	return TransactionPrompt{
+		width:  width,
+		height: height,
+		choice: &choice,
+		then:   then,
+		form: huh.NewForm(
+			huh.NewGroup(
+				huh.NewSelect[transactionChoice]().
+					Title("A transaction on "+connectionName+" has been open for "+openFor.Round(time.Second).String()).
+					Description("What should happen to it before "+leaving+"?").
+					Options(
+						huh.NewOption("Roll back", rollbackTransaction),
+						huh.NewOption("Commit", commitTransaction),
+						huh.NewOption("Stay connected", stayInTransaction),
+					).
+					Value(&choice),
+			),
+		).WithShowHelp(false),
+	}
+}
+
+func (m TransactionPrompt) Init() tea.Cmd {
+	return m.form.Init()
+}
+
+func (m TransactionPrompt) Update(msg tea.Msg) (TransactionPrompt, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+	case tea.KeyMsg:
+		if msg.String() == "esc" {
+			return m, func() tea.Msg { return TransactionResolvedMsg{choice: stayInTransaction} }
+		}
+	}
+
+	form, cmd := m.form.Update(msg)
+	if f, ok := form.(*huh.Form); ok {
+		m.form = f
+	}
+
+	switch m.form.State {
+	case huh.StateAborted:
+		return m, func() tea.Msg { return TransactionResolvedMsg{choice: stayInTransaction} }
+	case huh.StateCompleted:
+		resolved := TransactionResolvedMsg{choice: *m.choice, then: m.then}
+		return m, func() tea.Msg { return resolved }
+	}
+
+	return m, cmd
+}
+
+func (m TransactionPrompt) View() string {
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		dialogBoxStyle.Width(min(max(m.width-10, 40), 80)).Render(
+			lipgloss.JoinVertical(
+				lipgloss.Left,
+				savedQueryNameStyle.Render("Open Transaction"),
+				m.form.View(),
+			),
+		),
+		lipgloss.WithWhitespaceChars(" "),
+		lipgloss.WithWhitespaceForeground(subtle),
+	)
+}
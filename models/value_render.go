@@ -0,0 +1,292 @@
+package models
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/therealphatmike/squeal/util/databases"
+	"github.com/therealphatmike/squeal/util/settings"
+)
+
+// binaryPreviewBytes is how much of a binary value is shown as hex in the grid.
+const binaryPreviewBytes = 8
+
+// valueKind is how a column's values are drawn in the grid, from the type name
+// the driver reports.
+type valueKind int
+
+const (
+	textValue valueKind = iota
+	numberValue
+	booleanValue
+	timestampValue
+	zonedTimestampValue
+	dateValue
+	binaryValue
+	intervalValue
+	arrayValue
+)
+
+func valueKindOf(column databases.Column) valueKind {
+	t := strings.ToUpper(column.DatabaseType)
+	if strings.HasPrefix(t, "_") {
+		// postgres names array types after their element type
+		return arrayValue
+	}
+
+	switch strings.TrimPrefix(t, "UNSIGNED ") {
+	case "INT2", "INT4", "INT8", "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT",
+		"FLOAT4", "FLOAT8", "FLOAT", "DOUBLE", "REAL", "NUMERIC", "DECIMAL", "OID":
+		return numberValue
+	case "BOOL", "BOOLEAN":
+		return booleanValue
+	case "TIMESTAMPTZ":
+		return zonedTimestampValue
+	case "TIMESTAMP", "DATETIME":
+		return timestampValue
+	case "DATE":
+		return dateValue
+	case "BYTEA", "BINARY", "VARBINARY", "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB":
+		return binaryValue
+	case "INTERVAL":
+		return intervalValue
+	}
+	return textValue
+}
+
+// cellRenderer draws values for the grid. Only what's drawn changes; search,
+// filters, copying and exports still see the values as the driver sent them.
+type cellRenderer struct {
+	thousands string
+	// location is where zoned timestamps are shown, nil to leave them be
+	location *time.Location
+}
+
+func newCellRenderer(s settings.Settings) (cellRenderer, error) {
+	r := cellRenderer{thousands: s.ThousandsSeparator}
+	if s.TimeZone == "" {
+		return r, nil
+	}
+
+	location, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return r, fmt.Errorf("unknown time zone %q", s.TimeZone)
+	}
+	r.location = location
+	return r, nil
+}
+
+// alignRight reports whether the column's values line up on the right.
+func (r cellRenderer) alignRight(column databases.Column) bool {
+	return valueKindOf(column) == numberValue
+}
+
+func (r cellRenderer) render(column databases.Column, value any) string {
+	if value == nil {
+		return "NULL"
+	}
+	if b, ok := value.([]byte); ok && !utf8.Valid(b) {
+		return binaryPreview(b)
+	}
+
+	switch valueKindOf(column) {
+	case numberValue:
+		if s, ok := numberText(value); ok {
+			return groupDigits(s, r.thousands)
+		}
+	case booleanValue:
+		switch v := value.(type) {
+		case bool:
+			if v {
+				return "✓"
+			}
+			return "✗"
+		}
+	case zonedTimestampValue, timestampValue, dateValue:
+		if t, ok := value.(time.Time); ok {
+			return r.timestamp(column, t)
+		}
+	case binaryValue:
+		if b, ok := value.([]byte); ok {
+			return binaryPreview(b)
+		}
+	case intervalValue:
+		return prettyInterval(displayValue(value))
+	case arrayValue:
+		if s := displayValue(value); strings.HasPrefix(s, "{") {
+			return prettyArray(s)
+		}
+	}
+
+	return singleLine(displayValue(value))
+}
+
+func (r cellRenderer) timestamp(column databases.Column, t time.Time) string {
+	switch valueKindOf(column) {
+	case dateValue:
+		return t.Format("2006-01-02")
+	case timestampValue:
+		// the wall clock time is all there is, whatever zone the driver
+		// attached
+		return t.Format("2006-01-02 15:04:05.999999")
+	}
+	if r.location != nil {
+		t = t.In(r.location)
+	}
+	return t.Format("2006-01-02 15:04:05.999999 -07:00")
+}
+
+func numberText(value any) (string, bool) {
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case []byte:
+		return string(v), true
+	case string:
+		return v, true
+	}
+	return "", false
+}
+
+// groupDigits puts the separator between groups of three digits in the whole
+// part of a number, leaving anything that isn't a plain decimal alone.
+func groupDigits(s string, separator string) string {
+	if separator == "" {
+		return s
+	}
+
+	sign, digits := "", s
+	if strings.HasPrefix(digits, "-") || strings.HasPrefix(digits, "+") {
+		sign, digits = digits[:1], digits[1:]
+	}
+	whole, fraction, hasFraction := strings.Cut(digits, ".")
+	if whole == "" || strings.TrimLeft(whole, "0123456789") != "" {
+		return s
+	}
+
+	var out strings.Builder
+	out.WriteString(sign)
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			out.WriteString(separator)
+		}
+		out.WriteRune(digit)
+	}
+	if hasFraction {
+		out.WriteString("." + fraction)
+	}
+	return out.String()
+}
+
+// binaryPreview is the start of the value in hex and how long it is.
+func binaryPreview(b []byte) string {
+	preview := `\x` + hex.EncodeToString(b[:min(len(b), binaryPreviewBytes)])
+	if len(b) > binaryPreviewBytes {
+		preview += "…"
+	}
+	return fmt.Sprintf("%s (%s bytes)", preview, formatCount(len(b)))
+}
+
+// prettyInterval shortens postgres' default interval output, so
+// "1 year 2 mons 3 days 04:05:06" becomes "1y 2mo 3d 4h 5m 6s".
+func prettyInterval(s string) string {
+	units := map[string]string{
+		"year": "y", "years": "y", "mon": "mo", "mons": "mo", "day": "d", "days": "d",
+	}
+
+	parts := []string{}
+	fields := strings.Fields(s)
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if i+1 < len(fields) && units[fields[i+1]] != "" {
+			parts = append(parts, field+units[fields[i+1]])
+			i++
+			continue
+		}
+
+		sign := ""
+		if strings.HasPrefix(field, "-") {
+			sign, field = "-", field[1:]
+		}
+		clock := strings.Split(field, ":")
+		if len(clock) != 3 {
+			return s
+		}
+		hours, errH := strconv.Atoi(clock[0])
+		minutes, errM := strconv.Atoi(clock[1])
+		seconds, errS := strconv.ParseFloat(clock[2], 64)
+		if errH != nil || errM != nil || errS != nil {
+			return s
+		}
+		if hours > 0 {
+			parts = append(parts, fmt.Sprintf("%s%dh", sign, hours))
+		}
+		if minutes > 0 {
+			parts = append(parts, fmt.Sprintf("%s%dm", sign, minutes))
+		}
+		if seconds > 0 {
+			parts = append(parts, sign+strconv.FormatFloat(seconds, 'f', -1, 64)+"s")
+		}
+	}
+
+	if len(parts) == 0 {
+		return "0s"
+	}
+	return strings.Join(parts, " ")
+}
+
+// prettyArray turns a postgres array literal like {1,2,"a b"} into
+// [1, 2, a b], nested arrays included.
+func prettyArray(s string) string {
+	var out strings.Builder
+	quoted, escaped := false, false
+	for _, c := range s {
+		switch {
+		case escaped:
+			out.WriteRune(c)
+			escaped = false
+		case quoted && c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+			out.WriteRune(c)
+		case c == '{':
+			out.WriteRune('[')
+		case c == '}':
+			out.WriteRune(']')
+		case c == ',':
+			out.WriteString(", ")
+		default:
+			out.WriteRune(c)
+		}
+	}
+	return singleLine(out.String())
+}
+
+// singleLine makes text safe to draw in a single line cell: line breaks and
+// tabs become spaces and other control characters, which would throw the
+// widths off, become the replacement character.
+func singleLine(s string) string {
+	if !utf8.ValidString(s) {
+		s = strings.ToValidUTF8(s, "�")
+	}
+	return strings.Map(func(c rune) rune {
+		switch {
+		case c == '\n' || c == '\t':
+			return ' '
+		case c == '\r':
+			return -1
+		case unicode.IsControl(c):
+			return '�'
+		}
+		return c
+	}, s)
+}
@@ -0,0 +1,670 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type vimMode int
+
+const (
+	vimNormal vimMode = iota
+	vimInsert
+	vimVisual
+	vimVisualLine
+	vimCommandLine
+)
+
+func (m vimMode) String() string {
+	switch m {
+	case vimInsert:
+		return "INSERT"
+	case vimVisual:
+		return "VISUAL"
+	case vimVisualLine:
+		return "V-LINE"
+	case vimCommandLine:
+		return "COMMAND"
+	}
+	return "NORMAL"
+}
+
+// vimCommand is an ex command the editor can't carry out itself, like :w.
+type vimCommand struct {
+	name     string
+	argument string
+}
+
+type vimSnapshot struct {
+	text string
+	pos  int
+}
+
+const vimUndoLimit = 100
+
+// vimState is a tab's modal editing state. Edits are made on the buffer as
+// runes and written back to the textarea, which only handles insert mode.
+type vimState struct {
+	mode vimMode
+
+	// count, operator, object and g are the parts of a normal mode command
+	// typed so far, as in 2dgg or ci
+	count    string
+	operator string
+	opCount  int
+	object   string
+	g        bool
+
+	register string
+	linewise bool
+	anchor   int
+	command  string
+	undo     []vimSnapshot
+}
+
+func (v *vimState) reset() {
+	v.count = ""
+	v.operator = ""
+	v.opCount = 0
+	v.object = ""
+	v.g = false
+}
+
+func (v *vimState) takeCount() int {
+	count, err := strconv.Atoi(v.count)
+	v.count = ""
+	if err != nil || count < 1 {
+		return 1
+	}
+	return count
+}
+
+func (v *vimState) snapshot(t *QueryTab) {
+	v.undo = append(v.undo, vimSnapshot{text: t.editor.Value(), pos: t.runeCursor()})
+	if len(v.undo) > vimUndoLimit {
+		v.undo = v.undo[1:]
+	}
+}
+
+// pending is what the status bar shows while a command is being typed.
+func (v vimState) pending() string {
+	if v.mode == vimCommandLine {
+		return ":" + v.command
+	}
+	pending := v.count
+	if v.operator != "" {
+		pending = strconv.Itoa(v.opCount) + v.operator + pending + v.object
+	}
+	if v.g {
+		pending += "g"
+	}
+	return pending
+}
+
+type vimMotion struct {
+	pos       int
+	inclusive bool
+	linewise  bool
+}
+
+func lineStart(buf []rune, pos int) int {
+	for pos > 0 && buf[pos-1] != '\n' {
+		pos--
+	}
+	return pos
+}
+
+func lineEnd(buf []rune, pos int) int {
+	for pos < len(buf) && buf[pos] != '\n' {
+		pos++
+	}
+	return pos
+}
+
+// lastChar is where the normal mode cursor sits at the end of a line.
+func lastChar(buf []rune, pos int) int {
+	return max(lineEnd(buf, pos)-1, lineStart(buf, pos))
+}
+
+func firstNonBlank(buf []rune, pos int) int {
+	pos = lineStart(buf, pos)
+	for pos < len(buf) && (buf[pos] == ' ' || buf[pos] == '\t') {
+		pos++
+	}
+	return pos
+}
+
+func lineNumber(buf []rune, pos int) int {
+	return strings.Count(string(buf[:pos]), "\n")
+}
+
+// startOfLine returns the offset of the line'th line, clamped to the buffer.
+func startOfLine(buf []rune, line int) int {
+	pos := 0
+	for ; line > 0; line-- {
+		next := lineEnd(buf, pos)
+		if next >= len(buf) {
+			break
+		}
+		pos = next + 1
+	}
+	return pos
+}
+
+func vimClass(r rune) int {
+	switch {
+	case unicode.IsSpace(r):
+		return 0
+	case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+		return 1
+	}
+	return 2
+}
+
+func nextWordStart(buf []rune, pos int) int {
+	if pos >= len(buf) {
+		return pos
+	}
+	class := vimClass(buf[pos])
+	for pos < len(buf) && vimClass(buf[pos]) == class && class != 0 {
+		pos++
+	}
+	for pos < len(buf) && vimClass(buf[pos]) == 0 {
+		pos++
+	}
+	return pos
+}
+
+func previousWordStart(buf []rune, pos int) int {
+	for pos > 0 && vimClass(buf[pos-1]) == 0 {
+		pos--
+	}
+	if pos == 0 {
+		return 0
+	}
+	class := vimClass(buf[pos-1])
+	for pos > 0 && vimClass(buf[pos-1]) == class {
+		pos--
+	}
+	return pos
+}
+
+func wordEnd(buf []rune, pos int) int {
+	pos++
+	for pos < len(buf) && vimClass(buf[pos]) == 0 {
+		pos++
+	}
+	if pos >= len(buf) {
+		return max(len(buf)-1, 0)
+	}
+	class := vimClass(buf[pos])
+	for pos+1 < len(buf) && vimClass(buf[pos+1]) == class {
+		pos++
+	}
+	return pos
+}
+
+// vimMove applies a motion count times.
+func vimMove(buf []rune, pos int, key string, count int) (vimMotion, bool) {
+	switch key {
+	case "h", "left", "backspace":
+		return vimMotion{pos: max(pos-count, lineStart(buf, pos))}, true
+	case "l", "right", " ":
+		return vimMotion{pos: min(pos+count, lastChar(buf, pos))}, true
+	case "j", "down", "k", "up":
+		line := lineNumber(buf, pos)
+		if key == "j" || key == "down" {
+			line += count
+		} else {
+			line = max(line-count, 0)
+		}
+		column := pos - lineStart(buf, pos)
+		start := startOfLine(buf, line)
+		return vimMotion{pos: min(start+column, lastChar(buf, start)), linewise: true}, true
+	case "w":
+		for ; count > 0; count-- {
+			pos = nextWordStart(buf, pos)
+		}
+		return vimMotion{pos: pos}, true
+	case "b":
+		for ; count > 0; count-- {
+			pos = previousWordStart(buf, pos)
+		}
+		return vimMotion{pos: pos}, true
+	case "e":
+		for ; count > 0; count-- {
+			pos = wordEnd(buf, pos)
+		}
+		return vimMotion{pos: pos, inclusive: true}, true
+	case "0", "home":
+		return vimMotion{pos: lineStart(buf, pos)}, true
+	case "^":
+		return vimMotion{pos: firstNonBlank(buf, pos)}, true
+	case "$", "end":
+		return vimMotion{pos: lastChar(buf, pos), inclusive: true}, true
+	case "G":
+		return vimMotion{pos: firstNonBlank(buf, startOfLine(buf, strings.Count(string(buf), "\n"))), linewise: true}, true
+	}
+
+	return vimMotion{}, false
+}
+
+// wordObject finds the span of the iw or aw text object at pos: the word,
+// or run of blanks, under the cursor, and for aw the blanks after it too, or
+// before it when there are none after.
+func wordObject(buf []rune, pos int, around bool) (int, int, bool) {
+	if pos >= len(buf) || buf[pos] == '\n' {
+		return 0, 0, false
+	}
+
+	same := func(i int) bool { return buf[i] != '\n' && vimClass(buf[i]) == vimClass(buf[pos]) }
+	start, end := pos, pos+1
+	for start > 0 && same(start-1) {
+		start--
+	}
+	for end < len(buf) && same(end) {
+		end++
+	}
+	if !around {
+		return start, end, true
+	}
+
+	blank := func(i int) bool { return buf[i] == ' ' || buf[i] == '\t' }
+	if vimClass(buf[pos]) == 0 {
+		// around blanks takes the word after them
+		if end < len(buf) && buf[end] != '\n' {
+			class := vimClass(buf[end])
+			for end < len(buf) && buf[end] != '\n' && vimClass(buf[end]) == class {
+				end++
+			}
+		}
+		return start, end, true
+	}
+	if end < len(buf) && blank(end) {
+		for end < len(buf) && blank(end) {
+			end++
+		}
+		return start, end, true
+	}
+	for start > 0 && blank(start-1) {
+		start--
+	}
+	return start, end, true
+}
+
+// vimRange widens a motion from pos into the span it covers for an operator.
+func vimRange(buf []rune, pos int, motion vimMotion) (int, int, bool) {
+	start, end := min(pos, motion.pos), max(pos, motion.pos)
+	if motion.linewise {
+		return lineStart(buf, start), lineEnd(buf, end), true
+	}
+	if motion.inclusive && end < len(buf) {
+		end++
+	}
+	return start, end, false
+}
+
+// update handles a key for the tab's editor. Keys it doesn't use in insert
+// mode are left for the textarea, which is what handled reports.
+func (v *vimState) update(t *QueryTab, key string) (handled bool, command *vimCommand) {
+	switch v.mode {
+	case vimInsert:
+		if key == "esc" {
+			v.mode = vimNormal
+			buf := []rune(t.editor.Value())
+			pos := t.runeCursor()
+			if pos > lineStart(buf, pos) {
+				t.setRuneCursor(pos - 1)
+			}
+			return true, nil
+		}
+		return false, nil
+	case vimCommandLine:
+		return true, v.commandLine(key)
+	case vimVisual, vimVisualLine:
+		v.visual(t, key)
+		return true, nil
+	}
+
+	v.normal(t, key)
+	return true, nil
+}
+
+func (v *vimState) commandLine(key string) *vimCommand {
+	switch key {
+	case "esc", "ctrl+c":
+		v.mode = vimNormal
+		v.command = ""
+	case "backspace":
+		if v.command == "" {
+			v.mode = vimNormal
+			return nil
+		}
+		runes := []rune(v.command)
+		v.command = string(runes[:len(runes)-1])
+	case "enter":
+		fields := strings.Fields(v.command)
+		v.mode = vimNormal
+		v.command = ""
+		if len(fields) == 0 {
+			return nil
+		}
+		return &vimCommand{name: fields[0], argument: strings.Join(fields[1:], " ")}
+	default:
+		if key == "space" {
+			key = " "
+		}
+		if len([]rune(key)) == 1 {
+			v.command += key
+		}
+	}
+	return nil
+}
+
+func (v *vimState) normal(t *QueryTab, key string) {
+	buf := []rune(t.editor.Value())
+	pos := t.runeCursor()
+
+	if v.object != "" {
+		if start, end, ok := wordObject(buf, pos, v.object == "a"); ok && key == "w" {
+			v.apply(t, buf, start, vimMotion{pos: end - 1, inclusive: true})
+			return
+		}
+		v.reset()
+		return
+	}
+
+	if len(key) == 1 && key[0] >= '0' && key[0] <= '9' && (key != "0" || v.count != "") {
+		v.count += key
+		return
+	}
+	explicit := v.count != ""
+	count := v.takeCount()
+
+	if v.g {
+		v.g = false
+		if key != "g" {
+			v.reset()
+			return
+		}
+		line := 0
+		if count > 1 {
+			line = count - 1
+		}
+		v.apply(t, buf, pos, vimMotion{pos: firstNonBlank(buf, startOfLine(buf, line)), linewise: true})
+		return
+	}
+	if key == "g" {
+		v.g = true
+		v.count = strconv.Itoa(count)
+		if count == 1 {
+			v.count = ""
+		}
+		return
+	}
+
+	if v.operator != "" && key == v.operator {
+		// dd, cc and yy work on whole lines
+		last := startOfLine(buf, lineNumber(buf, pos)+v.opCount*count-1)
+		v.apply(t, buf, pos, vimMotion{pos: last, linewise: true})
+		return
+	}
+
+	if v.operator != "" && (key == "i" || key == "a") {
+		v.object = key
+		return
+	}
+
+	switch key {
+	case "d", "c", "y":
+		if v.operator == "" {
+			v.operator = key
+			v.opCount = count
+			return
+		}
+	}
+
+	if v.operator == "c" && key == "w" {
+		// cw changes to the end of the word, like ce, but stays put on the
+		// last character of a word
+		if pos+1 >= len(buf) || vimClass(buf[pos+1]) != vimClass(buf[pos]) {
+			v.apply(t, buf, pos, vimMotion{pos: pos, inclusive: true})
+			return
+		}
+		key = "e"
+	}
+	if motion, ok := vimMove(buf, pos, key, count*max(v.opCount, 1)); ok {
+		if key == "G" && explicit {
+			motion.pos = firstNonBlank(buf, startOfLine(buf, count-1))
+		}
+		v.apply(t, buf, pos, motion)
+		return
+	}
+	if v.operator != "" {
+		v.reset()
+		return
+	}
+
+	switch key {
+	case "i":
+		v.insert(t)
+	case "a":
+		if pos < lineEnd(buf, pos) {
+			t.setRuneCursor(pos + 1)
+		}
+		v.insert(t)
+	case "I":
+		t.setRuneCursor(firstNonBlank(buf, pos))
+		v.insert(t)
+	case "A":
+		t.setRuneCursor(lineEnd(buf, pos))
+		v.insert(t)
+	case "o":
+		v.snapshot(t)
+		end := lineEnd(buf, pos)
+		v.replace(t, buf, end, end, "\n", end+1)
+		v.mode = vimInsert
+	case "O":
+		v.snapshot(t)
+		start := lineStart(buf, pos)
+		v.replace(t, buf, start, start, "\n", start)
+		v.mode = vimInsert
+	case "x":
+		if end := min(pos+count, lineEnd(buf, pos)); end > pos {
+			v.snapshot(t)
+			v.register, v.linewise = string(buf[pos:end]), false
+			v.replace(t, buf, pos, end, "", pos)
+			v.clampNormal(t)
+		}
+	case "X":
+		if start := max(pos-count, lineStart(buf, pos)); start < pos {
+			v.snapshot(t)
+			v.register, v.linewise = string(buf[start:pos]), false
+			v.replace(t, buf, start, pos, "", start)
+		}
+	case "D", "C":
+		v.operator = strings.ToLower(key)
+		v.apply(t, buf, pos, vimMotion{pos: lastChar(buf, pos), inclusive: true})
+	case "p", "P":
+		v.paste(t, buf, pos, key == "p", count)
+	case "u":
+		if len(v.undo) == 0 {
+			return
+		}
+		last := v.undo[len(v.undo)-1]
+		v.undo = v.undo[:len(v.undo)-1]
+		t.editor.SetValue(last.text)
+		t.setRuneCursor(last.pos)
+	case "v":
+		v.mode = vimVisual
+		v.anchor = pos
+	case "V":
+		v.mode = vimVisualLine
+		v.anchor = pos
+	case ":":
+		v.mode = vimCommandLine
+		v.command = ""
+	}
+}
+
+func (v *vimState) insert(t *QueryTab) {
+	v.snapshot(t)
+	v.mode = vimInsert
+}
+
+// apply moves the cursor, or runs the pending operator over the motion.
+func (v *vimState) apply(t *QueryTab, buf []rune, pos int, motion vimMotion) {
+	operator := v.operator
+	v.reset()
+
+	if operator == "" {
+		t.setRuneCursor(motion.pos)
+		v.clampNormal(t)
+		return
+	}
+
+	start, end, linewise := vimRange(buf, pos, motion)
+	v.register, v.linewise = string(buf[start:end]), linewise
+
+	switch operator {
+	case "y":
+		if !linewise {
+			t.setRuneCursor(start)
+		}
+	case "d":
+		v.snapshot(t)
+		if linewise {
+			// take a line break with the lines so no blank line is left
+			if end < len(buf) {
+				end++
+			} else if start > 0 {
+				start--
+			}
+		}
+		v.replace(t, buf, start, end, "", start)
+		if linewise {
+			remaining := []rune(t.editor.Value())
+			t.setRuneCursor(firstNonBlank(remaining, min(start, len(remaining))))
+		}
+		v.clampNormal(t)
+	case "c":
+		v.snapshot(t)
+		v.replace(t, buf, start, end, "", start)
+		v.mode = vimInsert
+	}
+}
+
+func (v *vimState) paste(t *QueryTab, buf []rune, pos int, after bool, count int) {
+	if v.register == "" {
+		return
+	}
+	v.snapshot(t)
+
+	text := strings.Repeat(v.register, count)
+	if v.linewise {
+		lines := strings.TrimSuffix(strings.Repeat(v.register+"\n", count), "\n")
+		if after {
+			end := lineEnd(buf, pos)
+			v.replace(t, buf, end, end, "\n"+lines, end+1)
+		} else {
+			start := lineStart(buf, pos)
+			v.replace(t, buf, start, start, lines+"\n", start)
+		}
+		return
+	}
+
+	at := pos
+	if after && pos < lineEnd(buf, pos) {
+		at++
+	}
+	v.replace(t, buf, at, at, text, at+len([]rune(text))-1)
+}
+
+func (v *vimState) visual(t *QueryTab, key string) {
+	buf := []rune(t.editor.Value())
+	pos := t.runeCursor()
+
+	switch key {
+	case "esc", "ctrl+c":
+		v.mode = vimNormal
+		return
+	case "v", "V":
+		mode := vimVisual
+		if key == "V" {
+			mode = vimVisualLine
+		}
+		if v.mode == mode {
+			v.mode = vimNormal
+		} else {
+			v.mode = mode
+		}
+		return
+	case "o":
+		v.anchor, pos = pos, v.anchor
+		t.setRuneCursor(pos)
+		return
+	case "d", "x", "y", "c":
+		motion := vimMotion{pos: v.anchor, inclusive: true, linewise: v.mode == vimVisualLine}
+		v.mode = vimNormal
+		v.operator = map[string]string{"d": "d", "x": "d", "y": "y", "c": "c"}[key]
+		if v.operator == "c" && motion.linewise {
+			// changing lines keeps an empty line to type into
+			start, end, _ := vimRange(buf, pos, motion)
+			v.reset()
+			v.snapshot(t)
+			v.register, v.linewise = string(buf[start:end]), true
+			v.replace(t, buf, start, end, "", start)
+			v.mode = vimInsert
+			return
+		}
+		v.apply(t, buf, pos, motion)
+		return
+	}
+
+	if len(key) == 1 && key[0] >= '1' && key[0] <= '9' || key == "0" && v.count != "" {
+		v.count += key
+		return
+	}
+	if v.g {
+		v.g = false
+		if key == "g" {
+			t.setRuneCursor(0)
+		}
+		return
+	}
+	if key == "g" {
+		v.g = true
+		return
+	}
+	if motion, ok := vimMove(buf, pos, key, v.takeCount()); ok {
+		t.setRuneCursor(motion.pos)
+	}
+}
+
+// selection describes the visual selection for the status bar.
+func (v vimState) selection(t QueryTab) string {
+	buf := []rune(t.editor.Value())
+	pos := t.runeCursor()
+	if v.mode == vimVisualLine {
+		lines := lineNumber(buf, max(pos, v.anchor)) - lineNumber(buf, min(pos, v.anchor)) + 1
+		return strconv.Itoa(lines) + " lines"
+	}
+	return strconv.Itoa(max(pos, v.anchor)-min(pos, v.anchor)+1) + " chars"
+}
+
+func (v *vimState) replace(t *QueryTab, buf []rune, start int, end int, text string, cursor int) {
+	value := string(buf[:start]) + text + string(buf[end:])
+	t.editor.SetValue(value)
+	t.setRuneCursor(cursor)
+}
+
+// clampNormal keeps the cursor on a character, as normal mode can't sit past
+// the end of a line.
+func (v *vimState) clampNormal(t *QueryTab) {
+	buf := []rune(t.editor.Value())
+	pos := t.runeCursor()
+	if pos > lastChar(buf, pos) {
+		t.setRuneCursor(lastChar(buf, pos))
+	}
+}
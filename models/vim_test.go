@@ -0,0 +1,85 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVim(t *testing.T) {
+	cases := []struct {
+		name   string
+		buffer string
+		cursor int
+		keys   string
+		want   string
+		at     int
+	}{
+		{"w", "select id, name from t", 0, "w", "select id, name from t", 7},
+		{"count w", "select id, name from t", 0, "3w", "select id, name from t", 11},
+		{"e", "select id, name from t", 0, "e", "select id, name from t", 5},
+		{"b", "select id, name from t", 11, "b", "select id, name from t", 9},
+		{"w across lines", "select id\nfrom t", 7, "w", "select id\nfrom t", 10},
+		{"gg", "a\n  b", 4, "gg", "a\n  b", 0},
+		{"G", "a\n  b", 0, "G", "a\n  b", 4},
+		{"count G", "a\nb\nc", 0, "2G", "a\nb\nc", 2},
+		{"dw", "select id from t", 0, "dw", "id from t", 0},
+		{"d count w", "select id from t", 0, "d2w", "from t", 0},
+		{"count dw", "select id from t", 0, "2dw", "from t", 0},
+		{"dd", "a\nb\nc", 2, "dd", "a\nc", 2},
+		{"count dd", "a\nb\nc", 0, "2dd", "c", 0},
+		{"dd on the last line", "a\n  b", 3, "dd", "a", 0},
+		{"ciw", "select name from t", 8, "ciwid<esc>", "select id from t", 8},
+		{"ciw on the first letter", "select name from t", 7, "ciwid<esc>", "select id from t", 8},
+		{"diw on the last word", "select name", 9, "diw", "select ", 6},
+		{"daw", "select name from t", 8, "daw", "select from t", 7},
+		{"daw on the last word", "select name", 9, "daw", "select", 5},
+		{"cw", "select id", 2, "cwlect<esc>", "select id", 5},
+		{"yyp", "a\nb", 0, "yyp", "a\na\nb", 2},
+		{"yyP", "a\nb", 2, "yyP", "a\nb\nb", 2},
+		{"xp", "ab", 0, "xp", "ba", 1},
+		{"count x", "abcd", 0, "3x", "d", 0},
+		{"count p", "ab", 0, "x2p", "baa", 2},
+		{"u", "select id", 0, "dwu", "select id", 0},
+		{"operator without a motion", "select id", 0, "dqx", "elect id", 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tab := NewQueryTab(1, 80, 20)
+			tab.editor.SetValue(tc.buffer)
+			tab.setRuneCursor(tc.cursor)
+
+			for _, key := range vimKeys(tc.keys) {
+				if handled, _ := tab.vim.update(&tab, key); !handled {
+					tab.editor.InsertString(key)
+				}
+			}
+
+			if got := tab.editor.Value(); got != tc.want {
+				t.Errorf("buffer = %q, want %q", got, tc.want)
+			}
+			if got := tab.runeCursor(); got != tc.at {
+				t.Errorf("cursor = %d, want %d", got, tc.at)
+			}
+			if tab.vim.mode != vimNormal {
+				t.Errorf("mode = %v, want normal", tab.vim.mode)
+			}
+		})
+	}
+}
+
+// vimKeys splits keys into the key names the session passes on, with <esc>
+// for escape.
+func vimKeys(keys string) []string {
+	var names []string
+	for keys != "" {
+		if strings.HasPrefix(keys, "<esc>") {
+			names = append(names, "esc")
+			keys = keys[len("<esc>"):]
+			continue
+		}
+		names = append(names, keys[:1])
+		keys = keys[1:]
+	}
+	return names
+}
@@ -17,6 +17,7 @@ const (
 	welcomeView viewState = iota
 	newDbForm
 	selectDbForm
+	sessionView
 )
 
 var emptySelectDbFormState = SelectDatabase{}
@@ -29,6 +30,7 @@ type MainModel struct {
 	selectedOption    string
 	newDbFormState    NewDatabase
 	selectDbFormState SelectDatabase
+	session           Session
 }
 
 func InitSqueal() (tea.Model, tea.Cmd) {
@@ -101,17 +103,44 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			_, newCmd := m.selectDbFormState.Update(msg)
 			cmds = append(cmds, newCmd)
 		}
+	case sessionView:
+		var newCmd tea.Cmd
+		m.session, newCmd = m.session.Update(msg)
+		cmds = append(cmds, newCmd)
 	}
 
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+	case DatabaseSelectedMsg:
+		m.state = sessionView
+		m.session = NewSession(m.width, m.height, msg.Database)
+		return m, m.session.Init()
+	case QuitMsg:
+		m.session.Close()
+		return m, tea.Quit
+	case DisconnectMsg:
+		m.session.Close()
+		m.state = selectDbForm
+		m.selectDbFormState = NewSelectDatabaseForm(m.width, m.height, m.databases)
+		return m, m.selectDbFormState.Init()
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c":
+			if m.state == sessionView {
+				// the session cancels its running query or asks about the open
+				// transaction and unapplied changes instead
+				if m.session.Running() || m.session.InTransaction() || m.session.Unapplied() > 0 {
+					return m, tea.Batch(cmds...)
+				}
+				m.session.Close()
+			}
 			return m, tea.Quit
 		case "ctrl+n":
+			if m.state == sessionView {
+				break
+			}
 			newDb := NewDatabaseForm(m.width, m.height)
 			m.state = newDbForm
 			m.newDbFormState = newDb
@@ -135,6 +164,8 @@ func (m MainModel) View() string {
 		} else {
 			return ""
 		}
+	case sessionView:
+		return m.session.View()
 	default:
 		return m.getNoDatabasesScreen(m.width, m.height)
 	}
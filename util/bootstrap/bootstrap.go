@@ -5,6 +5,9 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/therealphatmike/squeal/util/databases"
+	"github.com/therealphatmike/squeal/util/history"
+	"github.com/therealphatmike/squeal/util/queries"
+	"github.com/therealphatmike/squeal/util/settings"
 )
 
 func BootstrapSqueal() error {
@@ -12,6 +15,18 @@ func BootstrapSqueal() error {
 		return err
 	}
 
+	if err := history.InitHistoryDir(); err != nil {
+		return err
+	}
+
+	if err := queries.InitSavedQueriesFile(); err != nil {
+		return err
+	}
+
+	if err := settings.InitSettingsFile(); err != nil {
+		return err
+	}
+
 	userHome, err := os.UserHomeDir()
 	if err != nil {
 		return err
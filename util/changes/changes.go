@@ -0,0 +1,92 @@
+package changes
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/therealphatmike/squeal/util/databases"
+)
+
+type Kind int
+
+const (
+	Update Kind = iota
+	Insert
+	Delete
+)
+
+// Table is what edits to a result are written back to: a single table whose
+// primary key columns are all in the result.
+type Table struct {
+	Name   []string
+	Key    []string
+	Engine string
+}
+
+// Change is one row's worth of edits. Key holds the primary key values the
+// row was read with, in the order of Table.Key; inserts have none. Columns
+// and Values are what an update sets or an insert adds.
+type Change struct {
+	Kind    Kind
+	Key     []any
+	Columns []string
+	Values  []any
+}
+
+// Exec is the statement for the change, with bind parameters for the values.
+func (t Table) Exec(c Change) databases.Exec {
+	exec := databases.Exec{}
+	exec.Query = t.sql(c, func(value any) string {
+		exec.Args = append(exec.Args, value)
+		if t.Engine == databases.EnginePostgres {
+			return fmt.Sprintf("$%d", len(exec.Args))
+		}
+		return "?"
+	})
+	return exec
+}
+
+// Preview is the statement for the change with the values written in, for
+// showing before it runs.
+func (t Table) Preview(c Change) string {
+	return t.sql(c, func(value any) string {
+		return databases.QuoteLiteral(value, t.Engine)
+	})
+}
+
+func (t Table) sql(c Change, bind func(any) string) string {
+	table := databases.QuoteName(t.Name, t.Engine)
+
+	switch c.Kind {
+	case Insert:
+		columns, values := []string{}, []string{}
+		for i, column := range c.Columns {
+			columns = append(columns, databases.QuoteIdentifier(column, t.Engine))
+			values = append(values, bind(c.Values[i]))
+		}
+		if len(columns) == 0 {
+			if t.Engine == databases.EnginePostgres {
+				return fmt.Sprintf("INSERT INTO %s DEFAULT VALUES", table)
+			}
+			return fmt.Sprintf("INSERT INTO %s () VALUES ()", table)
+		}
+		return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), strings.Join(values, ", "))
+	case Delete:
+		return fmt.Sprintf("DELETE FROM %s WHERE %s", table, t.where(c, bind))
+	}
+
+	sets := []string{}
+	for i, column := range c.Columns {
+		sets = append(sets, databases.QuoteIdentifier(column, t.Engine)+" = "+bind(c.Values[i]))
+	}
+	return fmt.Sprintf("UPDATE %s SET %s WHERE %s", table, strings.Join(sets, ", "), t.where(c, bind))
+}
+
+func (t Table) where(c Change, bind func(any) string) string {
+	conditions := []string{}
+	for i, column := range t.Key {
+		// a key column can't be NULL, so = is enough
+		conditions = append(conditions, databases.QuoteIdentifier(column, t.Engine)+" = "+bind(c.Key[i]))
+	}
+	return strings.Join(conditions, " AND ")
+}
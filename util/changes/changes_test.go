@@ -0,0 +1,68 @@
+package changes
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/therealphatmike/squeal/util/databases"
+)
+
+func TestExec(t *testing.T) {
+	postgres := Table{Name: []string{"public", "order items"}, Key: []string{"order_id", "line"}, Engine: databases.EnginePostgres}
+	mysql := Table{Name: []string{"items"}, Key: []string{"id"}, Engine: databases.EngineMySQL}
+
+	cases := []struct {
+		name   string
+		table  Table
+		change Change
+		query  string
+		args   []any
+	}{
+		{
+			"update",
+			postgres,
+			Change{Kind: Update, Key: []any{int64(7), int64(2)}, Columns: []string{"qty", "note"}, Values: []any{"3", nil}},
+			`UPDATE "public"."order items" SET "qty" = $1, "note" = $2 WHERE "order_id" = $3 AND "line" = $4`,
+			[]any{"3", nil, int64(7), int64(2)},
+		},
+		{
+			"delete",
+			mysql,
+			Change{Kind: Delete, Key: []any{int64(9)}},
+			"DELETE FROM `items` WHERE `id` = ?",
+			[]any{int64(9)},
+		},
+		{
+			"insert",
+			mysql,
+			Change{Kind: Insert, Columns: []string{"name"}, Values: []any{"bolt"}},
+			"INSERT INTO `items` (`name`) VALUES (?)",
+			[]any{"bolt"},
+		},
+		{
+			"insert of defaults",
+			postgres,
+			Change{Kind: Insert},
+			`INSERT INTO "public"."order items" DEFAULT VALUES`,
+			nil,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			exec := tc.table.Exec(tc.change)
+			if exec.Query != tc.query || !reflect.DeepEqual(exec.Args, tc.args) {
+				t.Errorf("Exec() = %s %v, want %s %v", exec.Query, exec.Args, tc.query, tc.args)
+			}
+		})
+	}
+}
+
+func TestPreview(t *testing.T) {
+	table := Table{Name: []string{"notes"}, Key: []string{"id"}, Engine: databases.EnginePostgres}
+	got := table.Preview(Change{Kind: Update, Key: []any{int64(1)}, Columns: []string{"body"}, Values: []any{"it's"}})
+	want := `UPDATE "notes" SET "body" = 'it''s' WHERE "id" = 1`
+	if got != want {
+		t.Errorf("Preview() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,240 @@
+package databases
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+)
+
+// Connection is an open session against a configured database. Statements run
+// on a single dedicated server connection so session state such as
+// transactions carries over between them; the pool behind it is used for
+// side channels like cancelling a running query.
+type Connection struct {
+	Database  Database
+	db        *sql.DB
+	conn      *sql.Conn
+	backendID int64
+
+	// mu serialises use of conn, which can only have one result set open:
+	// the active stream is closed before anything else runs
+	mu     sync.Mutex
+	active *RowStream
+}
+
+type Column struct {
+	Name         string
+	DatabaseType string
+	Nullable     bool
+	// NullableKnown is false when the driver can't tell whether the column
+	// allows NULL
+	NullableKnown bool
+	// Precision and Scale are a decimal column's, when DecimalKnown; columns
+	// like PostgreSQL's unconstrained numeric don't have them
+	Precision    int64
+	Scale        int64
+	DecimalKnown bool
+}
+
+type QueryResult struct {
+	Columns      []Column
+	Rows         [][]any
+	RowsAffected int64
+	Duration     time.Duration
+}
+
+func driverAndDSN(database Database) (string, string, error) {
+	switch database.Engine {
+	case EnginePostgres:
+		sslMode := database.SSLMode
+		if sslMode == "" {
+			sslMode = "require"
+			if isLocalHost(database.Host) {
+				sslMode = "disable"
+			}
+		}
+
+		dsn := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(database.Username, database.Password),
+			Host:     net.JoinHostPort(database.Host, defaultPort(database.Port, "5432")),
+			Path:     "/" + database.DefaultDatabase,
+			RawQuery: url.Values{"sslmode": {sslMode}}.Encode(),
+		}
+		return "postgres", dsn.String(), nil
+	case EngineMySQL, EngineMariaDB:
+		config := mysql.NewConfig()
+		config.User = database.Username
+		config.Passwd = database.Password
+		config.Net = "tcp"
+		config.Addr = net.JoinHostPort(database.Host, defaultPort(database.Port, "3306"))
+		config.DBName = database.DefaultDatabase
+		config.ParseTime = true
+		// count the rows an UPDATE matches, as PostgreSQL does, rather than
+		// the ones it changed
+		config.ClientFoundRows = true
+		return "mysql", config.FormatDSN(), nil
+	}
+
+	return "", "", fmt.Errorf("unsupported database engine %q", database.Engine)
+}
+
+func isLocalHost(host string) bool {
+	return host == "" || host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+func defaultPort(port string, fallback string) string {
+	if port == "" {
+		return fallback
+	}
+	return port
+}
+
+func Connect(ctx context.Context, database Database) (*Connection, error) {
+	driver, dsn, err := driverAndDSN(database)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	c := &Connection{Database: database, db: db, conn: conn}
+
+	backendQuery := "SELECT CONNECTION_ID()"
+	if database.Engine == EnginePostgres {
+		backendQuery = "SELECT pg_backend_pid()"
+	}
+	if err := conn.QueryRowContext(ctx, backendQuery).Scan(&c.backendID); err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *Connection) Close() error {
+	return errors.Join(c.conn.Close(), c.db.Close())
+}
+
+// claim locks the session connection for a statement, closing any result
+// still being streamed from it. The caller unlocks c.mu.
+func (c *Connection) claim() {
+	c.mu.Lock()
+	if c.active != nil {
+		c.active.rows.Close()
+		c.active.superseded = true
+		c.active = nil
+	}
+}
+
+// Query runs a statement on the session connection. Statements that return
+// rows are read in full; anything else reports the affected row count.
+func (c *Connection) Query(ctx context.Context, query string, returnsRows bool, args ...any) (QueryResult, error) {
+	c.claim()
+	defer c.mu.Unlock()
+
+	start := time.Now()
+
+	if !returnsRows {
+		res, err := c.conn.ExecContext(ctx, query, args...)
+		if err != nil {
+			return QueryResult{Duration: time.Since(start)}, err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			affected = -1
+		}
+		return QueryResult{RowsAffected: affected, Duration: time.Since(start)}, nil
+	}
+
+	rows, err := c.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return QueryResult{Duration: time.Since(start)}, err
+	}
+	defer rows.Close()
+
+	result := QueryResult{}
+	result.Columns, err = columnsOf(rows)
+	if err != nil {
+		return result, err
+	}
+
+	for rows.Next() {
+		values, err := scanRow(rows, len(result.Columns))
+		if err != nil {
+			return result, err
+		}
+		result.Rows = append(result.Rows, values)
+	}
+
+	result.RowsAffected = int64(len(result.Rows))
+	result.Duration = time.Since(start)
+
+	return result, rows.Err()
+}
+
+func columnsOf(rows *sql.Rows) ([]Column, error) {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := []Column{}
+	for _, t := range types {
+		nullable, known := t.Nullable()
+		precision, scale, decimal := t.DecimalSize()
+		columns = append(columns, Column{
+			Name:          t.Name(),
+			DatabaseType:  t.DatabaseTypeName(),
+			Nullable:      nullable,
+			NullableKnown: known,
+			Precision:     precision,
+			Scale:         scale,
+			DecimalKnown:  decimal,
+		})
+	}
+
+	return columns, nil
+}
+
+func scanRow(rows *sql.Rows, width int) ([]any, error) {
+	values := make([]any, width)
+	pointers := make([]any, width)
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	if err := rows.Scan(pointers...); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// Cancel asks the server to stop whatever the session connection is running,
+// using pg_cancel_backend on PostgreSQL and KILL QUERY on MySQL and MariaDB.
+// The running Query then returns with an error.
+func (c *Connection) Cancel(ctx context.Context) error {
+	if c.Database.Engine == EnginePostgres {
+		_, err := c.db.ExecContext(ctx, "SELECT pg_cancel_backend($1)", c.backendID)
+		return err
+	}
+
+	_, err := c.db.ExecContext(ctx, fmt.Sprintf("KILL QUERY %d", c.backendID))
+	return err
+}
@@ -1,5 +1,14 @@
 package databases
 
+import "strings"
+
+// Engine values stored in Database.Engine.
+const (
+	EnginePostgres = "postgres"
+	EngineMySQL    = "mysql"
+	EngineMariaDB  = "maria"
+)
+
 type Database struct {
 	ConnectionName  string `toml:"connectionName"`
 	Engine          string `toml:"engine"`
@@ -9,4 +18,32 @@ type Database struct {
 	Username        string `toml:"username"`
 	Password        string `toml:"password"`
 	DefaultDatabase string `toml:"defaultDatabase"`
+	// SSLMode is passed to PostgreSQL as sslmode. When empty, local hosts
+	// connect without TLS and everything else requires it.
+	SSLMode string `toml:"sslMode,omitempty"`
+	// Tags is a comma separated list of labels for the connection. Kept as a
+	// string so Database stays comparable for the connection picker.
+	Tags string `toml:"tags,omitempty"`
+}
+
+// IsProduction reports whether the connection is tagged production, which
+// makes confirming destructive statements stricter.
+func (d Database) IsProduction() bool {
+	for _, tag := range ParseTags(d.Tags) {
+		if strings.EqualFold(tag, "production") || strings.EqualFold(tag, "prod") {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTags splits a comma separated list of tags, dropping empty ones.
+func ParseTags(list string) []string {
+	tags := []string{}
+	for _, tag := range strings.Split(list, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
 }
@@ -0,0 +1,98 @@
+package databases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Exec is a statement with its arguments.
+type Exec struct {
+	Query string
+	Args  []any
+}
+
+// PrimaryKey returns the primary key columns of a table, in key order, or none
+// when it has no primary key. It runs on the session connection, to find the
+// table the session's search path or current database, temporary tables and
+// uncommitted DDL make the name mean, so like any statement it closes a
+// result still being read.
+func (c *Connection) PrimaryKey(ctx context.Context, table []string) ([]string, error) {
+	if err := checkTableName(table); err != nil {
+		return nil, err
+	}
+	c.claim()
+	defer c.mu.Unlock()
+
+	var query string
+	var args []any
+	switch c.Database.Engine {
+	case EnginePostgres:
+		query = `SELECT a.attname
+			FROM pg_index i
+			JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+			WHERE i.indrelid = to_regclass($1) AND i.indisprimary
+			ORDER BY array_position(i.indkey::int2[], a.attnum)`
+		args = []any{QuoteName(table, c.Database.Engine)}
+	default:
+		query = `SELECT COLUMN_NAME
+			FROM information_schema.KEY_COLUMN_USAGE
+			WHERE CONSTRAINT_NAME = 'PRIMARY' AND TABLE_SCHEMA = COALESCE(?, DATABASE()) AND TABLE_NAME = ?
+			ORDER BY ORDINAL_POSITION`
+		schema, name := splitTable(table)
+		args = []any{schema, name}
+	}
+
+	rows, err := c.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := []string{}
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+	return columns, rows.Err()
+}
+
+// Apply runs edits all or nothing, each of which must change exactly one row,
+// so a row that went away since it was read stops the lot. With a
+// transaction already open they run under a savepoint and are left for the
+// user to commit; otherwise they're committed in a transaction of their own.
+func (c *Connection) Apply(ctx context.Context, execs []Exec, inTransaction bool) error {
+	c.claim()
+	defer c.mu.Unlock()
+
+	begin, commit, rollback := "START TRANSACTION", "COMMIT", "ROLLBACK"
+	if c.Database.Engine == EnginePostgres {
+		begin = "BEGIN"
+	}
+	if inTransaction {
+		begin, commit, rollback = "SAVEPOINT squeal_apply", "RELEASE SAVEPOINT squeal_apply", "ROLLBACK TO SAVEPOINT squeal_apply"
+	}
+
+	if _, err := c.conn.ExecContext(ctx, begin); err != nil {
+		return err
+	}
+	for i, exec := range execs {
+		res, err := c.conn.ExecContext(ctx, exec.Query, exec.Args...)
+		if err == nil {
+			var affected int64
+			if affected, err = res.RowsAffected(); err == nil && affected != 1 {
+				err = fmt.Errorf("change %d affected %d rows instead of 1, the row may have changed since it was read", i+1, affected)
+			}
+		}
+		if err != nil {
+			_, undo := c.conn.ExecContext(ctx, rollback)
+			return errors.Join(err, undo)
+		}
+	}
+
+	_, err := c.conn.ExecContext(ctx, commit)
+	return err
+}
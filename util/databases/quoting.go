@@ -0,0 +1,64 @@
+package databases
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// QuoteName quotes each part of a possibly schema qualified name.
+func QuoteName(parts []string, engine string) string {
+	quoted := make([]string, len(parts))
+	for i, part := range parts {
+		quoted[i] = QuoteIdentifier(part, engine)
+	}
+	return strings.Join(quoted, ".")
+}
+
+func QuoteIdentifier(name string, engine string) string {
+	if engine == EnginePostgres {
+		return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+	}
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// QuoteLiteral writes a scanned value as an SQL literal for the engine.
+func QuoteLiteral(value any, engine string) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		return strings.ToUpper(strconv.FormatBool(v))
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case time.Time:
+		if engine != EnginePostgres {
+			// MariaDB and MySQL before 8.0.19 reject an offset, and keep
+			// microseconds at most
+			return "'" + v.Format("2006-01-02 15:04:05.999999") + "'"
+		}
+		return "'" + v.Format("2006-01-02 15:04:05.999999999-07:00") + "'"
+	case []byte:
+		if !utf8.Valid(v) {
+			if engine == EnginePostgres {
+				return `'\x` + hex.EncodeToString(v) + "'"
+			}
+			return "X'" + hex.EncodeToString(v) + "'"
+		}
+		return quoteString(string(v), engine)
+	}
+	return quoteString(fmt.Sprint(value), engine)
+}
+
+func quoteString(s string, engine string) string {
+	if engine != EnginePostgres {
+		// MySQL treats backslashes as escapes unless NO_BACKSLASH_ESCAPES is set
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,25 @@
+package databases
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuoteLiteralMySQL(t *testing.T) {
+	cases := []struct {
+		value any
+		want  string
+	}{
+		{"plain", "'plain'"},
+		{`it's a \ path`, `'it''s a \\ path'`},
+		{[]byte{0xde, 0xad, 0xff}, "X'deadff'"},
+		{false, "FALSE"},
+		{time.Date(2024, 3, 1, 9, 30, 0, 123456789, time.UTC), "'2024-03-01 09:30:00.123456'"},
+	}
+
+	for _, tc := range cases {
+		if got := QuoteLiteral(tc.value, EngineMySQL); got != tc.want {
+			t.Errorf("QuoteLiteral(%v) = %s, want %s", tc.value, got, tc.want)
+		}
+	}
+}
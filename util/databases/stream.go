@@ -0,0 +1,95 @@
+package databases
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// ErrSuperseded is returned when fetching from a stream that was closed
+// because another statement ran on the connection.
+var ErrSuperseded = errors.New("the result was closed by a later statement")
+
+// RowStream is a result set read from the server a page at a time, so large
+// results don't have to fit in memory. Only the most recent stream on a
+// connection stays open.
+type RowStream struct {
+	Columns []Column
+
+	conn       *Connection
+	rows       *sql.Rows
+	done       bool
+	superseded bool
+}
+
+// Stream starts a statement that returns rows without reading them.
+func (c *Connection) Stream(ctx context.Context, query string, args ...any) (*RowStream, error) {
+	c.claim()
+	defer c.mu.Unlock()
+
+	rows, err := c.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := columnsOf(rows)
+	if err != nil {
+		rows.Close()
+		return nil, err
+	}
+
+	stream := &RowStream{Columns: columns, conn: c, rows: rows}
+	c.active = stream
+
+	return stream, nil
+}
+
+// Fetch reads up to n more rows. Once the result is exhausted Done reports
+// true and the stream closes itself.
+func (s *RowStream) Fetch(n int) ([][]any, error) {
+	s.conn.mu.Lock()
+	defer s.conn.mu.Unlock()
+
+	if s.superseded {
+		return nil, ErrSuperseded
+	}
+	if s.done {
+		return nil, nil
+	}
+
+	page := [][]any{}
+	for len(page) < n {
+		if !s.rows.Next() {
+			s.done = true
+			s.conn.active = nil
+			return page, errors.Join(s.rows.Err(), s.rows.Close())
+		}
+		values, err := scanRow(s.rows, len(s.Columns))
+		if err != nil {
+			return page, err
+		}
+		page = append(page, values)
+	}
+	return page, nil
+}
+
+// Done reports whether every row has been read.
+func (s *RowStream) Done() bool {
+	s.conn.mu.Lock()
+	defer s.conn.mu.Unlock()
+
+	return s.done
+}
+
+// Close stops reading the result. Any rows left are discarded by the driver.
+func (s *RowStream) Close() error {
+	s.conn.mu.Lock()
+	defer s.conn.mu.Unlock()
+
+	if s.done || s.superseded {
+		return nil
+	}
+	s.done = true
+	s.conn.active = nil
+	return s.rows.Close()
+}
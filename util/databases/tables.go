@@ -0,0 +1,247 @@
+package databases
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TableColumn describes a column of a table as its definition has it, rather
+// than as a result reports it.
+type TableColumn struct {
+	Name     string
+	Type     string
+	Nullable bool
+	// Default is the expression the column defaults to, for showing; auto
+	// numbered columns count as having one
+	Default    string
+	HasDefault bool
+	// Generated columns are computed by the server and can't be written
+	Generated  bool
+	References *ForeignKey
+}
+
+// ForeignKey is the column a single column foreign key points at.
+type ForeignKey struct {
+	Table  []string
+	Column string
+}
+
+// Columns returns a table's columns in order, with the foreign key each
+// references on its own. Like PrimaryKey it runs on the session connection.
+func (c *Connection) Columns(ctx context.Context, table []string) ([]TableColumn, error) {
+	if err := checkTableName(table); err != nil {
+		return nil, err
+	}
+	c.claim()
+	defer c.mu.Unlock()
+
+	var columns []TableColumn
+	var err error
+	if c.Database.Engine == EnginePostgres {
+		columns, err = c.postgresColumns(ctx, table)
+	} else {
+		columns, err = c.mysqlColumns(ctx, table)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("there's no table %s", strings.Join(table, "."))
+	}
+
+	keys, err := c.foreignKeys(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	for i := range columns {
+		if key, ok := keys[columns[i].Name]; ok {
+			columns[i].References = &key
+		}
+	}
+	return columns, nil
+}
+
+func (c *Connection) postgresColumns(ctx context.Context, table []string) ([]TableColumn, error) {
+	rows, err := c.conn.QueryContext(ctx, `SELECT a.attname, format_type(a.atttypid, a.atttypmod), NOT a.attnotnull,
+			COALESCE(pg_get_expr(d.adbin, d.adrelid), ''), d.adbin IS NOT NULL OR a.attidentity <> '', a.attgenerated <> ''
+		FROM pg_attribute a
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE a.attrelid = to_regclass($1) AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, QuoteName(table, c.Database.Engine))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := []TableColumn{}
+	for rows.Next() {
+		var column TableColumn
+		if err := rows.Scan(&column.Name, &column.Type, &column.Nullable, &column.Default, &column.HasDefault, &column.Generated); err != nil {
+			return nil, err
+		}
+		if column.HasDefault && column.Default == "" {
+			column.Default = "generated identity"
+		}
+		columns = append(columns, column)
+	}
+	return columns, rows.Err()
+}
+
+func (c *Connection) mysqlColumns(ctx context.Context, table []string) ([]TableColumn, error) {
+	schema, name := splitTable(table)
+	rows, err := c.conn.QueryContext(ctx, `SELECT COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE = 'YES', COLUMN_DEFAULT, EXTRA
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = COALESCE(?, DATABASE()) AND TABLE_NAME = ?
+		ORDER BY ORDINAL_POSITION`, schema, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := []TableColumn{}
+	for rows.Next() {
+		var column TableColumn
+		var defaultValue sql.NullString
+		var extra string
+		if err := rows.Scan(&column.Name, &column.Type, &column.Nullable, &defaultValue, &extra); err != nil {
+			return nil, err
+		}
+
+		extra = strings.ToUpper(extra)
+		// MariaDB reports a NULL default as the text NULL
+		column.HasDefault = defaultValue.Valid && !(c.Database.Engine == EngineMariaDB && defaultValue.String == "NULL")
+		column.Default = defaultValue.String
+		if strings.Contains(extra, "AUTO_INCREMENT") {
+			column.HasDefault, column.Default = true, "auto_increment"
+		}
+		// DEFAULT_GENERATED marks an expression default, not a generated column
+		column.Generated = strings.Contains(strings.ReplaceAll(extra, "DEFAULT_GENERATED", ""), "GENERATED")
+		columns = append(columns, column)
+	}
+	return columns, rows.Err()
+}
+
+// foreignKeys maps the columns of a table that are foreign keys on their own
+// to what they reference. Keys over several columns are left out.
+func (c *Connection) foreignKeys(ctx context.Context, table []string) (map[string]ForeignKey, error) {
+	var rows *sql.Rows
+	var err error
+	if c.Database.Engine == EnginePostgres {
+		rows, err = c.conn.QueryContext(ctx, `SELECT a.attname, n.nspname, r.relname, fa.attname
+			FROM pg_constraint k
+			JOIN pg_attribute a ON a.attrelid = k.conrelid AND a.attnum = k.conkey[1]
+			JOIN pg_attribute fa ON fa.attrelid = k.confrelid AND fa.attnum = k.confkey[1]
+			JOIN pg_class r ON r.oid = k.confrelid
+			JOIN pg_namespace n ON n.oid = r.relnamespace
+			WHERE k.conrelid = to_regclass($1) AND k.contype = 'f' AND cardinality(k.conkey) = 1`,
+			QuoteName(table, c.Database.Engine))
+	} else {
+		schema, name := splitTable(table)
+		rows, err = c.conn.QueryContext(ctx, `SELECT k.COLUMN_NAME, k.REFERENCED_TABLE_SCHEMA, k.REFERENCED_TABLE_NAME, k.REFERENCED_COLUMN_NAME
+			FROM information_schema.KEY_COLUMN_USAGE k
+			WHERE k.TABLE_SCHEMA = COALESCE(?, DATABASE()) AND k.TABLE_NAME = ? AND k.REFERENCED_TABLE_NAME IS NOT NULL
+				AND (SELECT COUNT(*) FROM information_schema.KEY_COLUMN_USAGE o
+					WHERE o.CONSTRAINT_SCHEMA = k.CONSTRAINT_SCHEMA AND o.TABLE_NAME = k.TABLE_NAME AND o.CONSTRAINT_NAME = k.CONSTRAINT_NAME) = 1`,
+			schema, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := map[string]ForeignKey{}
+	for rows.Next() {
+		var column, schema, name string
+		var key ForeignKey
+		if err := rows.Scan(&column, &schema, &name, &key.Column); err != nil {
+			return nil, err
+		}
+		key.Table = []string{schema, name}
+		keys[column] = key
+	}
+	return keys, rows.Err()
+}
+
+// ForeignValues returns the distinct values of the column a foreign key
+// references, as text, up to limit of them. All reports whether that's all
+// there are. The table is schema qualified already, so this reads data on
+// the pool, where being refused access can't abort the session's open
+// transaction.
+func (c *Connection) ForeignValues(ctx context.Context, key ForeignKey, limit int) (values []string, all bool, err error) {
+	column := QuoteIdentifier(key.Column, c.Database.Engine)
+	query := fmt.Sprintf("SELECT DISTINCT %s FROM %s WHERE %s IS NOT NULL ORDER BY 1 LIMIT %d",
+		column, QuoteName(key.Table, c.Database.Engine), column, limit+1)
+	rows, err := c.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	values = []string{}
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, false, err
+		}
+		values = append(values, value)
+	}
+	if len(values) > limit {
+		return values[:limit], false, rows.Err()
+	}
+	return values, true, rows.Err()
+}
+
+// EstimatedRows is the server's estimate of how many rows a table has, from
+// its statistics rather than counting, or -1 when there isn't one. Like
+// PrimaryKey it runs on the session connection.
+func (c *Connection) EstimatedRows(ctx context.Context, table []string) (int64, error) {
+	if err := checkTableName(table); err != nil {
+		return -1, err
+	}
+	c.claim()
+	defer c.mu.Unlock()
+
+	var rows sql.NullInt64
+	var err error
+	if c.Database.Engine == EnginePostgres {
+		// reltuples is -1 for a table that's never been analyzed
+		err = c.conn.QueryRowContext(ctx, `SELECT reltuples::bigint FROM pg_class WHERE oid = to_regclass($1)`,
+			QuoteName(table, c.Database.Engine)).Scan(&rows)
+	} else {
+		schema, name := splitTable(table)
+		err = c.conn.QueryRowContext(ctx, `SELECT TABLE_ROWS FROM information_schema.TABLES
+			WHERE TABLE_SCHEMA = COALESCE(?, DATABASE()) AND TABLE_NAME = ?`, schema, name).Scan(&rows)
+	}
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return -1, fmt.Errorf("there's no table %s", strings.Join(table, "."))
+	case err != nil:
+		return -1, err
+	case !rows.Valid || rows.Int64 < 0:
+		return -1, nil
+	}
+	return rows.Int64, nil
+}
+
+// checkTableName rejects names with a database part before they reach the
+// session connection, where PostgreSQL's error for one would abort an open
+// transaction.
+func checkTableName(table []string) error {
+	if len(table) == 0 || len(table) > 2 {
+		return fmt.Errorf("%s isn't a table or schema qualified table name", strings.Join(table, "."))
+	}
+	return nil
+}
+
+// splitTable separates a table name into its schema, nil for the current
+// one, and name.
+func splitTable(table []string) (any, string) {
+	var schema any
+	if len(table) > 1 {
+		schema = table[len(table)-2]
+	}
+	return schema, table[len(table)-1]
+}
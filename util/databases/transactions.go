@@ -0,0 +1,53 @@
+package databases
+
+import (
+	"context"
+)
+
+// Begin opens a transaction on the session connection. Transactions are
+// driven with plain statements rather than database/sql's Tx so that
+// statements the user types, such as COMMIT, act on the same transaction.
+func (c *Connection) Begin(ctx context.Context) error {
+	begin := "START TRANSACTION"
+	if c.Database.Engine == EnginePostgres {
+		begin = "BEGIN"
+	}
+
+	c.claim()
+	defer c.mu.Unlock()
+
+	_, err := c.conn.ExecContext(ctx, begin)
+	return err
+}
+
+func (c *Connection) Commit(ctx context.Context) error {
+	c.claim()
+	defer c.mu.Unlock()
+
+	_, err := c.conn.ExecContext(ctx, "COMMIT")
+	return err
+}
+
+func (c *Connection) Rollback(ctx context.Context) error {
+	c.claim()
+	defer c.mu.Unlock()
+
+	_, err := c.conn.ExecContext(ctx, "ROLLBACK")
+	return err
+}
+
+func (c *Connection) Savepoint(ctx context.Context, name string) error {
+	c.claim()
+	defer c.mu.Unlock()
+
+	_, err := c.conn.ExecContext(ctx, "SAVEPOINT "+name)
+	return err
+}
+
+func (c *Connection) RollbackToSavepoint(ctx context.Context, name string) error {
+	c.claim()
+	defer c.mu.Unlock()
+
+	_, err := c.conn.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+	return err
+}
@@ -0,0 +1,80 @@
+package editor
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// FinishedMsg is sent once the external editor exits. Text holds the edited
+// buffer; on error it holds the original text.
+type FinishedMsg struct {
+	Text string
+	Err  error
+}
+
+// Command resolves the editor to run: the configured override, then $VISUAL,
+// then $EDITOR, falling back to vi. The value may include arguments, such as
+// "code --wait".
+func Command(configured string) []string {
+	for _, candidate := range []string{configured, os.Getenv("VISUAL"), os.Getenv("EDITOR")} {
+		if fields := strings.Fields(candidate); len(fields) > 0 {
+			return fields
+		}
+	}
+
+	return []string{"vi"}
+}
+
+// Open writes text to a temporary .sql file, suspends the program while the
+// editor runs and reports the edited text with a FinishedMsg.
+func Open(text string, configured string) tea.Cmd {
+	f, err := os.CreateTemp("", "squeal-*.sql")
+	if err != nil {
+		return finished(text, err)
+	}
+	path := f.Name()
+
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		os.Remove(path)
+		return finished(text, err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return finished(text, err)
+	}
+
+	args := append(Command(configured), path)
+	cmd := exec.Command(args[0], args[1:]...)
+
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		defer os.Remove(path)
+
+		if err != nil {
+			return FinishedMsg{Text: text, Err: errors.New("editor exited with an error: " + err.Error())}
+		}
+
+		edited, err := os.ReadFile(path)
+		if err != nil {
+			return FinishedMsg{Text: text, Err: err}
+		}
+
+		// most editors add a final newline the buffer never had
+		result := string(edited)
+		if !strings.HasSuffix(text, "\n") {
+			result = strings.TrimSuffix(strings.TrimSuffix(result, "\n"), "\r")
+		}
+
+		return FinishedMsg{Text: result}
+	})
+}
+
+func finished(text string, err error) tea.Cmd {
+	return func() tea.Msg {
+		return FinishedMsg{Text: text, Err: err}
+	}
+}
@@ -0,0 +1,181 @@
+package explain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/therealphatmike/squeal/util/databases"
+)
+
+// Node is one step of a query plan. Costs are in the planner's own units;
+// times are in milliseconds. Actual values are only set when the plan came
+// from EXPLAIN ANALYZE.
+type Node struct {
+	Operation     string
+	Relation      string
+	Detail        string
+	TotalCost     float64
+	StartupCost   float64
+	EstimatedRows float64
+	ActualRows    *float64
+	ActualTime    *float64
+	Loops         float64
+	Children      []*Node
+
+	// SelfTime is the time spent in this node excluding its children, or
+	// the exclusive cost when there is no timing.
+	SelfTime float64
+	Hotspot  bool
+	// Misestimate is how far the row estimate was off, as a factor, when it
+	// is off by more than ten times.
+	Misestimate float64
+}
+
+type Plan struct {
+	Root          *Node
+	Analyzed      bool
+	PlanningTime  *float64
+	ExecutionTime *float64
+}
+
+// hotspotShare is the fraction of the plan's time (or cost) a node has to
+// account for by itself to be highlighted.
+const hotspotShare = 0.25
+
+// Wrap turns a statement into the engine's JSON EXPLAIN. MySQL can't produce
+// JSON from EXPLAIN ANALYZE, so it only ever gets estimates.
+func Wrap(query string, engine string, analyze bool) (string, error) {
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+
+	switch engine {
+	case databases.EnginePostgres:
+		if analyze {
+			return "EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) " + query, nil
+		}
+		return "EXPLAIN (FORMAT JSON) " + query, nil
+	case databases.EngineMariaDB:
+		if analyze {
+			return "ANALYZE FORMAT=JSON " + query, nil
+		}
+		return "EXPLAIN FORMAT=JSON " + query, nil
+	case databases.EngineMySQL:
+		return "EXPLAIN FORMAT=JSON " + query, nil
+	}
+
+	return "", fmt.Errorf("explain isn't supported for %q", engine)
+}
+
+// Parse reads the JSON document returned by the EXPLAIN built by Wrap.
+func Parse(document string, engine string) (Plan, error) {
+	var plan Plan
+	var err error
+
+	switch engine {
+	case databases.EnginePostgres:
+		plan, err = parsePostgres(document)
+	case databases.EngineMySQL, databases.EngineMariaDB:
+		plan, err = parseMySQL(document)
+	default:
+		return Plan{}, fmt.Errorf("explain isn't supported for %q", engine)
+	}
+	if err != nil {
+		return Plan{}, err
+	}
+
+	markHotspots(&plan)
+
+	return plan, nil
+}
+
+func markHotspots(plan *Plan) {
+	if plan.Root == nil {
+		return
+	}
+
+	total := 0.0
+	var measure func(n *Node) float64
+	measure = func(n *Node) float64 {
+		children := 0.0
+		for _, child := range n.Children {
+			children += measure(child)
+		}
+
+		inclusive := n.TotalCost
+		if plan.Analyzed {
+			// steps without their own timing are as slow as their children
+			inclusive = children
+			if n.ActualTime != nil {
+				inclusive = *n.ActualTime * max(n.Loops, 1)
+			}
+		}
+		n.SelfTime = max(inclusive-children, 0)
+		total += n.SelfTime
+
+		if n.ActualRows != nil {
+			estimated := max(n.EstimatedRows, 1)
+			actual := max(*n.ActualRows, 1)
+			if factor := max(estimated/actual, actual/estimated); factor >= 10 {
+				n.Misestimate = factor
+			}
+		}
+
+		return inclusive
+	}
+	measure(plan.Root)
+
+	if total <= 0 {
+		return
+	}
+	Walk(plan.Root, func(n *Node, _ int) {
+		n.Hotspot = n.SelfTime/total >= hotspotShare
+	})
+}
+
+// Walk visits every node depth first, passing its depth in the tree.
+func Walk(root *Node, visit func(n *Node, depth int)) {
+	var walk func(n *Node, depth int)
+	walk = func(n *Node, depth int) {
+		visit(n, depth)
+		for _, child := range n.Children {
+			walk(child, depth+1)
+		}
+	}
+	if root != nil {
+		walk(root, 0)
+	}
+}
+
+// number reads plan values that may be JSON numbers or, as MySQL writes
+// them, numeric strings.
+func number(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func numberPtr(value any) *float64 {
+	if f, ok := number(value); ok {
+		return &f
+	}
+	return nil
+}
+
+func text(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,172 @@
+package explain
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// mysqlOperations names the plan steps of MySQL and MariaDB JSON plans, in
+// the order their children are listed.
+var mysqlOperations = []struct {
+	key       string
+	operation string
+}{
+	{"query_block", "Query Block"},
+	{"union_result", "Union"},
+	{"query_specifications", "Union Parts"},
+	{"ordering_operation", "Sort"},
+	{"grouping_operation", "Group"},
+	{"duplicates_removal", "Distinct"},
+	{"windowing", "Window"},
+	{"having_subqueries", "Having Subqueries"},
+	{"filesort", "Filesort"},
+	{"read_sorted_file", "Read Sorted File"},
+	{"temporary_table", "Temporary Table"},
+	{"nested_loop", "Nested Loop"},
+	{"block-nl-join", "Block Nested Loop"},
+	{"table", "Table"},
+	{"materialized_from_subquery", "Materialize"},
+	{"attached_subqueries", "Subqueries"},
+	{"select_list_subqueries", "Select List Subqueries"},
+	{"subqueries", "Subqueries"},
+	{"optimized_away_subqueries", "Optimized Away Subqueries"},
+	{"update_value_subqueries", "Update Subqueries"},
+}
+
+var accessTypes = map[string]string{
+	"ALL":             "Full Table Scan",
+	"index":           "Full Index Scan",
+	"range":           "Index Range Scan",
+	"ref":             "Index Lookup",
+	"eq_ref":          "Unique Index Lookup",
+	"ref_or_null":     "Index Lookup (or NULL)",
+	"index_merge":     "Index Merge",
+	"const":           "Constant Lookup",
+	"system":          "Constant Lookup",
+	"fulltext":        "Fulltext Lookup",
+	"unique_subquery": "Unique Subquery Lookup",
+	"index_subquery":  "Index Subquery Lookup",
+}
+
+func parseMySQL(document string) (Plan, error) {
+	decoder := json.NewDecoder(strings.NewReader(document))
+	decoder.UseNumber()
+
+	var explained map[string]any
+	if err := decoder.Decode(&explained); err != nil {
+		return Plan{}, err
+	}
+
+	block, ok := explained["query_block"].(map[string]any)
+	if !ok {
+		return Plan{}, errors.New("the plan has no query_block")
+	}
+
+	root := mysqlNode("query_block", "Query Block", block)
+	analyzed := false
+	Walk(root, func(n *Node, _ int) {
+		analyzed = analyzed || n.ActualTime != nil || n.ActualRows != nil
+	})
+
+	plan := Plan{Root: root, Analyzed: analyzed}
+	if analyzed {
+		plan.ExecutionTime = root.ActualTime
+	}
+
+	return plan, nil
+}
+
+func mysqlNode(key string, operation string, raw map[string]any) *Node {
+	n := &Node{Operation: operation}
+
+	if key == "query_block" {
+		if id := text(raw["select_id"]); id != "" {
+			n.Operation += " #" + id
+		}
+	}
+
+	if key == "table" {
+		if access, ok := accessTypes[text(raw["access_type"])]; ok {
+			n.Operation = access
+		}
+		n.Relation = text(raw["table_name"])
+		if index := text(raw["key"]); index != "" {
+			n.Relation += " using " + index
+		}
+	}
+
+	details := []string{}
+	for _, detailKey := range []string{"attached_condition", "index_condition", "sort_key", "message"} {
+		if value := text(raw[detailKey]); value != "" {
+			details = append(details, strings.ReplaceAll(detailKey, "_", " ")+": "+value)
+		}
+	}
+	if raw["using_filesort"] == true {
+		details = append(details, "using filesort")
+	}
+	if raw["using_temporary_table"] == true {
+		details = append(details, "using temporary table")
+	}
+	n.Detail = strings.Join(details, "; ")
+
+	if rows, ok := number(raw["rows_produced_per_join"]); ok {
+		n.EstimatedRows = rows
+	} else if rows, ok := number(raw["rows"]); ok {
+		n.EstimatedRows = rows
+	}
+
+	if costs, ok := raw["cost_info"].(map[string]any); ok {
+		if cost, ok := number(costs["query_cost"]); ok {
+			n.TotalCost = cost
+		} else if cost, ok := number(costs["sort_cost"]); ok {
+			n.TotalCost = cost
+		} else {
+			read, _ := number(costs["read_cost"])
+			eval, _ := number(costs["eval_cost"])
+			n.TotalCost = read + eval
+		}
+	}
+
+	// MariaDB's ANALYZE reports totals across loops; keep per-loop figures
+	// like postgres does
+	n.Loops, _ = number(raw["r_loops"])
+	n.ActualRows = numberPtr(raw["r_rows"])
+	if total, ok := number(raw["r_total_time_ms"]); ok {
+		perLoop := total / max(n.Loops, 1)
+		n.ActualTime = &perLoop
+	}
+
+	n.Children = mysqlChildren(raw)
+
+	if n.TotalCost == 0 {
+		for _, child := range n.Children {
+			n.TotalCost += child.TotalCost
+		}
+	}
+
+	return n
+}
+
+func mysqlChildren(raw map[string]any) []*Node {
+	children := []*Node{}
+	for _, op := range mysqlOperations {
+		switch value := raw[op.key].(type) {
+		case map[string]any:
+			children = append(children, mysqlNode(op.key, op.operation, value))
+		case []any:
+			group := &Node{Operation: op.operation}
+			for _, item := range value {
+				if itemMap, ok := item.(map[string]any); ok {
+					group.Children = append(group.Children, mysqlChildren(itemMap)...)
+				}
+			}
+			for _, child := range group.Children {
+				group.TotalCost += child.TotalCost
+			}
+			children = append(children, group)
+		}
+	}
+
+	return children
+}
@@ -0,0 +1,86 @@
+package explain
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+func parsePostgres(document string) (Plan, error) {
+	var explained []map[string]any
+	if err := json.Unmarshal([]byte(document), &explained); err != nil {
+		return Plan{}, err
+	}
+	if len(explained) == 0 {
+		return Plan{}, errors.New("the server returned an empty plan")
+	}
+
+	top := explained[0]
+	rawRoot, ok := top["Plan"].(map[string]any)
+	if !ok {
+		return Plan{}, errors.New("the plan has no root node")
+	}
+
+	root := postgresNode(rawRoot)
+	return Plan{
+		Root:          root,
+		Analyzed:      root.ActualTime != nil,
+		PlanningTime:  numberPtr(top["Planning Time"]),
+		ExecutionTime: numberPtr(top["Execution Time"]),
+	}, nil
+}
+
+func postgresNode(raw map[string]any) *Node {
+	n := &Node{
+		Operation:  text(raw["Node Type"]),
+		ActualRows: numberPtr(raw["Actual Rows"]),
+		ActualTime: numberPtr(raw["Actual Total Time"]),
+	}
+	n.TotalCost, _ = number(raw["Total Cost"])
+	n.StartupCost, _ = number(raw["Startup Cost"])
+	n.EstimatedRows, _ = number(raw["Plan Rows"])
+	n.Loops, _ = number(raw["Actual Loops"])
+
+	if join := text(raw["Join Type"]); join != "" && join != "Inner" {
+		n.Operation = join + " " + n.Operation
+	}
+
+	relation := text(raw["Relation Name"])
+	if alias := text(raw["Alias"]); alias != "" && alias != relation {
+		relation += " " + alias
+	}
+	if index := text(raw["Index Name"]); index != "" {
+		relation = strings.TrimSpace(relation + " using " + index)
+	}
+	n.Relation = relation
+
+	details := []string{}
+	for _, key := range []string{"Index Cond", "Hash Cond", "Merge Cond", "Join Filter", "Filter", "Recheck Cond", "Sort Key", "Group Key"} {
+		value, ok := raw[key]
+		if !ok {
+			continue
+		}
+		if list, ok := value.([]any); ok {
+			parts := []string{}
+			for _, item := range list {
+				parts = append(parts, text(item))
+			}
+			value = strings.Join(parts, ", ")
+		}
+		details = append(details, key+": "+text(value))
+	}
+	if removed, ok := number(raw["Rows Removed by Filter"]); ok && removed > 0 {
+		details = append(details, "Rows Removed by Filter: "+text(raw["Rows Removed by Filter"]))
+	}
+	n.Detail = strings.Join(details, "; ")
+
+	if children, ok := raw["Plans"].([]any); ok {
+		for _, child := range children {
+			if childMap, ok := child.(map[string]any); ok {
+				n.Children = append(n.Children, postgresNode(childMap))
+			}
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,153 @@
+package export
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/therealphatmike/squeal/util/databases"
+	"github.com/therealphatmike/squeal/util/params"
+)
+
+// columnKind is how the typed formats store a column, worked out from the
+// type name the driver reports.
+type columnKind int
+
+const (
+	kindText columnKind = iota
+	kindInteger
+	kindFloat
+	kindDecimal
+	kindBoolean
+	kindTimestamp
+	kindDate
+	kindBinary
+	kindJSON
+)
+
+func kindOf(column databases.Column) columnKind {
+	t := strings.ToUpper(strings.TrimSpace(column.DatabaseType))
+	if t == "UNSIGNED BIGINT" {
+		// doesn't fit in an int64
+		return kindDecimal
+	}
+	t = strings.TrimPrefix(t, "UNSIGNED ")
+
+	switch t {
+	case "INT2", "INT4", "INT8", "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT", "YEAR":
+		return kindInteger
+	case "FLOAT4", "FLOAT8", "FLOAT", "DOUBLE", "REAL":
+		return kindFloat
+	case "NUMERIC", "DECIMAL", "MONEY":
+		return kindDecimal
+	case "BOOL", "BOOLEAN":
+		return kindBoolean
+	case "TIMESTAMP", "TIMESTAMPTZ", "DATETIME":
+		return kindTimestamp
+	case "DATE":
+		return kindDate
+	case "BYTEA", "BINARY", "VARBINARY", "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB":
+		return kindBinary
+	case "JSON", "JSONB":
+		return kindJSON
+	}
+	return kindText
+}
+
+// convert turns a scanned value into the Go type the column's kind is stored
+// as: int64, float64, bool, time.Time, []byte or string. Drivers hand back
+// text for many types, MySQL for nearly all of them, so text is parsed.
+func convert(kind columnKind, value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	switch kind {
+	case kindInteger:
+		switch v := value.(type) {
+		case int64:
+			return v, nil
+		case bool:
+			if v {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		}
+		return parsed(params.TypeInteger, value)
+	case kindFloat:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case float32:
+			return float64(v), nil
+		case int64:
+			return float64(v), nil
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(textOf(value)), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", textOf(value))
+		}
+		return f, nil
+	case kindBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case int64:
+			return v != 0, nil
+		}
+		return parsed(params.TypeBoolean, value)
+	case kindTimestamp, kindDate:
+		if v, ok := value.(time.Time); ok {
+			return v, nil
+		}
+		return parsed(params.TypeTimestamp, value)
+	case kindBinary:
+		if v, ok := value.([]byte); ok {
+			return v, nil
+		}
+		return []byte(textOf(value)), nil
+	}
+	return textOf(value), nil
+}
+
+func parsed(typ string, value any) (any, error) {
+	return params.Convert(params.Value{Raw: textOf(value), Type: typ})
+}
+
+// textOf is the value as text, with binary that isn't UTF-8 written as hex.
+func textOf(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		if !utf8.Valid(v) {
+			return `\x` + hex.EncodeToString(v)
+		}
+		return string(v)
+	case time.Time:
+		return v.Format(timeLayout)
+	}
+	return fmt.Sprint(value)
+}
+
+// columnNames are the names to write, made unique and never empty since the
+// typed formats look columns up by name.
+func columnNames(columns []databases.Column) []string {
+	names := make([]string, len(columns))
+	seen := map[string]int{}
+	for i, column := range columns {
+		name := column.Name
+		if name == "" || name == "?column?" {
+			name = fmt.Sprintf("column_%d", i+1)
+		}
+		seen[name]++
+		if seen[name] > 1 {
+			name = fmt.Sprintf("%s_%d", name, seen[name])
+		}
+		names[i] = name
+	}
+	return names
+}
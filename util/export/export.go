@@ -0,0 +1,350 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/therealphatmike/squeal/util/databases"
+)
+
+// Formats results can be written in.
+const (
+	FormatCSV      = "csv"
+	FormatTSV      = "tsv"
+	FormatJSON     = "json"
+	FormatNDJSON   = "ndjson"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatSQL      = "sql"
+	FormatParquet  = "parquet"
+	FormatXLSX     = "xlsx"
+)
+
+var Formats = []string{FormatCSV, FormatTSV, FormatJSON, FormatNDJSON, FormatMarkdown, FormatHTML, FormatSQL, FormatParquet, FormatXLSX}
+
+// Binary reports whether the format is a file format rather than text, so
+// it can't go to a terminal and holds only one result.
+func Binary(format string) bool {
+	return format == FormatParquet || format == FormatXLSX
+}
+
+// SingleResult reports whether a file in the format holds just one result,
+// so another can't be written after it. Two JSON arrays one after the other
+// aren't JSON.
+func SingleResult(format string) bool {
+	return Binary(format) || format == FormatJSON
+}
+
+// Quoting values for Options.Quoting, which only CSV and TSV use.
+const (
+	QuoteMinimal = "minimal"
+	QuoteAll     = "all"
+	QuoteNone    = "none"
+)
+
+var Quotings = []string{QuoteMinimal, QuoteAll, QuoteNone}
+
+const timeLayout = "2006-01-02 15:04:05.999999999-07:00"
+
+type Options struct {
+	Format string
+	// Header writes the column names first in CSV and TSV. Markdown and
+	// HTML tables always have one.
+	Header bool
+	// Delimiter separates CSV fields, a comma when empty. TSV always uses
+	// tabs.
+	Delimiter string
+	Quoting   string
+	// Null is written for NULLs in CSV, TSV, Markdown and HTML. JSON and SQL
+	// have their own null.
+	Null string
+	// Table is the target of SQL INSERT statements and Engine picks how its
+	// identifiers and literals are quoted.
+	Table  string
+	Engine string
+}
+
+// Defaults are the options used for anything not asked for.
+func Defaults(format string) Options {
+	return Options{Format: format, Header: true, Delimiter: ",", Quoting: QuoteMinimal, Table: "exported", Engine: databases.EnginePostgres}
+}
+
+// ExtensionFor is the usual file extension for the format.
+func ExtensionFor(format string) string {
+	switch format {
+	case FormatMarkdown:
+		return "md"
+	case FormatNDJSON:
+		return "ndjson"
+	}
+	return format
+}
+
+// Writer writes rows one at a time, so results don't have to be held in
+// memory to be exported. Close finishes the document and must be called.
+type Writer struct {
+	w       *bufio.Writer
+	columns []databases.Column
+	opts    Options
+	rows    int
+	// typed writes the binary formats, which keep the column types
+	typed typedWriter
+}
+
+type typedWriter interface {
+	WriteRow(row []any) error
+	Close() error
+}
+
+func NewWriter(w io.Writer, columns []databases.Column, opts Options) (*Writer, error) {
+	switch opts.Format {
+	case FormatCSV, FormatTSV, FormatJSON, FormatNDJSON, FormatMarkdown, FormatHTML, FormatParquet, FormatXLSX:
+	case FormatSQL:
+		if strings.TrimSpace(opts.Table) == "" {
+			return nil, fmt.Errorf("INSERT statements need a table name")
+		}
+	default:
+		return nil, fmt.Errorf("unknown format %q, expected one of %s", opts.Format, strings.Join(Formats, ", "))
+	}
+	if opts.Format == FormatCSV && opts.Delimiter == "" {
+		opts.Delimiter = ","
+	}
+	if opts.Format == FormatTSV {
+		opts.Delimiter = "\t"
+	}
+
+	x := &Writer{w: bufio.NewWriter(w), columns: columns, opts: opts}
+	return x, x.start()
+}
+
+// Write writes a whole result set.
+func Write(w io.Writer, columns []databases.Column, rows [][]any, opts Options) error {
+	x, err := NewWriter(w, columns, opts)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := x.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	return x.Close()
+}
+
+func (x *Writer) names() []string {
+	names := make([]string, len(x.columns))
+	for i, column := range x.columns {
+		names[i] = column.Name
+	}
+	return names
+}
+
+func (x *Writer) start() error {
+	var err error
+	switch x.opts.Format {
+	case FormatParquet:
+		x.typed, err = newParquetWriter(x.w, x.columns)
+		return err
+	case FormatXLSX:
+		x.typed, err = newXLSXWriter(x.w, x.columns)
+		return err
+	case FormatCSV, FormatTSV:
+		if x.opts.Header {
+			return x.delimited(x.names(), nil)
+		}
+	case FormatJSON:
+		_, err = x.w.WriteString("[")
+		return err
+	case FormatMarkdown:
+		cells, rule := []string{}, []string{}
+		for _, name := range x.names() {
+			cells = append(cells, markdownCell(name))
+			rule = append(rule, "---")
+		}
+		_, err = fmt.Fprintf(x.w, "| %s |\n| %s |\n", strings.Join(cells, " | "), strings.Join(rule, " | "))
+		return err
+	case FormatHTML:
+		var out strings.Builder
+		out.WriteString("<table>\n  <thead>\n    <tr>")
+		for _, name := range x.names() {
+			out.WriteString("<th>" + html.EscapeString(name) + "</th>")
+		}
+		out.WriteString("</tr>\n  </thead>\n  <tbody>\n")
+		_, err = x.w.WriteString(out.String())
+		return err
+	}
+	return nil
+}
+
+func (x *Writer) WriteRow(row []any) error {
+	defer func() { x.rows++ }()
+
+	switch x.opts.Format {
+	case FormatCSV, FormatTSV:
+		fields := make([]string, len(row))
+		nulls := make([]bool, len(row))
+		for i, value := range row {
+			fields[i], nulls[i] = x.text(value), value == nil
+		}
+		return x.delimited(fields, nulls)
+	case FormatJSON, FormatNDJSON:
+		return x.object(row)
+	case FormatMarkdown:
+		cells := make([]string, len(row))
+		for i, value := range row {
+			cells[i] = markdownCell(x.text(value))
+		}
+		_, err := fmt.Fprintf(x.w, "| %s |\n", strings.Join(cells, " | "))
+		return err
+	case FormatHTML:
+		var out strings.Builder
+		out.WriteString("    <tr>")
+		for _, value := range row {
+			out.WriteString("<td>" + html.EscapeString(x.text(value)) + "</td>")
+		}
+		out.WriteString("</tr>\n")
+		_, err := x.w.WriteString(out.String())
+		return err
+	case FormatSQL:
+		return x.insert(row)
+	case FormatParquet, FormatXLSX:
+		return x.typed.WriteRow(row)
+	}
+	return nil
+}
+
+func (x *Writer) Close() error {
+	var err error
+	switch x.opts.Format {
+	case FormatJSON:
+		if x.rows > 0 {
+			_, err = x.w.WriteString("\n]\n")
+		} else {
+			_, err = x.w.WriteString("]\n")
+		}
+	case FormatHTML:
+		_, err = x.w.WriteString("  </tbody>\n</table>\n")
+	case FormatParquet, FormatXLSX:
+		err = x.typed.Close()
+	}
+	if err != nil {
+		return err
+	}
+	return x.w.Flush()
+}
+
+// Rows is how many rows have been written.
+func (x *Writer) Rows() int {
+	return x.rows
+}
+
+// text is how a value is written in the text formats.
+func (x *Writer) text(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return x.opts.Null
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(timeLayout)
+	}
+	return fmt.Sprint(value)
+}
+
+// delimited writes a CSV or TSV line. NULLs are left unquoted even when
+// quoting everything so they can be told apart from empty strings.
+func (x *Writer) delimited(fields []string, nulls []bool) error {
+	for i, field := range fields {
+		if i > 0 {
+			x.w.WriteString(x.opts.Delimiter)
+		}
+		quote := false
+		switch x.opts.Quoting {
+		case QuoteAll:
+			quote = nulls == nil || !nulls[i]
+		case QuoteNone:
+		default:
+			quote = field != "" && (strings.Contains(field, x.opts.Delimiter) ||
+				strings.ContainsAny(field, "\"\r\n") || field[0] == ' ' || field[len(field)-1] == ' ')
+		}
+		if quote {
+			field = `"` + strings.ReplaceAll(field, `"`, `""`) + `"`
+		}
+		x.w.WriteString(field)
+	}
+	_, err := x.w.WriteString("\n")
+	return err
+}
+
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// object writes the row as a JSON object, keeping the column order.
+func (x *Writer) object(row []any) error {
+	var out strings.Builder
+	if x.opts.Format == FormatJSON {
+		if x.rows > 0 {
+			out.WriteString(",")
+		}
+		out.WriteString("\n  ")
+	}
+
+	out.WriteString("{")
+	for i, value := range row {
+		if i > 0 {
+			out.WriteString(",")
+		}
+		name, _ := json.Marshal(x.columns[i].Name)
+		encoded, err := json.Marshal(jsonValue(value))
+		if err != nil {
+			return err
+		}
+		out.Write(name)
+		out.WriteString(":")
+		out.Write(encoded)
+	}
+	out.WriteString("}")
+	if x.opts.Format == FormatNDJSON {
+		out.WriteString("\n")
+	}
+
+	_, err := x.w.WriteString(out.String())
+	return err
+}
+
+func jsonValue(value any) any {
+	switch v := value.(type) {
+	case []byte:
+		if !utf8.Valid(v) {
+			// encoding/json writes byte slices as base64
+			return v
+		}
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return value
+}
+
+func (x *Writer) insert(row []any) error {
+	columns := make([]string, len(x.columns))
+	for i, column := range x.columns {
+		columns[i] = databases.QuoteIdentifier(column.Name, x.opts.Engine)
+	}
+	values := make([]string, len(row))
+	for i, value := range row {
+		values[i] = databases.QuoteLiteral(value, x.opts.Engine)
+	}
+
+	_, err := fmt.Fprintf(x.w, "INSERT INTO %s (%s) VALUES (%s);\n",
+		databases.QuoteName(strings.Split(strings.TrimSpace(x.opts.Table), "."), x.opts.Engine), strings.Join(columns, ", "), strings.Join(values, ", "))
+	return err
+}
@@ -0,0 +1,373 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/therealphatmike/squeal/util/databases"
+)
+
+func TestWrite(t *testing.T) {
+	columns := []databases.Column{{Name: "id"}, {Name: "note"}, {Name: "seen"}}
+	rows := [][]any{
+		{int64(1), []byte(`say "hi", please`), time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)},
+		{int64(2), nil, true},
+	}
+
+	cases := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{
+			"csv",
+			Options{Format: FormatCSV, Header: true, Quoting: QuoteMinimal, Null: "NULL"},
+			"id,note,seen\n1,\"say \"\"hi\"\", please\",2024-03-01 09:30:00+00:00\n2,NULL,true\n",
+		},
+		{
+			"csv quoting everything without a header",
+			Options{Format: FormatCSV, Delimiter: ";", Quoting: QuoteAll},
+			"\"1\";\"say \"\"hi\"\", please\";\"2024-03-01 09:30:00+00:00\"\n\"2\";;\"true\"\n",
+		},
+		{
+			"tsv",
+			Options{Format: FormatTSV, Header: true, Quoting: QuoteNone},
+			"id\tnote\tseen\n1\tsay \"hi\", please\t2024-03-01 09:30:00+00:00\n2\t\ttrue\n",
+		},
+		{
+			"json",
+			Options{Format: FormatJSON},
+			"[\n  {\"id\":1,\"note\":\"say \\\"hi\\\", please\",\"seen\":\"2024-03-01T09:30:00Z\"},\n  {\"id\":2,\"note\":null,\"seen\":true}\n]\n",
+		},
+		{
+			"ndjson",
+			Options{Format: FormatNDJSON},
+			"{\"id\":1,\"note\":\"say \\\"hi\\\", please\",\"seen\":\"2024-03-01T09:30:00Z\"}\n{\"id\":2,\"note\":null,\"seen\":true}\n",
+		},
+		{
+			"markdown",
+			Options{Format: FormatMarkdown, Null: "NULL"},
+			"| id | note | seen |\n| --- | --- | --- |\n| 1 | say \"hi\", please | 2024-03-01 09:30:00+00:00 |\n| 2 | NULL | true |\n",
+		},
+		{
+			"sql for postgres",
+			Options{Format: FormatSQL, Table: "public.notes", Engine: databases.EnginePostgres},
+			"INSERT INTO \"public\".\"notes\" (\"id\", \"note\", \"seen\") VALUES (1, 'say \"hi\", please', '2024-03-01 09:30:00+00:00');\n" +
+				"INSERT INTO \"public\".\"notes\" (\"id\", \"note\", \"seen\") VALUES (2, NULL, TRUE);\n",
+		},
+		{
+			"sql for mysql",
+			Options{Format: FormatSQL, Table: "shop.notes", Engine: databases.EngineMySQL},
+			"INSERT INTO `shop`.`notes` (`id`, `note`, `seen`) VALUES (1, 'say \"hi\", please', '2024-03-01 09:30:00');\n" +
+				"INSERT INTO `shop`.`notes` (`id`, `note`, `seen`) VALUES (2, NULL, TRUE);\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := Write(&out, columns, rows, tc.opts); err != nil {
+				t.Fatalf("Write() error: %v", err)
+			}
+			if out.String() != tc.want {
+				t.Errorf("Write() =\n%s\nwant\n%s", out.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestHTMLEscapes(t *testing.T) {
+	var out strings.Builder
+	if err := Write(&out, []databases.Column{{Name: "<b>"}}, [][]any{{"a & b"}}, Options{Format: FormatHTML}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "<th>&lt;b&gt;</th>") || !strings.Contains(out.String(), "<td>a &amp; b</td>") {
+		t.Errorf("html not escaped:\n%s", out.String())
+	}
+}
+
+func TestParquet(t *testing.T) {
+	columns := []databases.Column{{Name: "id", DatabaseType: "INT8"}, {Name: "seen", DatabaseType: "BOOL"}}
+	rows := [][]any{{int64(1), true}, {[]byte("2"), nil}, {int64(3), false}}
+
+	var out strings.Builder
+	if err := Write(&out, columns, rows, Options{Format: FormatParquet}); err != nil {
+		t.Fatal(err)
+	}
+	file := out.String()
+	if !strings.HasPrefix(file, "PAR1") || !strings.HasSuffix(file, "PAR1") {
+		t.Fatalf("missing magic: %q", file)
+	}
+	footer := int(binary.LittleEndian.Uint32([]byte(file[len(file)-8 : len(file)-4])))
+	if footer <= 0 || footer > len(file)-12 {
+		t.Fatalf("footer length %d doesn't fit in %d bytes", footer, len(file))
+	}
+
+	// every id is present, so its page is a single run of levels followed by
+	// the values, the text one parsed
+	want := string(definitionLevels([]byte{1, 1, 1})) + "\x01\x00\x00\x00\x00\x00\x00\x00\x02\x00\x00\x00\x00\x00\x00\x00\x03\x00\x00\x00\x00\x00\x00\x00"
+	if !strings.Contains(file, want) {
+		t.Errorf("id values not written as PLAIN int64s")
+	}
+}
+
+func TestParquetDecimals(t *testing.T) {
+	columns := []databases.Column{
+		{Name: "price", DatabaseType: "NUMERIC", Precision: 10, Scale: 2, DecimalKnown: true},
+		{Name: "ratio", DatabaseType: "NUMERIC"},
+	}
+	rows := [][]any{{[]byte("12.5"), []byte("0.333")}, {"-1.05", nil}}
+
+	var out strings.Builder
+	if err := Write(&out, columns, rows, Options{Format: FormatParquet}); err != nil {
+		t.Fatal(err)
+	}
+	// 1250 and -105, length prefixed
+	if !strings.Contains(out.String(), "\x02\x00\x00\x00\x04\xe2\x01\x00\x00\x00\x97") {
+		t.Errorf("price not written as unscaled DECIMAL values")
+	}
+	if !strings.Contains(out.String(), "\x05\x00\x00\x000.333") {
+		t.Errorf("ratio without a precision not kept as text")
+	}
+
+	err := Write(io.Discard, columns[:1], [][]any{{"1.005"}}, Options{Format: FormatParquet})
+	if err == nil || !strings.Contains(err.Error(), "decimal places") {
+		t.Errorf("Write() error = %v, want one about the scale", err)
+	}
+}
+
+// TestParquetFooter reads the footer back the way a parquet reader would and
+// follows it to every page, across more than one row group.
+func TestParquetFooter(t *testing.T) {
+	columns := []databases.Column{
+		{Name: "id", DatabaseType: "INT8"},
+		{Name: "price", DatabaseType: "NUMERIC", Precision: 10, Scale: 2, DecimalKnown: true},
+	}
+	total := parquetGroupRows + 10
+	rows := make([][]any, total)
+	for i := range rows {
+		rows[i] = []any{int64(i), "1.50"}
+	}
+	rows[3][1] = nil
+
+	var out bytes.Buffer
+	if err := Write(&out, columns, rows, Options{Format: FormatParquet}); err != nil {
+		t.Fatal(err)
+	}
+	file := out.Bytes()
+	footerSize := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	footerStart := len(file) - 8 - footerSize
+	meta := (&compactReader{b: file[footerStart : len(file)-8]}).structure()
+
+	if meta[1] != int64(1) || meta[3] != int64(total) {
+		t.Errorf("version %v and num_rows %v, want 1 and %d", meta[1], meta[3], total)
+	}
+
+	schema := meta[2].([]any)
+	if len(schema) != 3 || schema[0].(thriftStruct)[5] != int64(2) {
+		t.Fatalf("schema = %v, want a root with 2 children", schema)
+	}
+	wantSchema := []thriftStruct{
+		{1: int64(parquetInt64), 3: int64(parquetOptional), 4: "id"},
+		{1: int64(parquetByteArray), 3: int64(parquetOptional), 4: "price", 6: int64(parquetDecimal), 7: int64(2), 8: int64(10)},
+	}
+	for i, want := range wantSchema {
+		if got := schema[i+1].(thriftStruct); !reflect.DeepEqual(got, want) {
+			t.Errorf("schema element %d = %v, want %v", i+1, got, want)
+		}
+	}
+
+	groups := meta[4].([]any)
+	if len(groups) != 2 {
+		t.Fatalf("%d row groups, want 2", len(groups))
+	}
+	offset, rowsSeen := int64(len(parquetMagic)), int64(0)
+	for g, group := range groups {
+		group := group.(thriftStruct)
+		groupRows := group[3].(int64)
+		rowsSeen += groupRows
+		size := int64(0)
+		for c, chunk := range group[1].([]any) {
+			chunk := chunk.(thriftStruct)
+			data := chunk[3].(thriftStruct)
+			if chunk[2] != offset || data[9] != offset {
+				t.Fatalf("group %d column %d starts at %v/%v, want %d", g, c, chunk[2], data[9], offset)
+			}
+			if data[3].([]any)[0] != columns[c].Name || data[5] != groupRows {
+				t.Errorf("group %d column %d metadata = %v", g, c, data)
+			}
+
+			// the page header is followed by exactly the page it describes
+			pageReader := &compactReader{b: file[offset:]}
+			page := pageReader.structure()
+			if page[1] != int64(parquetDataPage) || page[2] != page[3] || page[5].(thriftStruct)[1] != groupRows {
+				t.Errorf("group %d column %d page header = %v", g, c, page)
+			}
+			chunkSize := int64(pageReader.pos) + page[3].(int64)
+			if data[6] != chunkSize || data[7] != chunkSize {
+				t.Errorf("group %d column %d is %d bytes, metadata says %v/%v", g, c, chunkSize, data[6], data[7])
+			}
+			offset += chunkSize
+			size += chunkSize
+		}
+		if group[2] != size {
+			t.Errorf("group %d total_byte_size %v, want %d", g, group[2], size)
+		}
+	}
+	if rowsSeen != int64(total) {
+		t.Errorf("row groups hold %d rows, want %d", rowsSeen, total)
+	}
+	if offset != int64(footerStart) {
+		t.Errorf("pages end at %d, the footer starts at %d", offset, footerStart)
+	}
+}
+
+// thriftStruct is a decoded Thrift struct, by field id.
+type thriftStruct map[int16]any
+
+// compactReader decodes Thrift's compact protocol, as much as the tests need
+// to read parquet metadata back.
+type compactReader struct {
+	b   []byte
+	pos int
+}
+
+func (r *compactReader) byte() byte {
+	b := r.b[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *compactReader) uvarint() uint64 {
+	n, size := binary.Uvarint(r.b[r.pos:])
+	r.pos += size
+	return n
+}
+
+func (r *compactReader) varint() int64 {
+	n := r.uvarint()
+	return int64(n>>1) ^ -int64(n&1)
+}
+
+func (r *compactReader) value(typ byte) any {
+	switch typ {
+	case 1, 2:
+		return typ == 1
+	case 5, 6:
+		return r.varint()
+	case compactBinary:
+		n := int(r.uvarint())
+		r.pos += n
+		return string(r.b[r.pos-n : r.pos])
+	case compactList:
+		header := r.byte()
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(r.uvarint())
+		}
+		list := make([]any, size)
+		for i := range list {
+			list[i] = r.value(header & 0x0f)
+		}
+		return list
+	case compactStruct:
+		return r.structure()
+	}
+	panic(fmt.Sprintf("unexpected compact type %d at %d", typ, r.pos))
+}
+
+func (r *compactReader) structure() thriftStruct {
+	s := thriftStruct{}
+	id := int16(0)
+	for {
+		header := r.byte()
+		if header == 0 {
+			return s
+		}
+		if delta := header >> 4; delta != 0 {
+			id += int16(delta)
+		} else {
+			id = int16(r.varint())
+		}
+		s[id] = r.value(header & 0x0f)
+	}
+}
+
+func TestParquetRejectsBadValues(t *testing.T) {
+	columns := []databases.Column{{Name: "id", DatabaseType: "INT4"}}
+	err := Write(io.Discard, columns, [][]any{{[]byte("one")}}, Options{Format: FormatParquet})
+	if err == nil || !strings.Contains(err.Error(), "column id") {
+		t.Errorf("Write() error = %v, want one naming the column", err)
+	}
+}
+
+func TestDefinitionLevels(t *testing.T) {
+	got := definitionLevels([]byte{1, 1, 0, 1})
+	want := []byte{2 << 1, 1, 1 << 1, 0, 1 << 1, 1}
+	if string(got) != string(want) {
+		t.Errorf("definitionLevels() = %v, want %v", got, want)
+	}
+}
+
+func TestCompactWriter(t *testing.T) {
+	var c compactWriter
+	c.i32(1, 1)
+	c.str(4, "id")
+	c.i64(20, -1)
+	c.stop()
+
+	want := []byte{0x15, 0x02, 0x38, 0x02, 'i', 'd', 0x06, 0x28, 0x01, 0x00}
+	if string(c.buf.Bytes()) != string(want) {
+		t.Errorf("compact encoding = % x, want % x", c.buf.Bytes(), want)
+	}
+}
+
+func TestXLSX(t *testing.T) {
+	columns := []databases.Column{{Name: "id", DatabaseType: "INT4"}, {Name: "note", DatabaseType: "TEXT"}, {Name: "day", DatabaseType: "DATE"}}
+	rows := [][]any{{int64(7), "a & b", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}, {nil, nil, nil}}
+
+	var out bytes.Buffer
+	if err := Write(&out, columns, rows, Options{Format: FormatXLSX}); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sheet []byte
+	for _, f := range archive.File {
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			r, _ := f.Open()
+			sheet, _ = io.ReadAll(r)
+		}
+	}
+	for _, want := range []string{
+		`state="frozen"`,
+		`<c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">id</t></is></c>`,
+		`<c r="A2"><v>7</v></c>`,
+		`<t xml:space="preserve">a &amp; b</t>`,
+		`<c r="C2" s="3"><v>45352</v></c>`,
+		`<row r="3"></row>`,
+	} {
+		if !strings.Contains(string(sheet), want) {
+			t.Errorf("sheet is missing %s:\n%s", want, sheet)
+		}
+	}
+}
+
+func TestCellName(t *testing.T) {
+	cases := map[int]string{0: "A1", 25: "Z1", 26: "AA1", 701: "ZZ1", 702: "AAA1"}
+	for column, want := range cases {
+		if got := cellName(column, 1); got != want {
+			t.Errorf("cellName(%d) = %s, want %s", column, got, want)
+		}
+	}
+}
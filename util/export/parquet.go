@@ -0,0 +1,429 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/therealphatmike/squeal/util/databases"
+)
+
+// Parquet is written by hand: uncompressed PLAIN pages, one per column in
+// each row group, with the footer in Thrift's compact protocol. Every column
+// is OPTIONAL so NULLs need nothing special. Rows are buffered a row group at
+// a time, so memory stays bounded however many rows are exported.
+
+const (
+	parquetMagic = "PAR1"
+	// a row group is written once it has this many rows or bytes
+	parquetGroupRows  = 65536
+	parquetGroupBytes = 64 << 20
+)
+
+// parquet physical types
+const (
+	parquetBoolean   = 0
+	parquetInt32     = 1
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+)
+
+// parquet converted types, or noConvertedType when a column has none
+const (
+	noConvertedType        = -1
+	parquetUTF8            = 0
+	parquetDecimal         = 5
+	parquetDate            = 6
+	parquetTimestampMicros = 10
+	parquetJSON            = 19
+)
+
+const (
+	parquetOptional     = 1
+	parquetEncodingRLE  = 3
+	parquetPlain        = 0
+	parquetDataPage     = 0
+	parquetUncompressed = 0
+)
+
+type parquetColumn struct {
+	name      string
+	kind      columnKind
+	physical  int32
+	converted int32
+	// precision and scale of DECIMAL columns
+	precision int32
+	scale     int32
+
+	// the current row group: PLAIN encoded values, booleans waiting to be
+	// packed, and a definition level per row
+	values bytes.Buffer
+	bools  []bool
+	levels []byte
+}
+
+type parquetChunk struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+type parquetGroup struct {
+	chunks []parquetChunk
+	rows   int64
+	size   int64
+}
+
+type parquetWriter struct {
+	w       io.Writer
+	offset  int64
+	columns []*parquetColumn
+	rows    int64
+	size    int
+	groups  []parquetGroup
+}
+
+func newParquetWriter(w io.Writer, columns []databases.Column) (*parquetWriter, error) {
+	p := &parquetWriter{w: w}
+	for i, name := range columnNames(columns) {
+		column := &parquetColumn{name: name, kind: kindOf(columns[i]), converted: noConvertedType}
+		switch column.kind {
+		case kindInteger:
+			column.physical = parquetInt64
+		case kindFloat:
+			column.physical = parquetDouble
+		case kindBoolean:
+			column.physical = parquetBoolean
+		case kindTimestamp:
+			column.physical, column.converted = parquetInt64, parquetTimestampMicros
+		case kindDate:
+			column.physical, column.converted = parquetInt32, parquetDate
+		case kindBinary:
+			column.physical = parquetByteArray
+		case kindJSON:
+			column.physical, column.converted = parquetByteArray, parquetJSON
+		case kindDecimal:
+			// decimals without a declared precision and scale, such as
+			// PostgreSQL's bare numeric, can't be a DECIMAL so stay text
+			if !columns[i].DecimalKnown || columns[i].Precision <= 0 {
+				column.physical, column.converted = parquetByteArray, parquetUTF8
+				break
+			}
+			column.physical, column.converted = parquetByteArray, parquetDecimal
+			column.precision, column.scale = int32(columns[i].Precision), int32(columns[i].Scale)
+		default:
+			column.physical, column.converted = parquetByteArray, parquetUTF8
+		}
+		p.columns = append(p.columns, column)
+	}
+	return p, p.write([]byte(parquetMagic))
+}
+
+func (p *parquetWriter) write(b []byte) error {
+	n, err := p.w.Write(b)
+	p.offset += int64(n)
+	return err
+}
+
+func (p *parquetWriter) WriteRow(row []any) error {
+	for i, column := range p.columns {
+		value, err := convert(column.kind, row[i])
+		if err == nil && value != nil && column.converted == parquetDecimal {
+			value, err = unscaledDecimal(value.(string), column.scale)
+		}
+		if err != nil {
+			return fmt.Errorf("row %d, column %s: %w", p.rows+1, column.name, err)
+		}
+		if value == nil {
+			column.levels = append(column.levels, 0)
+			continue
+		}
+		column.levels = append(column.levels, 1)
+
+		before := column.values.Len()
+		switch v := value.(type) {
+		case int64:
+			binary.Write(&column.values, binary.LittleEndian, v)
+		case float64:
+			binary.Write(&column.values, binary.LittleEndian, math.Float64bits(v))
+		case bool:
+			column.bools = append(column.bools, v)
+		case time.Time:
+			if column.physical == parquetInt32 {
+				wall := time.Date(v.Year(), v.Month(), v.Day(), 0, 0, 0, 0, time.UTC)
+				binary.Write(&column.values, binary.LittleEndian, int32(wall.Unix()/86400))
+			} else {
+				binary.Write(&column.values, binary.LittleEndian, v.UnixMicro())
+			}
+		case []byte:
+			binary.Write(&column.values, binary.LittleEndian, uint32(len(v)))
+			column.values.Write(v)
+		case string:
+			binary.Write(&column.values, binary.LittleEndian, uint32(len(v)))
+			column.values.WriteString(v)
+		}
+		p.size += column.values.Len() - before
+	}
+
+	p.rows++
+	if len(p.columns) > 0 && len(p.columns[0].levels) >= parquetGroupRows || p.size >= parquetGroupBytes {
+		return p.flush()
+	}
+	return nil
+}
+
+// flush writes the buffered rows as a row group.
+func (p *parquetWriter) flush() error {
+	if len(p.columns) == 0 || len(p.columns[0].levels) == 0 {
+		return nil
+	}
+
+	group := parquetGroup{rows: int64(len(p.columns[0].levels))}
+	for _, column := range p.columns {
+		var page bytes.Buffer
+		levels := definitionLevels(column.levels)
+		binary.Write(&page, binary.LittleEndian, uint32(len(levels)))
+		page.Write(levels)
+		page.Write(packBooleans(column.bools))
+		page.Write(column.values.Bytes())
+
+		var header compactWriter
+		header.i32(1, parquetDataPage)
+		header.i32(2, int32(page.Len()))
+		header.i32(3, int32(page.Len()))
+		header.beginStruct(5)
+		header.i32(1, int32(len(column.levels)))
+		header.i32(2, parquetPlain)
+		header.i32(3, parquetEncodingRLE)
+		header.i32(4, parquetEncodingRLE)
+		header.endStruct()
+		header.stop()
+
+		chunk := parquetChunk{offset: p.offset, size: int64(header.buf.Len() + page.Len()), values: int64(len(column.levels))}
+		if err := p.write(header.buf.Bytes()); err != nil {
+			return err
+		}
+		if err := p.write(page.Bytes()); err != nil {
+			return err
+		}
+		group.chunks = append(group.chunks, chunk)
+		group.size += chunk.size
+
+		column.values.Reset()
+		column.bools = column.bools[:0]
+		column.levels = column.levels[:0]
+	}
+
+	p.groups = append(p.groups, group)
+	p.size = 0
+	return nil
+}
+
+func (p *parquetWriter) Close() error {
+	if err := p.flush(); err != nil {
+		return err
+	}
+
+	var footer compactWriter
+	footer.i32(1, 1)
+	footer.beginList(2, compactStruct, len(p.columns)+1)
+	footer.beginElement()
+	footer.str(4, "schema")
+	footer.i32(5, int32(len(p.columns)))
+	footer.endElement()
+	for _, column := range p.columns {
+		footer.beginElement()
+		footer.i32(1, column.physical)
+		footer.i32(3, parquetOptional)
+		footer.str(4, column.name)
+		if column.converted != noConvertedType {
+			footer.i32(6, column.converted)
+		}
+		if column.converted == parquetDecimal {
+			footer.i32(7, column.scale)
+			footer.i32(8, column.precision)
+		}
+		footer.endElement()
+	}
+	footer.i64(3, p.rows)
+	footer.beginList(4, compactStruct, len(p.groups))
+	for _, group := range p.groups {
+		footer.beginElement()
+		footer.beginList(1, compactStruct, len(group.chunks))
+		for i, chunk := range group.chunks {
+			column := p.columns[i]
+			footer.beginElement()
+			footer.i64(2, chunk.offset)
+			footer.beginStruct(3)
+			footer.i32(1, column.physical)
+			footer.beginList(2, compactI32, 2)
+			footer.varint(parquetPlain)
+			footer.varint(parquetEncodingRLE)
+			footer.beginList(3, compactBinary, 1)
+			footer.uvarint(uint64(len(column.name)))
+			footer.buf.WriteString(column.name)
+			footer.i32(4, parquetUncompressed)
+			footer.i64(5, chunk.values)
+			footer.i64(6, chunk.size)
+			footer.i64(7, chunk.size)
+			footer.i64(9, chunk.offset)
+			footer.endStruct()
+			footer.endElement()
+		}
+		footer.i64(2, group.size)
+		footer.i64(3, group.rows)
+		footer.endElement()
+	}
+	footer.str(6, "squeal")
+	footer.stop()
+
+	if err := p.write(footer.buf.Bytes()); err != nil {
+		return err
+	}
+	if err := binary.Write(p.w, binary.LittleEndian, uint32(footer.buf.Len())); err != nil {
+		return err
+	}
+	_, err := io.WriteString(p.w, parquetMagic)
+	return err
+}
+
+// unscaledDecimal is a decimal as parquet stores it: the value times ten to
+// the scale, as a big-endian two's complement integer.
+func unscaledDecimal(text string, scale int32) ([]byte, error) {
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(text))
+	if !ok {
+		return nil, fmt.Errorf("%q is not a decimal", text)
+	}
+	value.Mul(value, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)))
+	if !value.IsInt() {
+		return nil, fmt.Errorf("%q has more than %d decimal places", text, scale)
+	}
+
+	n := value.Num()
+	size := n.BitLen()/8 + 1
+	if n.Sign() < 0 {
+		// two's complement is the value plus 2^bits
+		n = new(big.Int).Add(n, new(big.Int).Lsh(big.NewInt(1), uint(size*8)))
+	}
+	return n.FillBytes(make([]byte, size)), nil
+}
+
+// definitionLevels encodes the levels as runs in parquet's RLE/bit-packed
+// hybrid, using only runs since a level is a single bit.
+func definitionLevels(levels []byte) []byte {
+	var out compactWriter
+	for i := 0; i < len(levels); {
+		j := i
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		out.uvarint(uint64(j-i) << 1)
+		out.buf.WriteByte(levels[i])
+		i = j
+	}
+	return out.buf.Bytes()
+}
+
+// packBooleans packs booleans into bits, lowest bit first.
+func packBooleans(bools []bool) []byte {
+	packed := make([]byte, (len(bools)+7)/8)
+	for i, b := range bools {
+		if b {
+			packed[i/8] |= 1 << (i % 8)
+		}
+	}
+	return packed
+}
+
+// compact protocol field types
+const (
+	compactI32    = 5
+	compactI64    = 6
+	compactBinary = 8
+	compactList   = 9
+	compactStruct = 12
+)
+
+// compactWriter writes Thrift's compact protocol, as much of it as parquet
+// metadata needs. Field ids are written as deltas from the previous field in
+// the same struct.
+type compactWriter struct {
+	buf  bytes.Buffer
+	last []int16
+	id   int16
+}
+
+func (c *compactWriter) uvarint(n uint64) {
+	c.buf.Write(binary.AppendUvarint(nil, n))
+}
+
+func (c *compactWriter) varint(n int64) {
+	c.uvarint(uint64(n<<1) ^ uint64(n>>63))
+}
+
+func (c *compactWriter) field(id int16, typ byte) {
+	if delta := id - c.id; delta > 0 && delta <= 15 {
+		c.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		c.buf.WriteByte(typ)
+		c.varint(int64(id))
+	}
+	c.id = id
+}
+
+func (c *compactWriter) i32(id int16, n int32) {
+	c.field(id, compactI32)
+	c.varint(int64(n))
+}
+
+func (c *compactWriter) i64(id int16, n int64) {
+	c.field(id, compactI64)
+	c.varint(n)
+}
+
+func (c *compactWriter) str(id int16, s string) {
+	c.field(id, compactBinary)
+	c.uvarint(uint64(len(s)))
+	c.buf.WriteString(s)
+}
+
+func (c *compactWriter) beginList(id int16, typ byte, size int) {
+	c.field(id, compactList)
+	if size < 15 {
+		c.buf.WriteByte(byte(size)<<4 | typ)
+	} else {
+		c.buf.WriteByte(0xf0 | typ)
+		c.uvarint(uint64(size))
+	}
+}
+
+// beginElement starts a struct inside a list, which has no field header.
+func (c *compactWriter) beginElement() {
+	c.last = append(c.last, c.id)
+	c.id = 0
+}
+
+func (c *compactWriter) endElement() {
+	c.stop()
+	c.id = c.last[len(c.last)-1]
+	c.last = c.last[:len(c.last)-1]
+}
+
+func (c *compactWriter) beginStruct(id int16) {
+	c.field(id, compactStruct)
+	c.beginElement()
+}
+
+func (c *compactWriter) endStruct() {
+	c.endElement()
+}
+
+func (c *compactWriter) stop() {
+	c.buf.WriteByte(0)
+}
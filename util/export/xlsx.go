@@ -0,0 +1,216 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/therealphatmike/squeal/util/databases"
+)
+
+// XLSX is written by hand too: the sheet streams straight into the zip and
+// the rest of the workbook, which doesn't depend on the rows, is added when
+// it's closed. Strings are written inline so nothing has to be collected for
+// a shared strings table.
+
+const (
+	// Excel won't open a sheet with more rows than this, or show more than
+	// this many characters in a cell
+	xlsxMaxRows      = 1048576
+	xlsxMaxCellChars = 32767
+)
+
+// styles, in the order of cellXfs in xlsxStyles
+const (
+	xlsxDefaultStyle = iota
+	xlsxHeaderStyle
+	xlsxTimestampStyle
+	xlsxDateStyle
+)
+
+// spreadsheet dates count days from here, pretending 1900 was a leap year
+var xlsxEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+type xlsxWriter struct {
+	zip   *zip.Writer
+	sheet io.Writer
+	kinds []columnKind
+	names []string
+	rows  int
+}
+
+func newXLSXWriter(w io.Writer, columns []databases.Column) (*xlsxWriter, error) {
+	x := &xlsxWriter{zip: zip.NewWriter(w), names: columnNames(columns)}
+	for _, column := range columns {
+		x.kinds = append(x.kinds, kindOf(column))
+	}
+
+	sheet, err := x.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	x.sheet = sheet
+
+	// the header row stays put while scrolling
+	var out strings.Builder
+	out.WriteString(xml.Header)
+	out.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	out.WriteString(`<sheetViews><sheetView workbookViewId="0">`)
+	out.WriteString(`<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>`)
+	out.WriteString(`<selection pane="bottomLeft"/></sheetView></sheetViews><sheetData>`)
+	out.WriteString(`<row r="1">`)
+	for i, name := range x.names {
+		out.WriteString(inlineString(cellName(i, 1), name, xlsxHeaderStyle))
+	}
+	out.WriteString(`</row>`)
+	_, err = io.WriteString(x.sheet, out.String())
+	return x, err
+}
+
+func (x *xlsxWriter) WriteRow(row []any) error {
+	if x.rows+1 >= xlsxMaxRows {
+		return fmt.Errorf("a sheet holds at most %d rows; export to CSV or Parquet instead", xlsxMaxRows-1)
+	}
+	x.rows++
+	r := x.rows + 1
+
+	var out strings.Builder
+	fmt.Fprintf(&out, `<row r="%d">`, r)
+	for i, raw := range row {
+		value, err := convert(x.kinds[i], raw)
+		if err != nil {
+			return fmt.Errorf("row %d, column %s: %w", x.rows, x.names[i], err)
+		}
+		ref := cellName(i, r)
+
+		switch v := value.(type) {
+		case nil:
+			// an empty cell is left out altogether
+		case int64:
+			fmt.Fprintf(&out, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case float64:
+			fmt.Fprintf(&out, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(v, 'g', -1, 64))
+		case bool:
+			b := 0
+			if v {
+				b = 1
+			}
+			fmt.Fprintf(&out, `<c r="%s" t="b"><v>%d</v></c>`, ref, b)
+		case time.Time:
+			style := xlsxTimestampStyle
+			if x.kinds[i] == kindDate {
+				style = xlsxDateStyle
+			}
+			fmt.Fprintf(&out, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, strconv.FormatFloat(serialDate(v), 'f', -1, 64))
+		default:
+			text := textOf(v)
+			if x.kinds[i] == kindDecimal {
+				if _, err := strconv.ParseFloat(text, 64); err == nil {
+					fmt.Fprintf(&out, `<c r="%s"><v>%s</v></c>`, ref, text)
+					continue
+				}
+			}
+			out.WriteString(inlineString(ref, text, xlsxDefaultStyle))
+		}
+	}
+	out.WriteString(`</row>`)
+
+	_, err := io.WriteString(x.sheet, out.String())
+	return err
+}
+
+func (x *xlsxWriter) Close() error {
+	if _, err := io.WriteString(x.sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, part := range parts {
+		w, err := x.zip.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, xml.Header+part.content); err != nil {
+			return err
+		}
+	}
+	return x.zip.Close()
+}
+
+// cellName is the A1 style reference for a zero based column and one based
+// row.
+func cellName(column int, row int) string {
+	name := ""
+	for column++; column > 0; column = (column - 1) / 26 {
+		name = string(rune('A'+(column-1)%26)) + name
+	}
+	return name + strconv.Itoa(row)
+}
+
+func inlineString(ref string, text string, style int) string {
+	if n := []rune(text); len(n) > xlsxMaxCellChars {
+		text = string(n[:xlsxMaxCellChars])
+	}
+	var escaped strings.Builder
+	// EscapeText swaps characters XML can't hold for U+FFFD
+	xml.EscapeText(&escaped, []byte(text))
+
+	s := ""
+	if style != xlsxDefaultStyle {
+		s = fmt.Sprintf(` s="%d"`, style)
+	}
+	return fmt.Sprintf(`<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, s, escaped.String())
+}
+
+// serialDate is the time as a spreadsheet serial number, keeping the wall
+// clock time since cells have no time zone.
+func serialDate(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return float64(wall.Sub(xlsxEpoch).Microseconds()) / float64(24*time.Hour/time.Microsecond)
+}
+
+const xlsxContentTypes = `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const xlsxRels = `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const xlsxWorkbook = `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<sheets><sheet name="Results" sheetId="1" r:id="rId1"/></sheets>` +
+	`</workbook>`
+
+const xlsxWorkbookRels = `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
+
+const xlsxStyles = `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="2"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm:ss"/><numFmt numFmtId="165" formatCode="yyyy-mm-dd"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="4">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
@@ -0,0 +1,27 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReplace(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "layouts.toml")
+	if err := os.WriteFile(file, []byte("a much longer old file\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Replace(file, []byte("new\n")); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(file); string(data) != "new\n" {
+		t.Errorf("file holds %q, want %q", data, "new\n")
+	}
+
+	// the temporary file is gone
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("%d files left in the directory, want 1", len(entries))
+	}
+}
@@ -0,0 +1,429 @@
+package formatter
+
+import (
+	"strings"
+
+	"github.com/therealphatmike/squeal/util/statements"
+)
+
+const (
+	UpperCase    = "upper"
+	LowerCase    = "lower"
+	PreserveCase = "preserve"
+)
+
+type Options struct {
+	// KeywordCase is one of UpperCase, LowerCase or PreserveCase.
+	KeywordCase string
+	Indent      string
+}
+
+func DefaultOptions() Options {
+	return Options{
+		KeywordCase: UpperCase,
+		Indent:      "  ",
+	}
+}
+
+// Format pretty prints src using the default options.
+func Format(src string, engine string) string {
+	return FormatWith(src, engine, DefaultOptions())
+}
+
+// FormatWith pretty prints every statement in src. Clauses start on their own
+// line, select lists and other comma separated clauses get one item per line,
+// and subqueries and CTE bodies are indented inside their parentheses.
+func FormatWith(src string, engine string, opts Options) string {
+	f := &formatter{
+		opts:   opts,
+		tokens: statements.Tokenize(src, engine),
+	}
+	f.reset()
+	f.run()
+
+	return strings.TrimSpace(f.out.String()) + "\n"
+}
+
+type clauseLayout int
+
+const (
+	// block clauses put their contents on the following lines, indented
+	block clauseLayout = iota
+	// inline clauses keep their contents on the keyword's line
+	inline
+	// continuation phrases never start a new line
+	continuation
+	// set operators sit on a line of their own
+	setOperator
+	join
+)
+
+type clause struct {
+	words  []string
+	layout clauseLayout
+	// list clauses put each comma separated item on its own line
+	list bool
+}
+
+// clauses are matched longest first at every keyword.
+var clauses = []clause{
+	{words: []string{"ON", "DUPLICATE", "KEY", "UPDATE"}, layout: block, list: true},
+	{words: []string{"FOR", "NO", "KEY", "UPDATE"}, layout: inline},
+	{words: []string{"LOCK", "IN", "SHARE", "MODE"}, layout: inline},
+	{words: []string{"FOR", "KEY", "SHARE"}, layout: inline},
+	{words: []string{"LEFT", "OUTER", "JOIN"}, layout: join},
+	{words: []string{"RIGHT", "OUTER", "JOIN"}, layout: join},
+	{words: []string{"FULL", "OUTER", "JOIN"}, layout: join},
+	{words: []string{"NATURAL", "LEFT", "JOIN"}, layout: join},
+	{words: []string{"NATURAL", "RIGHT", "JOIN"}, layout: join},
+	{words: []string{"WITH", "RECURSIVE"}, layout: inline, list: true},
+	{words: []string{"SELECT", "DISTINCT"}, layout: block, list: true},
+	{words: []string{"SELECT", "ALL"}, layout: block, list: true},
+	{words: []string{"GROUP", "BY"}, layout: block, list: true},
+	{words: []string{"ORDER", "BY"}, layout: block, list: true},
+	{words: []string{"INSERT", "INTO"}, layout: inline},
+	{words: []string{"REPLACE", "INTO"}, layout: inline},
+	{words: []string{"DELETE", "FROM"}, layout: inline},
+	{words: []string{"ON", "CONFLICT"}, layout: inline},
+	{words: []string{"FOR", "UPDATE"}, layout: inline},
+	{words: []string{"FOR", "SHARE"}, layout: inline},
+	{words: []string{"DO", "UPDATE"}, layout: continuation},
+	{words: []string{"DO", "NOTHING"}, layout: continuation},
+	{words: []string{"UNION", "ALL"}, layout: setOperator},
+	{words: []string{"EXCEPT", "ALL"}, layout: setOperator},
+	{words: []string{"INTERSECT", "ALL"}, layout: setOperator},
+	{words: []string{"LEFT", "JOIN"}, layout: join},
+	{words: []string{"RIGHT", "JOIN"}, layout: join},
+	{words: []string{"FULL", "JOIN"}, layout: join},
+	{words: []string{"INNER", "JOIN"}, layout: join},
+	{words: []string{"CROSS", "JOIN"}, layout: join},
+	{words: []string{"NATURAL", "JOIN"}, layout: join},
+	{words: []string{"STRAIGHT_JOIN"}, layout: join},
+	{words: []string{"JOIN"}, layout: join},
+	{words: []string{"WITH"}, layout: inline, list: true},
+	{words: []string{"SELECT"}, layout: block, list: true},
+	{words: []string{"FROM"}, layout: block, list: true},
+	{words: []string{"WHERE"}, layout: block},
+	{words: []string{"HAVING"}, layout: block},
+	{words: []string{"WINDOW"}, layout: block, list: true},
+	{words: []string{"VALUES"}, layout: block, list: true},
+	{words: []string{"SET"}, layout: block, list: true},
+	{words: []string{"RETURNING"}, layout: block, list: true},
+	{words: []string{"LIMIT"}, layout: inline},
+	{words: []string{"OFFSET"}, layout: inline},
+	{words: []string{"FETCH"}, layout: inline},
+	{words: []string{"UPDATE"}, layout: inline},
+	{words: []string{"UNION"}, layout: setOperator},
+	{words: []string{"EXCEPT"}, layout: setOperator},
+	{words: []string{"INTERSECT"}, layout: setOperator},
+}
+
+type frame struct {
+	// block frames are the top level and subqueries; everything else is an
+	// inline parenthesis such as a function call or IN list
+	block      bool
+	indent     int
+	closeAt    int
+	clause     clause
+	caseDepth  int
+	betweenAnd bool
+}
+
+type formatter struct {
+	opts       Options
+	tokens     []statements.Token
+	out        strings.Builder
+	frames     []frame
+	lineStart  bool
+	lineIndent int
+	prev       *statements.Token
+	prevUnary  bool
+}
+
+func (f *formatter) reset() {
+	f.frames = []frame{{block: true}}
+	f.lineStart = true
+	f.lineIndent = 0
+	f.prev = nil
+	f.prevUnary = false
+}
+
+func (f *formatter) top() *frame {
+	return &f.frames[len(f.frames)-1]
+}
+
+func (f *formatter) newline(indent int) {
+	if !f.lineStart {
+		f.out.WriteString("\n")
+	}
+	f.lineStart = true
+	f.lineIndent = indent
+}
+
+func (f *formatter) emit(tok statements.Token, text string) {
+	if f.lineStart {
+		f.out.WriteString(strings.Repeat(f.opts.Indent, f.lineIndent))
+	} else if f.spaceBefore(tok) {
+		f.out.WriteString(" ")
+	}
+	f.out.WriteString(text)
+	f.lineStart = false
+
+	f.prevUnary = tok.Kind == statements.Operator && (tok.Text == "-" || tok.Text == "+") && f.startsExpression()
+	t := tok
+	f.prev = &t
+}
+
+// startsExpression reports whether the previously written token leaves us at
+// the start of an operand, which makes a following + or - unary.
+func (f *formatter) startsExpression() bool {
+	if f.prev == nil {
+		return true
+	}
+	switch f.prev.Kind {
+	case statements.Operator, statements.Comment:
+		return true
+	case statements.Punctuation:
+		return f.prev.Text == "(" || f.prev.Text == "," || f.prev.Text == "["
+	case statements.Word:
+		return isKeyword(f.prev.Upper())
+	}
+	return false
+}
+
+func (f *formatter) spaceBefore(tok statements.Token) bool {
+	if f.prev == nil {
+		return false
+	}
+	prev := *f.prev
+
+	switch tok.Text {
+	case ",", ")", ";", ".", "]":
+		if tok.Kind == statements.Punctuation {
+			return false
+		}
+	case "::":
+		return false
+	}
+
+	if prev.Kind == statements.Punctuation && (prev.Text == "(" || prev.Text == "." || prev.Text == "[") {
+		return false
+	}
+	if prev.Text == "::" || f.prevUnary {
+		return false
+	}
+
+	if tok.Kind == statements.Punctuation && (tok.Text == "(" || tok.Text == "[") {
+		if prev.Kind == statements.Word && isKeyword(prev.Upper()) && !isTypeName(prev.Upper()) {
+			return true
+		}
+		if prev.Kind == statements.Word || prev.Kind == statements.QuotedIdentifier || prev.Text == ")" || prev.Text == "]" {
+			return tok.SpaceBefore
+		}
+	}
+
+	return true
+}
+
+func (f *formatter) keyword(text string) string {
+	switch f.opts.KeywordCase {
+	case LowerCase:
+		return strings.ToLower(text)
+	case PreserveCase:
+		return text
+	}
+	return strings.ToUpper(text)
+}
+
+func (f *formatter) matchClause(i int) (clause, bool) {
+	for _, c := range clauses {
+		if i+len(c.words) > len(f.tokens) {
+			continue
+		}
+		matched := true
+		for j, w := range c.words {
+			tok := f.tokens[i+j]
+			if tok.Kind != statements.Word || tok.Upper() != w {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return c, true
+		}
+	}
+
+	return clause{}, false
+}
+
+func (f *formatter) contentIndent() int {
+	fr := f.top()
+	if fr.clause.layout == block && len(fr.clause.words) > 0 {
+		return fr.indent + 1
+	}
+	return fr.indent
+}
+
+func (f *formatter) run() {
+	for i := 0; i < len(f.tokens); i++ {
+		tok := f.tokens[i]
+		fr := f.top()
+
+		switch {
+		case tok.Kind == statements.Comment:
+			f.comment(tok)
+
+		case tok.Is(statements.Punctuation, ";"):
+			f.emit(tok, ";")
+			f.out.WriteString("\n\n")
+			f.reset()
+			f.newline(0)
+
+		case tok.Is(statements.Punctuation, "("):
+			f.emit(tok, "(")
+			if f.opensSubquery(i) {
+				closeAt := f.lineIndent
+				f.frames = append(f.frames, frame{block: true, indent: closeAt + 1, closeAt: closeAt})
+				f.newline(closeAt + 1)
+			} else {
+				f.frames = append(f.frames, frame{indent: fr.indent, closeAt: -1})
+			}
+
+		case tok.Is(statements.Punctuation, ")"):
+			if len(f.frames) > 1 {
+				closing := *fr
+				f.frames = f.frames[:len(f.frames)-1]
+				if closing.block {
+					f.newline(closing.closeAt)
+				}
+			}
+			f.emit(tok, ")")
+
+		case tok.Is(statements.Punctuation, ","):
+			f.emit(tok, ",")
+			if fr.block && fr.clause.list && fr.caseDepth == 0 {
+				f.newline(f.contentIndent())
+			}
+
+		case tok.Kind == statements.Word:
+			i = f.word(i)
+
+		default:
+			f.emit(tok, tok.Text)
+		}
+	}
+}
+
+// word writes the keyword or identifier at i and returns the index of the
+// last token it consumed.
+func (f *formatter) word(i int) int {
+	tok := f.tokens[i]
+	fr := f.top()
+	upper := tok.Upper()
+
+	if fr.block && fr.caseDepth == 0 {
+		if c, ok := f.matchClause(i); ok && !f.valuesFunction(c) {
+			return f.clause(i, c)
+		}
+	}
+
+	switch upper {
+	case "CASE":
+		fr.caseDepth++
+	case "END":
+		if fr.caseDepth > 0 {
+			fr.caseDepth--
+		}
+	case "BETWEEN":
+		fr.betweenAnd = true
+	case "AND", "OR":
+		if upper == "AND" && fr.betweenAnd {
+			fr.betweenAnd = false
+			break
+		}
+		if fr.block && fr.caseDepth == 0 && len(fr.clause.words) > 0 {
+			switch fr.clause.words[0] {
+			case "WHERE", "HAVING":
+				f.newline(fr.indent + 1)
+			case "FROM":
+				f.newline(fr.indent + 2)
+			}
+		}
+	}
+
+	text := tok.Text
+	if isKeyword(upper) {
+		text = f.keyword(tok.Text)
+	}
+	f.emit(tok, text)
+
+	return i
+}
+
+// valuesFunction reports whether a VALUES clause is really MySQL's VALUES()
+// function, which refers to the inserted row in ON DUPLICATE KEY UPDATE.
+func (f *formatter) valuesFunction(c clause) bool {
+	current := f.top().clause.words
+	return c.words[0] == "VALUES" && len(current) > 1 && current[1] == "DUPLICATE"
+}
+
+func (f *formatter) clause(i int, c clause) int {
+	fr := f.top()
+
+	switch c.layout {
+	case block, inline:
+		f.newline(fr.indent)
+		fr.clause = c
+	case setOperator:
+		f.newline(fr.indent)
+		fr.clause = clause{}
+	case join:
+		f.newline(fr.indent + 1)
+	}
+
+	for j := range c.words {
+		f.emit(f.tokens[i+j], f.keyword(f.tokens[i+j].Text))
+	}
+
+	switch c.layout {
+	case block:
+		f.newline(fr.indent + 1)
+	case setOperator:
+		f.newline(fr.indent)
+	}
+
+	return i + len(c.words) - 1
+}
+
+func (f *formatter) comment(tok statements.Token) {
+	if strings.HasPrefix(tok.Text, "/*") {
+		f.emit(tok, tok.Text)
+		return
+	}
+
+	// line comments run to the end of the line, so whatever follows has to
+	// start a new one
+	f.emit(tok, tok.Text)
+	f.newline(f.lineIndent)
+}
+
+// opensSubquery reports whether the parenthesis at i wraps a query rather than
+// an expression list.
+func (f *formatter) opensSubquery(i int) bool {
+	for j := i + 1; j < len(f.tokens); j++ {
+		tok := f.tokens[j]
+		if tok.Kind == statements.Comment {
+			continue
+		}
+		if tok.Kind != statements.Word {
+			return false
+		}
+		switch tok.Upper() {
+		case "SELECT", "WITH", "VALUES":
+			return true
+		}
+		return false
+	}
+
+	return false
+}
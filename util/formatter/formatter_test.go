@@ -0,0 +1,87 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/therealphatmike/squeal/util/databases"
+)
+
+func TestFormat(t *testing.T) {
+	cases := []struct {
+		name   string
+		engine string
+		sql    string
+		want   string
+	}{
+		{
+			name:   "postgres clauses and conditions",
+			engine: databases.EnginePostgres,
+			sql:    "select a, b from t where a = 1 and b > 2 order by a desc limit 10",
+			want:   "SELECT\n  a,\n  b\nFROM\n  t\nWHERE\n  a = 1\n  AND b > 2\nORDER BY\n  a DESC\nLIMIT 10\n",
+		},
+		{
+			name:   "postgres row locks stay one clause",
+			engine: databases.EnginePostgres,
+			sql:    "select * from t for update of t skip locked; select * from t for no key update nowait",
+			want:   "SELECT\n  *\nFROM\n  t\nFOR UPDATE OF t SKIP LOCKED;\n\nSELECT\n  *\nFROM\n  t\nFOR NO KEY UPDATE NOWAIT\n",
+		},
+		{
+			name:   "postgres casts, CTEs and joins",
+			engine: databases.EnginePostgres,
+			sql:    "with x as (select 1 as n) select n::text from x left join y on y.n = x.n",
+			want:   "WITH x AS (\n  SELECT\n    1 AS n\n)\nSELECT\n  n::TEXT\nFROM\n  x\n  LEFT JOIN y ON y.n = x.n\n",
+		},
+		{
+			name:   "postgres update returning",
+			engine: databases.EnginePostgres,
+			sql:    "update t set a = 1, b = 2 where id = $1 returning id",
+			want:   "UPDATE t\nSET\n  a = 1,\n  b = 2\nWHERE\n  id = $1\nRETURNING\n  id\n",
+		},
+		{
+			name:   "mysql subqueries and share locks",
+			engine: databases.EngineMySQL,
+			sql:    "select id from t where x in (select y from u) lock in share mode",
+			want:   "SELECT\n  id\nFROM\n  t\nWHERE\n  x IN (\n    SELECT\n      y\n    FROM\n      u\n  )\nLOCK IN SHARE MODE\n",
+		},
+		{
+			name:   "mysql set operators and backticks",
+			engine: databases.EngineMySQL,
+			sql:    "select `a`, count(*) from t group by `a` having count(*) > 1 union all select 'x', -1 for share",
+			want:   "SELECT\n  `a`,\n  count(*)\nFROM\n  t\nGROUP BY\n  `a`\nHAVING\n  count(*) > 1\nUNION ALL\nSELECT\n  'x',\n  -1\nFOR SHARE\n",
+		},
+		{
+			name:   "mariadb upsert keeps VALUES() a function",
+			engine: databases.EngineMariaDB,
+			sql:    "insert into t (a, b) values (1, 'x') on duplicate key update b = values(b)",
+			want:   "INSERT INTO t (a, b)\nVALUES\n  (1, 'x')\nON DUPLICATE KEY UPDATE\n  b = VALUES (b)\n",
+		},
+		{
+			name:   "mariadb between and line comments",
+			engine: databases.EngineMariaDB,
+			sql:    "delete from u -- old rows\nwhere id between 1 and 5 and # flagged\nflag",
+			want:   "DELETE FROM u -- old rows\nWHERE\n  id BETWEEN 1 AND 5\n  AND # flagged\n  flag\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Format(tc.sql, tc.engine); got != tc.want {
+				t.Errorf("Format(%q)\n got: %q\nwant: %q", tc.sql, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFormatWithOptions(t *testing.T) {
+	got := FormatWith("SELECT a FROM t FOR UPDATE", databases.EnginePostgres, Options{KeywordCase: LowerCase, Indent: "    "})
+	want := "select\n    a\nfrom\n    t\nfor update\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	got = FormatWith("Select a From t", databases.EngineMySQL, Options{KeywordCase: PreserveCase, Indent: "\t"})
+	want = "Select\n\ta\nFrom\n\tt\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
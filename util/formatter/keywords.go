@@ -0,0 +1,47 @@
+package formatter
+
+var keywords = map[string]bool{}
+
+var typeNames = map[string]bool{}
+
+func init() {
+	for _, k := range []string{
+		"ADD", "ALL", "ALTER", "ANALYZE", "AND", "ANY", "ARRAY", "AS", "ASC", "ATOMIC", "BEGIN",
+		"BETWEEN", "BY", "CASCADE", "CASE", "CAST", "CHECK", "COLLATE", "COLUMN", "COMMIT",
+		"CONFLICT", "CONSTRAINT", "CREATE", "CROSS", "CURRENT_DATE", "CURRENT_TIME",
+		"CURRENT_TIMESTAMP", "DATABASE", "DEFAULT", "DELETE", "DELIMITER", "DESC", "DISTINCT",
+		"DO", "DROP", "DUPLICATE", "ELSE", "END", "ESCAPE", "EXCEPT", "EXISTS", "EXPLAIN",
+		"FALSE", "FETCH", "FILTER", "FIRST", "FOR", "FOREIGN", "FROM", "FULL", "FUNCTION",
+		"GRANT", "GROUP", "HAVING", "IF", "ILIKE", "IN", "INDEX", "INNER", "INSERT",
+		"INTERSECT", "INTERVAL", "INTO", "IS", "JOIN", "KEY", "LAST", "LATERAL", "LEFT",
+		"LIKE", "LIMIT", "LOCK", "LOCKED", "MODE", "NATURAL", "NO", "NOT", "NOTHING",
+		"NOWAIT", "NULL", "NULLS", "OF", "OFFSET", "ON", "ONLY", "OR", "ORDER", "OUTER",
+		"OVER", "PARTITION", "PRIMARY", "PROCEDURE", "RECURSIVE", "REFERENCES", "REPLACE",
+		"RETURNING", "RETURNS", "REVOKE", "RIGHT", "ROLLBACK", "ROW", "ROWS", "SAVEPOINT",
+		"SCHEMA", "SELECT", "SET", "SHARE", "SKIP", "SOME", "STRAIGHT_JOIN", "TABLE", "THEN", "TO", "TRANSACTION", "TRIGGER", "TRUE",
+		"TRUNCATE", "UNION", "UNIQUE", "UPDATE", "USING", "VALUES", "VIEW", "WHEN",
+		"WHERE", "WINDOW", "WITH", "WITHIN",
+	} {
+		keywords[k] = true
+	}
+
+	// type names are upper cased like keywords but read like function calls,
+	// so varchar(10) keeps its parenthesis attached
+	for _, t := range []string{
+		"BIGINT", "BINARY", "BIT", "BLOB", "BOOL", "BOOLEAN", "BYTEA", "CHAR", "CHARACTER",
+		"DATE", "DATETIME", "DECIMAL", "DOUBLE", "ENUM", "FLOAT", "INT", "INTEGER", "JSON",
+		"JSONB", "LONGTEXT", "MEDIUMINT", "NUMERIC", "REAL", "SERIAL", "SMALLINT", "TEXT",
+		"TIME", "TIMESTAMP", "TIMESTAMPTZ", "TINYINT", "UUID", "VARBINARY", "VARCHAR",
+	} {
+		keywords[t] = true
+		typeNames[t] = true
+	}
+}
+
+func isKeyword(word string) bool {
+	return keywords[word]
+}
+
+func isTypeName(word string) bool {
+	return typeNames[word]
+}
@@ -0,0 +1,130 @@
+package guardrails
+
+import (
+	"github.com/therealphatmike/squeal/util/statements"
+)
+
+// Risk explains why a statement needs confirming before it runs.
+type Risk struct {
+	Statement string
+	Reason    string
+}
+
+// verbs that can follow a WITH clause
+var dataVerbs = map[string]bool{
+	"SELECT": true,
+	"INSERT": true,
+	"UPDATE": true,
+	"DELETE": true,
+	"MERGE":  true,
+}
+
+// Check returns the risks of running the statement, or nothing if it is safe
+// to run without asking.
+func Check(src string, engine string) []Risk {
+	tokens := []statements.Token{}
+	for _, tok := range statements.Tokenize(src, engine) {
+		if tok.Kind != statements.Comment {
+			tokens = append(tokens, tok)
+		}
+	}
+
+	tokens = explained(tokens)
+	verb, at := mainVerb(tokens)
+	risk := func(reason string) []Risk {
+		return []Risk{{Statement: src, Reason: reason}}
+	}
+
+	switch verb {
+	case "UPDATE", "DELETE":
+		if !hasTopLevel(tokens[at:], "WHERE") {
+			return risk(verb + " without a WHERE clause changes every row")
+		}
+	case "DROP":
+		return risk("DROP removes objects and their data")
+	case "TRUNCATE":
+		return risk("TRUNCATE removes every row")
+	case "ALTER":
+		return risk("ALTER changes the schema")
+	}
+
+	return nil
+}
+
+// CheckAll collects the risks of every statement in a script.
+func CheckAll(srcs []string, engine string) []Risk {
+	risks := []Risk{}
+	for _, src := range srcs {
+		risks = append(risks, Check(src, engine)...)
+	}
+	return risks
+}
+
+// explained returns the statement an EXPLAIN or MariaDB's ANALYZE wraps,
+// past options such as ANALYZE, VERBOSE, (...) and FORMAT=JSON, since with
+// ANALYZE the statement really runs. Anything else is returned as it is.
+func explained(tokens []statements.Token) []statements.Token {
+	if len(tokens) == 0 {
+		return tokens
+	}
+	switch tokens[0].Upper() {
+	case "EXPLAIN", "ANALYZE", "ANALYSE":
+	default:
+		return tokens
+	}
+
+	depth := 0
+	for i, tok := range tokens[1:] {
+		switch {
+		case tok.Is(statements.Punctuation, "("):
+			depth++
+		case tok.Is(statements.Punctuation, ")"):
+			depth--
+		case tok.Kind == statements.Word && depth == 0 && (dataVerbs[tok.Upper()] || tok.Upper() == "WITH"):
+			return tokens[i+1:]
+		}
+	}
+
+	return tokens
+}
+
+// mainVerb finds the statement's command, looking past a leading WITH clause,
+// and returns it with its token index.
+func mainVerb(tokens []statements.Token) (string, int) {
+	depth := 0
+	for i, tok := range tokens {
+		switch {
+		case tok.Is(statements.Punctuation, "("):
+			depth++
+		case tok.Is(statements.Punctuation, ")"):
+			depth--
+		case tok.Kind == statements.Word && depth == 0:
+			if i == 0 && tok.Upper() != "WITH" {
+				return tok.Upper(), i
+			}
+			if i > 0 && dataVerbs[tok.Upper()] {
+				return tok.Upper(), i
+			}
+		}
+	}
+
+	return "", 0
+}
+
+// hasTopLevel reports whether the keyword appears outside any parentheses, so
+// a WHERE inside a subquery doesn't count.
+func hasTopLevel(tokens []statements.Token, keyword string) bool {
+	depth := 0
+	for _, tok := range tokens {
+		switch {
+		case tok.Is(statements.Punctuation, "("):
+			depth++
+		case tok.Is(statements.Punctuation, ")"):
+			depth--
+		case depth == 0 && tok.Is(statements.Word, keyword):
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,43 @@
+package guardrails
+
+import (
+	"testing"
+
+	"github.com/therealphatmike/squeal/util/databases"
+)
+
+func TestCheck(t *testing.T) {
+	cases := []struct {
+		name   string
+		engine string
+		sql    string
+		risky  bool
+	}{
+		{"delete without where", databases.EnginePostgres, "DELETE FROM orders", true},
+		{"delete with where", databases.EnginePostgres, "delete from orders where id = 1", false},
+		{"where only in a subquery", databases.EnginePostgres, "update t set a = (select max(a) from u where u.id = 1)", true},
+		{"where in a comment", databases.EngineMySQL, "delete from t -- where id = 1", true},
+		{"cte feeding a delete", databases.EnginePostgres, "with old as (select id from t where x) delete from t", true},
+		{"drop", databases.EngineMySQL, "DROP TABLE t", true},
+		{"truncate", databases.EngineMariaDB, "truncate t", true},
+		{"alter", databases.EnginePostgres, "/* add column */ alter table t add column c int", true},
+		{"select", databases.EnginePostgres, "select * from t", false},
+		{"insert", databases.EngineMySQL, "insert into t values (1)", false},
+		{"explain analyze delete", databases.EnginePostgres, "EXPLAIN ANALYZE DELETE FROM t", true},
+		{"explain with options", databases.EnginePostgres, "explain (analyze, buffers, format json) update t set a = 1", true},
+		{"explain verbose cte delete", databases.EnginePostgres, "explain analyze verbose with x as (select 1) delete from t", true},
+		{"mariadb analyze delete", databases.EngineMariaDB, "ANALYZE FORMAT=JSON DELETE FROM t", true},
+		{"explain a guarded delete", databases.EngineMySQL, "explain analyze delete from t where id = 1", false},
+		{"explain select", databases.EnginePostgres, "explain analyze select * from t", false},
+		{"analyze a table", databases.EnginePostgres, "analyze t", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			risks := Check(tc.sql, tc.engine)
+			if got := len(risks) > 0; got != tc.risky {
+				t.Errorf("Check(%q) = %v, want risky %v", tc.sql, risks, tc.risky)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sahilm/fuzzy"
+)
+
+// Entry is a single executed statement recorded against a connection.
+type Entry struct {
+	ConnectionName string        `json:"connectionName"`
+	Query          string        `json:"query"`
+	ExecutedAt     time.Time     `json:"executedAt"`
+	Duration       time.Duration `json:"duration"`
+	RowCount       int64         `json:"rowCount"`
+	Error          string        `json:"error,omitempty"`
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// maxEntries is how many statements are kept for a connection. Once a
+// recording goes past it the oldest tenth are dropped, so the file is only
+// rewritten now and then.
+var maxEntries = 10_000
+
+// lineCounts caches how many entries each history file holds, so recording
+// doesn't read the whole file to find out.
+var (
+	lineCounts   = map[string]int{}
+	lineCountsMu sync.Mutex
+)
+
+func historyDir() (string, error) {
+	userHome, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return userHome + "/.squeal/history", nil
+}
+
+// historyFile is named after the connection so it can be found by hand, with
+// a hash of the full name since different names can sanitise the same way.
+func historyFile(connectionName string) (string, error) {
+	dir, err := historyDir()
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(connectionName))
+	return dir + "/" + safeName(connectionName) + "-" + hex.EncodeToString(sum[:4]) + ".jsonl", nil
+}
+
+func safeName(connectionName string) string {
+	name := unsafeFileChars.ReplaceAllString(connectionName, "_")
+	if name == "" {
+		name = "_"
+	}
+	return name
+}
+
+func InitHistoryDir() error {
+	dir, err := historyDir()
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Record appends the entry to the history file for its connection, trimming
+// the oldest entries once there are more than maxEntries.
+func Record(entry Entry) error {
+	lineCountsMu.Lock()
+	defer lineCountsMu.Unlock()
+
+	if err := InitHistoryDir(); err != nil {
+		return err
+	}
+
+	file, err := historyFile(entry.ConnectionName)
+	if err != nil {
+		return err
+	}
+
+	if entry.ExecutedAt.IsZero() {
+		entry.ExecutedAt = time.Now()
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	count, ok := lineCounts[file]
+	if !ok {
+		if count, err = countLines(file); err != nil {
+			return err
+		}
+	}
+	if count < maxEntries {
+		if err := appendLine(file, line); err != nil {
+			return err
+		}
+		lineCounts[file] = count + 1
+		return nil
+	}
+
+	lines, err := readLines(file)
+	if err != nil {
+		return err
+	}
+	lines = append(lines, string(line))
+	lines = lines[max(len(lines)-maxEntries*9/10, 0):]
+	if err := rewrite(file, lines); err != nil {
+		delete(lineCounts, file)
+		return err
+	}
+	lineCounts[file] = len(lines)
+	return nil
+}
+
+// countLines counts the lines in a file without parsing them, or none when it
+// doesn't exist yet.
+func countLines(file string) (int, error) {
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	count := 0
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := f.Read(buf)
+		count += bytes.Count(buf[:n], []byte{'\n'})
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+func appendLine(file string, line []byte) error {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// rewrite replaces the file with the lines, through a temporary file so a
+// crash part way leaves the old history in place.
+func rewrite(file string, lines []string) error {
+	f, err := os.CreateTemp(filepath.Dir(file), ".history-*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.WriteString(strings.Join(lines, "\n") + "\n"); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return os.Rename(f.Name(), file)
+}
+
+func readLines(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lines := []string{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, scanner.Err()
+}
+
+// Read returns every recorded entry for the connection, newest first.
+func Read(connectionName string) ([]Entry, error) {
+	file, err := historyFile(connectionName)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := readLines(file)
+	if os.IsNotExist(err) {
+		return []Entry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []Entry{}
+	for _, line := range lines {
+		entry := Entry{}
+		// a half-written line from a crash shouldn't cost us the rest of the history
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].ExecutedAt.After(entries[j].ExecutedAt)
+	})
+
+	return entries, nil
+}
+
+type entrySource []Entry
+
+func (s entrySource) String(i int) string {
+	return s[i].Query
+}
+
+func (s entrySource) Len() int {
+	return len(s)
+}
+
+// Search fuzzy matches term against the recorded queries. Results are ordered
+// by match quality, with recency breaking ties. An empty term returns the
+// entries unchanged.
+func Search(entries []Entry, term string) []Entry {
+	if strings.TrimSpace(term) == "" {
+		return entries
+	}
+
+	matches := fuzzy.FindFrom(term, entrySource(entries))
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score == matches[j].Score {
+			return matches[i].Index < matches[j].Index
+		}
+		return matches[i].Score > matches[j].Score
+	})
+
+	results := make([]Entry, 0, len(matches))
+	for _, match := range matches {
+		results = append(results, entries[match.Index])
+	}
+
+	return results
+}
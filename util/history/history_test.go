@@ -0,0 +1,53 @@
+package history
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestConnectionsKeepTheirOwnHistory(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	for _, name := range []string{"prod db", "prod_db"} {
+		if err := Record(Entry{ConnectionName: name, Query: "SELECT '" + name + "'"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"prod db", "prod_db"} {
+		entries, err := Read(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].ConnectionName != name {
+			t.Errorf("Read(%q) = %v", name, entries)
+		}
+	}
+}
+
+func TestRecordTrims(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	defer func(kept int) { maxEntries = kept }(maxEntries)
+	maxEntries = 20
+
+	for i := 0; i < maxEntries+5; i++ {
+		if err := Record(Entry{ConnectionName: "dev", Query: fmt.Sprintf("SELECT %d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := Read("dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) > maxEntries || len(entries) < maxEntries*9/10 {
+		t.Fatalf("kept %d entries, want at most %d", len(entries), maxEntries)
+	}
+	queries := map[string]bool{}
+	for _, e := range entries {
+		queries[e.Query] = true
+	}
+	if queries["SELECT 0"] || !queries[fmt.Sprintf("SELECT %d", maxEntries+4)] {
+		t.Error("the oldest entries should be the ones dropped")
+	}
+}
@@ -0,0 +1,121 @@
+package layouts
+
+import (
+	"bytes"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/therealphatmike/squeal/util/files"
+	"github.com/therealphatmike/squeal/util/params"
+)
+
+// maxLayouts is how many queries' layouts are kept; the ones arranged
+// longest ago are dropped past it.
+var maxLayouts = 500
+
+// saving orders saves, which run in their own goroutines, so one can't read
+// the file while another is replacing it.
+var saving sync.Mutex
+
+// Layout is how a query's result columns were arranged in the grid. Columns
+// are referred to by name so the layout survives columns being added to or
+// dropped from the query.
+type Layout struct {
+	// Order is the column names in display order.
+	Order  []string `toml:"order"`
+	Hidden []string `toml:"hidden,omitempty"`
+	// Widths holds the columns that were resized; the rest fit their content.
+	Widths map[string]int `toml:"widths,omitempty"`
+	// Frozen is how many leading columns stay put when scrolling sideways.
+	Frozen int `toml:"frozen,omitempty"`
+	// SavedAt is when the layout was arranged, so a save that runs late can't
+	// overwrite a newer one.
+	SavedAt time.Time `toml:"saved_at,omitempty"`
+}
+
+type layoutsFile struct {
+	Queries map[string]Layout `toml:"queries"`
+}
+
+func layoutsFilePath() (string, error) {
+	userHome, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return userHome + "/.squeal/layouts.toml", nil
+}
+
+func readLayouts() (layoutsFile, error) {
+	layouts := layoutsFile{Queries: map[string]Layout{}}
+
+	file, err := layoutsFilePath()
+	if err != nil {
+		return layouts, err
+	}
+
+	if _, err := toml.DecodeFile(file, &layouts); err != nil && !os.IsNotExist(err) {
+		return layouts, err
+	}
+	if layouts.Queries == nil {
+		layouts.Queries = map[string]Layout{}
+	}
+
+	return layouts, nil
+}
+
+// Load returns the layout last used for the query's results. The zero Layout
+// is returned for queries that haven't been arranged.
+func Load(query string) (Layout, error) {
+	layouts, err := readLayouts()
+	if err != nil {
+		return Layout{}, err
+	}
+
+	return layouts.Queries[params.Fingerprint(query)], nil
+}
+
+// Save stores the layout for the query's results, unless a newer one has
+// been saved already, and drops the oldest layouts past maxLayouts.
+func Save(query string, layout Layout) error {
+	saving.Lock()
+	defer saving.Unlock()
+
+	layouts, err := readLayouts()
+	if err != nil {
+		return err
+	}
+	key := params.Fingerprint(query)
+	if layouts.Queries[key].SavedAt.After(layout.SavedAt) {
+		return nil
+	}
+	layouts.Queries[key] = layout
+
+	if len(layouts.Queries) > maxLayouts {
+		keys := []string{}
+		for k := range layouts.Queries {
+			keys = append(keys, k)
+		}
+		slices.SortFunc(keys, func(a, b string) int {
+			return layouts.Queries[a].SavedAt.Compare(layouts.Queries[b].SavedAt)
+		})
+		for _, k := range keys[:len(keys)-maxLayouts] {
+			delete(layouts.Queries, k)
+		}
+	}
+
+	file, err := layoutsFilePath()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(layouts); err != nil {
+		return err
+	}
+
+	return files.Replace(file, buf.Bytes())
+}
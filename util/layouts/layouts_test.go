@@ -0,0 +1,69 @@
+package layouts
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func withHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.Mkdir(home+"/.squeal", 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSaveKeepsTheNewest(t *testing.T) {
+	withHome(t)
+
+	now := time.Now()
+	newer := Layout{Order: []string{"b", "a"}, SavedAt: now}
+	older := Layout{Order: []string{"a", "b"}, SavedAt: now.Add(-time.Second)}
+	for _, layout := range []Layout{newer, older} {
+		if err := Save("SELECT a, b FROM t", layout); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := Load("SELECT a, b\n  FROM t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Order) != 2 || got.Order[0] != "b" {
+		t.Errorf("Load() = %v, want the newer layout", got.Order)
+	}
+}
+
+func TestConcurrentSaves(t *testing.T) {
+	withHome(t)
+	defer func(kept int) { maxLayouts = kept }(maxLayouts)
+	maxLayouts = 10
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			layout := Layout{Order: []string{"a"}, SavedAt: start.Add(time.Duration(i) * time.Millisecond)}
+			if err := Save(fmt.Sprintf("SELECT %d", i), layout); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	layouts, err := readLayouts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(layouts.Queries) != maxLayouts {
+		t.Errorf("%d layouts kept, want %d", len(layouts.Queries), maxLayouts)
+	}
+	if got, _ := Load("SELECT 29"); len(got.Order) == 0 {
+		t.Errorf("the newest layout was dropped")
+	}
+}
@@ -0,0 +1,169 @@
+package paging
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/therealphatmike/squeal/util/databases"
+	"github.com/therealphatmike/squeal/util/statements"
+)
+
+// Pager builds the queries for reading a table a page at a time. With a key,
+// usually the primary key, pages are found by seeking past the key of the
+// previous page's last row, which costs the same however deep the page is.
+// Without one, or when sorted by other columns, pages are counted off with
+// OFFSET, which gets slower the further in they are and isn't stable while
+// the table changes.
+type Pager struct {
+	Table  []string
+	Key    []string
+	Engine string
+	Size   int
+	// Where is a condition rows have to meet, written by the user, and
+	// Order the columns to sort by ahead of the key
+	Where string
+	Order []Order
+}
+
+// Order is a column to sort by.
+type Order struct {
+	Column     string `toml:"column"`
+	Descending bool   `toml:"descending,omitempty"`
+}
+
+// CheckWhere rejects a condition that would end the query early and run
+// something else after it.
+func CheckWhere(where string, engine string) error {
+	split := statements.Split("SELECT 1 WHERE "+where, engine)
+	if len(split) > 1 || len(split) == 1 && split[0].Delimiter != "" {
+		return errors.New("the condition can't contain ;")
+	}
+	return nil
+}
+
+// Fetch is a query for a page. Reversed pages are read backwards from where
+// they start and have to be turned around before showing.
+type Fetch struct {
+	databases.Exec
+	Reversed bool
+}
+
+// Keyset reports whether pages are found by key rather than by offset.
+func (p Pager) Keyset() bool {
+	return len(p.Key) > 0 && len(p.Order) == 0
+}
+
+// Base is the query for the whole table, in the order pages come in.
+func (p Pager) Base() string {
+	return p.query("", false)
+}
+
+// First is the query for the first page.
+func (p Pager) First() Fetch {
+	return p.fetch(p.query("", false), nil, false)
+}
+
+// After is the query for the page following the row with the given key.
+func (p Pager) After(key []any) Fetch {
+	exec := databases.Exec{}
+	condition := p.compare(">", key, &exec)
+	return p.fetch(p.query(condition, false), exec.Args, false)
+}
+
+// Before is the query for the page leading up to the row with the given key.
+func (p Pager) Before(key []any) Fetch {
+	exec := databases.Exec{}
+	condition := p.compare("<", key, &exec)
+	return p.fetch(p.query(condition, true), exec.Args, true)
+}
+
+// Last is the query for the last page, which may be short. Without a key it
+// can't be found, so it's the first page instead.
+func (p Pager) Last() Fetch {
+	if !p.Keyset() {
+		return p.First()
+	}
+	return p.fetch(p.query("", true), nil, true)
+}
+
+// Page is the query for the nth page, counting from 1, by offset since a key
+// can't say where a page starts without reading up to it.
+func (p Pager) Page(n int) Fetch {
+	f := p.fetch(p.query("", false), nil, false)
+	if n > 1 {
+		f.Query += fmt.Sprintf(" OFFSET %d", (n-1)*p.Size)
+	}
+	return f
+}
+
+func (p Pager) fetch(query string, args []any, reversed bool) Fetch {
+	return Fetch{
+		Exec:     databases.Exec{Query: fmt.Sprintf("%s LIMIT %d", query, p.Size), Args: args},
+		Reversed: reversed,
+	}
+}
+
+func (p Pager) query(condition string, descending bool) string {
+	query := "SELECT * FROM " + databases.QuoteName(p.Table, p.Engine)
+	conditions := []string{}
+	// the condition gets lines of its own so a -- comment ending it can't
+	// swallow the rest of the query
+	if where := strings.TrimSpace(p.Where); where != "" {
+		conditions = append(conditions, "(\n"+where+"\n)")
+	}
+	if condition != "" {
+		conditions = append(conditions, condition)
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// the key comes last so rows that sort the same keep their places
+	// between pages
+	sorts := append([]Order{}, p.Order...)
+	for _, column := range p.Key {
+		sorts = append(sorts, Order{Column: column})
+	}
+	if len(sorts) == 0 {
+		return query
+	}
+
+	order := []string{}
+	for _, sort := range sorts {
+		column := databases.QuoteIdentifier(sort.Column, p.Engine)
+		if sort.Descending != descending {
+			column += " DESC"
+		}
+		order = append(order, column)
+	}
+	return query + " ORDER BY " + strings.Join(order, ", ")
+}
+
+// compare is the condition for rows past the key one way or the other. Keys
+// over several columns are compared as a row, which both engines order
+// column by column.
+func (p Pager) compare(operator string, key []any, exec *databases.Exec) string {
+	columns, values := []string{}, []string{}
+	for i, column := range p.Key {
+		columns = append(columns, databases.QuoteIdentifier(column, p.Engine))
+		exec.Args = append(exec.Args, key[i])
+		if p.Engine == databases.EnginePostgres {
+			values = append(values, fmt.Sprintf("$%d", len(exec.Args)))
+		} else {
+			values = append(values, "?")
+		}
+	}
+	if len(columns) == 1 {
+		return columns[0] + " " + operator + " " + values[0]
+	}
+	return "(" + strings.Join(columns, ", ") + ") " + operator + " (" + strings.Join(values, ", ") + ")"
+}
+
+// Pages is how many pages the estimated number of rows makes, at least one.
+func (p Pager) Pages(rows int64) int {
+	if rows <= 0 || p.Size <= 0 {
+		return 1
+	}
+	return int((rows + int64(p.Size) - 1) / int64(p.Size))
+}
@@ -0,0 +1,129 @@
+package paging
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/therealphatmike/squeal/util/databases"
+)
+
+func TestPager(t *testing.T) {
+	keyed := Pager{Table: []string{"public", "events"}, Key: []string{"tenant", "id"}, Engine: databases.EnginePostgres, Size: 100}
+	single := Pager{Table: []string{"events"}, Key: []string{"id"}, Engine: databases.EngineMySQL, Size: 50}
+	unkeyed := Pager{Table: []string{"log"}, Engine: databases.EngineMySQL, Size: 50}
+	filtered := Pager{Table: []string{"events"}, Key: []string{"id"}, Engine: databases.EnginePostgres, Size: 10, Where: "kind = 'click' OR kind = 'view'"}
+	sorted := filtered
+	sorted.Order = []Order{{Column: "created at", Descending: true}}
+	commented := single
+	commented.Where = "id > 5 -- recent ones"
+
+	cases := []struct {
+		name     string
+		fetch    Fetch
+		query    string
+		args     []any
+		reversed bool
+	}{
+		{
+			"first",
+			keyed.First(),
+			`SELECT * FROM "public"."events" ORDER BY "tenant", "id" LIMIT 100`,
+			nil,
+			false,
+		},
+		{
+			"after a composite key",
+			keyed.After([]any{int64(3), int64(900)}),
+			`SELECT * FROM "public"."events" WHERE ("tenant", "id") > ($1, $2) ORDER BY "tenant", "id" LIMIT 100`,
+			[]any{int64(3), int64(900)},
+			false,
+		},
+		{
+			"before a composite key",
+			keyed.Before([]any{int64(3), int64(900)}),
+			`SELECT * FROM "public"."events" WHERE ("tenant", "id") < ($1, $2) ORDER BY "tenant" DESC, "id" DESC LIMIT 100`,
+			[]any{int64(3), int64(900)},
+			true,
+		},
+		{
+			"after a single key",
+			single.After([]any{int64(42)}),
+			"SELECT * FROM `events` WHERE `id` > ? ORDER BY `id` LIMIT 50",
+			[]any{int64(42)},
+			false,
+		},
+		{
+			"last",
+			single.Last(),
+			"SELECT * FROM `events` ORDER BY `id` DESC LIMIT 50",
+			nil,
+			true,
+		},
+		{
+			"jump",
+			single.Page(3),
+			"SELECT * FROM `events` ORDER BY `id` LIMIT 50 OFFSET 100",
+			nil,
+			false,
+		},
+		{
+			"filtered",
+			filtered.After([]any{int64(5)}),
+			"SELECT * FROM \"events\" WHERE (\nkind = 'click' OR kind = 'view'\n) AND \"id\" > $1 ORDER BY \"id\" LIMIT 10",
+			[]any{int64(5)},
+			false,
+		},
+		{
+			"sorted falls back to offset",
+			sorted.Page(2),
+			"SELECT * FROM \"events\" WHERE (\nkind = 'click' OR kind = 'view'\n) ORDER BY \"created at\" DESC, \"id\" LIMIT 10 OFFSET 10",
+			nil,
+			false,
+		},
+		{
+			"condition ending in a comment",
+			commented.After([]any{int64(9)}),
+			"SELECT * FROM `events` WHERE (\nid > 5 -- recent ones\n) AND `id` > ? ORDER BY `id` LIMIT 50",
+			[]any{int64(9)},
+			false,
+		},
+		{
+			"offset without a key",
+			unkeyed.Page(2),
+			"SELECT * FROM `log` LIMIT 50 OFFSET 50",
+			nil,
+			false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.fetch.Query != tc.query || !reflect.DeepEqual(tc.fetch.Args, tc.args) || tc.fetch.Reversed != tc.reversed {
+				t.Errorf("got %s %v reversed %v, want %s %v reversed %v", tc.fetch.Query, tc.fetch.Args, tc.fetch.Reversed, tc.query, tc.args, tc.reversed)
+			}
+		})
+	}
+}
+
+func TestPages(t *testing.T) {
+	p := Pager{Size: 100}
+	for rows, want := range map[int64]int{-1: 1, 0: 1, 1: 1, 100: 1, 101: 2, 500_000_000: 5_000_000} {
+		if got := p.Pages(rows); got != want {
+			t.Errorf("Pages(%d) = %d, want %d", rows, got, want)
+		}
+	}
+}
+
+func TestCheckWhere(t *testing.T) {
+	for where, ok := range map[string]bool{
+		"id > 5":                      true,
+		"note = 'a;b'":                true,
+		"id > 5; DROP TABLE events":   false,
+		"id > 5;":                     false,
+		"id > 5 -- trailing; comment": true,
+	} {
+		if err := CheckWhere(where, databases.EnginePostgres); (err == nil) != ok {
+			t.Errorf("CheckWhere(%q) = %v", where, err)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package params
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/therealphatmike/squeal/util/files"
+)
+
+// Type hints offered when prompting for a bind parameter.
+const (
+	TypeText      = "text"
+	TypeInteger   = "integer"
+	TypeNumeric   = "numeric"
+	TypeBoolean   = "boolean"
+	TypeTimestamp = "timestamp"
+	TypeNull      = "null"
+)
+
+var Types = []string{TypeText, TypeInteger, TypeNumeric, TypeBoolean, TypeTimestamp, TypeNull}
+
+// Value is what the user typed for a parameter along with its type hint.
+type Value struct {
+	Raw  string `toml:"raw"`
+	Type string `toml:"type"`
+}
+
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// Convert turns the raw text into the Go value handed to the driver.
+func Convert(v Value) (any, error) {
+	raw := strings.TrimSpace(v.Raw)
+	switch v.Type {
+	case TypeNull:
+		return nil, nil
+	case TypeInteger:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", v.Raw)
+		}
+		return n, nil
+	case TypeNumeric:
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return nil, fmt.Errorf("%q is not a number", v.Raw)
+		}
+		// sent as text so the server keeps the exact decimal
+		return raw, nil
+	case TypeBoolean:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not true or false", v.Raw)
+		}
+		return b, nil
+	case TypeTimestamp:
+		for _, layout := range timestampLayouts {
+			if t, err := time.Parse(layout, raw); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not a timestamp (try 2006-01-02 15:04:05)", v.Raw)
+	}
+
+	return v.Raw, nil
+}
+
+// GuessType picks a type hint for a value the user hasn't typed before.
+func GuessType(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if _, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return TypeInteger
+	}
+	if _, err := strconv.ParseFloat(raw, 64); err == nil {
+		return TypeNumeric
+	}
+	if raw == "true" || raw == "false" {
+		return TypeBoolean
+	}
+	return TypeText
+}
+
+// maxRemembered is how many queries' values are kept; the ones run longest
+// ago are forgotten past it.
+var maxRemembered = 500
+
+type rememberedFile struct {
+	Queries map[string]map[string]Value `toml:"queries"`
+	// Used is when each query's values were last remembered. Queries missing
+	// from it were remembered before it was kept and count as oldest.
+	Used map[string]time.Time `toml:"used,omitempty"`
+}
+
+func rememberedValuesFile() (string, error) {
+	userHome, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return userHome + "/.squeal/params.toml", nil
+}
+
+// Fingerprint identifies a query independent of whitespace so reformatting
+// it doesn't lose the remembered values.
+func Fingerprint(query string) string {
+	sum := sha256.Sum256([]byte(strings.Join(strings.Fields(query), " ")))
+	return hex.EncodeToString(sum[:])
+}
+
+func readRemembered() (rememberedFile, error) {
+	remembered := rememberedFile{Queries: map[string]map[string]Value{}}
+
+	file, err := rememberedValuesFile()
+	if err != nil {
+		return remembered, err
+	}
+
+	if _, err := toml.DecodeFile(file, &remembered); err != nil && !os.IsNotExist(err) {
+		return remembered, err
+	}
+	if remembered.Queries == nil {
+		remembered.Queries = map[string]map[string]Value{}
+	}
+	if remembered.Used == nil {
+		remembered.Used = map[string]time.Time{}
+	}
+
+	return remembered, nil
+}
+
+// LastValues returns the values last used for the query, keyed by
+// placeholder label.
+func LastValues(query string) (map[string]Value, error) {
+	remembered, err := readRemembered()
+	if err != nil {
+		return map[string]Value{}, err
+	}
+
+	values, ok := remembered.Queries[Fingerprint(query)]
+	if !ok {
+		return map[string]Value{}, nil
+	}
+
+	return values, nil
+}
+
+// RememberValues stores the values used for the query, forgetting the
+// oldest queries' past maxRemembered.
+func RememberValues(query string, values map[string]Value) error {
+	remembered, err := readRemembered()
+	if err != nil {
+		return err
+	}
+	key := Fingerprint(query)
+	remembered.Queries[key] = values
+	remembered.Used[key] = time.Now()
+
+	if len(remembered.Queries) > maxRemembered {
+		keys := []string{}
+		for k := range remembered.Queries {
+			keys = append(keys, k)
+		}
+		slices.SortFunc(keys, func(a, b string) int {
+			return remembered.Used[a].Compare(remembered.Used[b])
+		})
+		for _, k := range keys[:len(keys)-maxRemembered] {
+			delete(remembered.Queries, k)
+			delete(remembered.Used, k)
+		}
+	}
+
+	file, err := rememberedValuesFile()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(remembered); err != nil {
+		return err
+	}
+
+	return files.Replace(file, buf.Bytes())
+}
@@ -0,0 +1,62 @@
+package params
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestConvert(t *testing.T) {
+	cases := []struct {
+		value Value
+		want  any
+		ok    bool
+	}{
+		{Value{Raw: " 42 ", Type: TypeInteger}, int64(42), true},
+		{Value{Raw: "4.2", Type: TypeInteger}, nil, false},
+		{Value{Raw: "10.50", Type: TypeNumeric}, "10.50", true},
+		{Value{Raw: "true", Type: TypeBoolean}, true, true},
+		{Value{Raw: "2024-03-01", Type: TypeTimestamp}, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), true},
+		{Value{Raw: "anything", Type: TypeNull}, nil, true},
+		{Value{Raw: "x", Type: TypeText}, "x", true},
+	}
+
+	for _, tc := range cases {
+		got, err := Convert(tc.value)
+		if (err == nil) != tc.ok || got != tc.want {
+			t.Errorf("Convert(%v) = %v, %v, want %v", tc.value, got, err, tc.want)
+		}
+	}
+}
+
+func TestRememberValuesForgetsTheOldest(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.Mkdir(home+"/.squeal", 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer func(kept int) { maxRemembered = kept }(maxRemembered)
+	maxRemembered = 3
+
+	for i := 0; i < 5; i++ {
+		query := fmt.Sprintf("SELECT * FROM t WHERE id = $1 -- %d", i)
+		if err := RememberValues(query, map[string]Value{"$1": {Raw: fmt.Sprint(i), Type: TypeInteger}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	remembered, err := readRemembered()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remembered.Queries) != maxRemembered {
+		t.Errorf("%d queries remembered, want %d", len(remembered.Queries), maxRemembered)
+	}
+	if values, _ := LastValues("SELECT * FROM t WHERE id = $1 -- 0"); len(values) != 0 {
+		t.Errorf("the oldest query's values were kept")
+	}
+	if values, _ := LastValues("SELECT * FROM t WHERE id = $1 -- 4"); values["$1"].Raw != "4" {
+		t.Errorf("LastValues() = %v, want the newest query's values", values)
+	}
+}
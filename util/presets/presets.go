@@ -0,0 +1,118 @@
+package presets
+
+import (
+	"bytes"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/therealphatmike/squeal/util/files"
+	"github.com/therealphatmike/squeal/util/paging"
+)
+
+// Preset is a filter and sort saved under a name for browsing a table, so
+// the views of it that get looked at often are a keypress away.
+type Preset struct {
+	Name  string         `toml:"name"`
+	Where string         `toml:"where,omitempty"`
+	Order []paging.Order `toml:"order,omitempty"`
+}
+
+type presetsFile struct {
+	// Tables is keyed by connection and then table name, since the same
+	// table name means different tables on different servers.
+	Tables map[string]map[string][]Preset `toml:"tables"`
+}
+
+func presetsFilePath() (string, error) {
+	userHome, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return userHome + "/.squeal/presets.toml", nil
+}
+
+func readPresets() (presetsFile, error) {
+	presets := presetsFile{Tables: map[string]map[string][]Preset{}}
+
+	file, err := presetsFilePath()
+	if err != nil {
+		return presets, err
+	}
+
+	if _, err := toml.DecodeFile(file, &presets); err != nil && !os.IsNotExist(err) {
+		return presets, err
+	}
+	if presets.Tables == nil {
+		presets.Tables = map[string]map[string][]Preset{}
+	}
+
+	return presets, nil
+}
+
+func writePresets(presets presetsFile) error {
+	file, err := presetsFilePath()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(presets); err != nil {
+		return err
+	}
+
+	return files.Replace(file, buf.Bytes())
+}
+
+// Load returns the presets saved for a table on a connection, in the order
+// they were saved.
+func Load(connection string, table []string) ([]Preset, error) {
+	presets, err := readPresets()
+	if err != nil {
+		return nil, err
+	}
+
+	return presets.Tables[connection][strings.Join(table, ".")], nil
+}
+
+// Save stores a preset for a table, replacing any with the same name.
+func Save(connection string, table []string, preset Preset) error {
+	presets, err := readPresets()
+	if err != nil {
+		return err
+	}
+
+	tables := presets.Tables[connection]
+	if tables == nil {
+		tables = map[string][]Preset{}
+		presets.Tables[connection] = tables
+	}
+	name := strings.Join(table, ".")
+	saved := slices.DeleteFunc(tables[name], func(p Preset) bool { return p.Name == preset.Name })
+	tables[name] = append(saved, preset)
+
+	return writePresets(presets)
+}
+
+// Delete removes the named preset for a table.
+func Delete(connection string, table []string, name string) error {
+	presets, err := readPresets()
+	if err != nil {
+		return err
+	}
+
+	// the file may have changed since the list was read
+	tables := presets.Tables[connection]
+	key := strings.Join(table, ".")
+	if len(tables[key]) == 0 {
+		return nil
+	}
+	tables[key] = slices.DeleteFunc(tables[key], func(p Preset) bool { return p.Name == name })
+	if len(tables[key]) == 0 {
+		delete(tables, key)
+	}
+
+	return writePresets(presets)
+}
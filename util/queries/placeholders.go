@@ -0,0 +1,45 @@
+package queries
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Placeholder is a snippet variable written as {{name}} or {{name:default}}.
+type Placeholder struct {
+	Name    string
+	Default string
+}
+
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*(?::([^}]*))?\}\}`)
+
+// Placeholders returns the distinct placeholders in the snippet in the order
+// they first appear.
+func Placeholders(snippet string) []Placeholder {
+	seen := map[string]bool{}
+	placeholders := []Placeholder{}
+	for _, match := range placeholderPattern.FindAllStringSubmatch(snippet, -1) {
+		if seen[match[1]] {
+			continue
+		}
+		seen[match[1]] = true
+		placeholders = append(placeholders, Placeholder{
+			Name:    match[1],
+			Default: strings.TrimSpace(match[2]),
+		})
+	}
+
+	return placeholders
+}
+
+// Expand replaces each placeholder with its value, falling back to the
+// placeholder's default when no value was given.
+func Expand(snippet string, values map[string]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(snippet, func(raw string) string {
+		match := placeholderPattern.FindStringSubmatch(raw)
+		if value, ok := values[match[1]]; ok && value != "" {
+			return value
+		}
+		return strings.TrimSpace(match[2])
+	})
+}
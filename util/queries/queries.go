@@ -0,0 +1,219 @@
+package queries
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// SavedQuery is a named query that is either global (no ConnectionName) or
+// scoped to a single connection.
+type SavedQuery struct {
+	Name           string   `toml:"name"`
+	Description    string   `toml:"description"`
+	Tags           []string `toml:"tags"`
+	ConnectionName string   `toml:"connectionName"`
+	Query          string   `toml:"query"`
+}
+
+type SavedQueryFile struct {
+	Queries []SavedQuery
+}
+
+func (q SavedQuery) IsGlobal() bool {
+	return q.ConnectionName == ""
+}
+
+func (q SavedQuery) HasTag(tag string) bool {
+	for _, t := range q.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func savedQueriesFile() (string, error) {
+	userHome, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return userHome + "/.squeal/queries.toml", nil
+}
+
+func InitSavedQueriesFile() error {
+	file, err := savedQueriesFile()
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		if err := os.WriteFile(file, []byte(""), 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ReadSavedQueries() ([]SavedQuery, error) {
+	file, err := savedQueriesFile()
+	if err != nil {
+		return nil, err
+	}
+
+	queryFile := SavedQueryFile{}
+	if _, err := toml.DecodeFile(file, &queryFile); err != nil {
+		if os.IsNotExist(err) {
+			return []SavedQuery{}, nil
+		}
+		return nil, err
+	}
+
+	return queryFile.Queries, nil
+}
+
+func writeSavedQueries(queries []SavedQuery) error {
+	file, err := savedQueriesFile()
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(queries, func(i, j int) bool {
+		return strings.ToLower(queries[i].Name) < strings.ToLower(queries[j].Name)
+	})
+
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	if err := toml.NewEncoder(f).Encode(SavedQueryFile{Queries: queries}); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// SaveQuery adds the query, replacing any existing query with the same name
+// and scope.
+func SaveQuery(query SavedQuery) error {
+	if strings.TrimSpace(query.Name) == "" {
+		return errors.New("saved queries need a name")
+	}
+
+	existing, err := ReadSavedQueries()
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for i, q := range existing {
+		if sameQuery(q, query) {
+			existing[i] = query
+			replaced = true
+		}
+	}
+	if !replaced {
+		existing = append(existing, query)
+	}
+
+	return writeSavedQueries(existing)
+}
+
+// SaveQueryText stores text under name. A saved query of that name visible
+// from the connection keeps its description, tags and scope; otherwise a new
+// one is scoped to the connection.
+func SaveQueryText(name string, connectionName string, text string) error {
+	existing, err := ReadSavedQueries()
+	if err != nil {
+		return err
+	}
+
+	query, found := Lookup(existing, name, connectionName)
+	if !found {
+		query = SavedQuery{Name: name, ConnectionName: connectionName}
+	}
+	query.Query = text
+
+	return SaveQuery(query)
+}
+
+// Lookup finds the saved query called name that the connection sees. A
+// connection's own query wins over a global one of the same name.
+func Lookup(queries []SavedQuery, name string, connectionName string) (SavedQuery, bool) {
+	query := SavedQuery{}
+	found := false
+	for _, q := range ForConnection(queries, connectionName) {
+		if strings.EqualFold(q.Name, name) && (!found || !q.IsGlobal()) {
+			query = q
+			found = true
+		}
+	}
+
+	return query, found
+}
+
+func DeleteSavedQuery(query SavedQuery) error {
+	existing, err := ReadSavedQueries()
+	if err != nil {
+		return err
+	}
+
+	kept := []SavedQuery{}
+	for _, q := range existing {
+		if !sameQuery(q, query) {
+			kept = append(kept, q)
+		}
+	}
+
+	return writeSavedQueries(kept)
+}
+
+// ForConnection returns the global queries plus those scoped to the connection.
+func ForConnection(queries []SavedQuery, connectionName string) []SavedQuery {
+	available := []SavedQuery{}
+	for _, q := range queries {
+		if q.IsGlobal() || q.ConnectionName == connectionName {
+			available = append(available, q)
+		}
+	}
+
+	return available
+}
+
+// Filter matches term against name, description and tags. A term of the form
+// "#tag" only matches tags.
+func Filter(queries []SavedQuery, term string) []SavedQuery {
+	term = strings.ToLower(strings.TrimSpace(term))
+	if term == "" {
+		return queries
+	}
+
+	matched := []SavedQuery{}
+	for _, q := range queries {
+		if tag, ok := strings.CutPrefix(term, "#"); ok {
+			if q.HasTag(tag) {
+				matched = append(matched, q)
+			}
+			continue
+		}
+
+		if strings.Contains(strings.ToLower(q.Name), term) ||
+			strings.Contains(strings.ToLower(q.Description), term) ||
+			q.HasTag(term) {
+			matched = append(matched, q)
+		}
+	}
+
+	return matched
+}
+
+func sameQuery(a SavedQuery, b SavedQuery) bool {
+	return strings.EqualFold(a.Name, b.Name) && a.ConnectionName == b.ConnectionName
+}
@@ -0,0 +1,201 @@
+package queries
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func useHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".squeal"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	useHome(t)
+
+	saved := []SavedQuery{
+		{Name: "Long running", Description: "Anything over five minutes", Tags: []string{"diagnostics", "postgres"}, ConnectionName: "production", Query: "SELECT pid\nFROM pg_stat_activity\n-- still running\nWHERE state = 'active'"},
+		{Name: "long running", Query: "SELECT 1"},
+		{Name: "Report: {{month}}", Tags: []string{"reports"}, Query: "SELECT * FROM sales WHERE month = '{{month:2024-01}}'"},
+	}
+
+	dir := t.TempDir()
+	written, err := ExportSQLDir(saved, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, path := range written {
+		names = append(names, filepath.Base(path))
+	}
+	want := []string{"long_running.sql", "long_running_2.sql", "report_month.sql"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("ExportSQLDir() wrote %v, want %v", names, want)
+	}
+
+	if _, err := ImportSQLDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadSavedQueries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the global and the connection's "long running" are different queries
+	if len(read) != len(saved) {
+		t.Fatalf("read %d queries, want %d: %+v", len(read), len(saved), read)
+	}
+	for _, s := range saved {
+		found := false
+		for _, r := range read {
+			if sameQuery(r, s) {
+				found = true
+				if !reflect.DeepEqual(r, s) {
+					t.Errorf("imported %+v, want %+v", r, s)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("%q (%q) wasn't imported", s.Name, s.ConnectionName)
+		}
+	}
+}
+
+func TestImportWithoutHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "active users.sql")
+	if err := os.WriteFile(path, []byte("-- who's on\nSELECT * FROM users\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	query, err := ImportSQLFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := SavedQuery{Name: "active users", Query: "-- who's on\nSELECT * FROM users"}
+	if !reflect.DeepEqual(query, want) {
+		t.Errorf("ImportSQLFile() = %+v, want %+v", query, want)
+	}
+}
+
+func TestSaveQueryText(t *testing.T) {
+	useHome(t)
+
+	global := SavedQuery{Name: "Sizes", Description: "Table sizes", Tags: []string{"admin"}, Query: "old"}
+	if err := SaveQuery(global); err != nil {
+		t.Fatal(err)
+	}
+
+	// saving over a global query keeps it global, with its description and tags
+	if err := SaveQueryText("sizes", "production", "new"); err != nil {
+		t.Fatal(err)
+	}
+	// a name nothing uses yet is scoped to the connection
+	if err := SaveQueryText("Locks", "production", "locks"); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := ReadSavedQueries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SavedQuery{
+		{Name: "Locks", ConnectionName: "production", Query: "locks"},
+		{Name: "Sizes", Description: "Table sizes", Tags: []string{"admin"}, Query: "new"},
+	}
+	if !reflect.DeepEqual(read, want) {
+		t.Errorf("ReadSavedQueries() = %+v, want %+v", read, want)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	snippet := "SELECT * FROM {{ table }} WHERE created > '{{since:2024-01-01}}' AND kind = '{{kind: a b }}' LIMIT {{limit}} -- {{table}}"
+
+	placeholders := Placeholders(snippet)
+	want := []Placeholder{{Name: "table"}, {Name: "since", Default: "2024-01-01"}, {Name: "kind", Default: "a b"}, {Name: "limit"}}
+	if !reflect.DeepEqual(placeholders, want) {
+		t.Errorf("Placeholders() = %+v, want %+v", placeholders, want)
+	}
+
+	cases := []struct {
+		name   string
+		values map[string]string
+		want   string
+	}{
+		{
+			"values",
+			map[string]string{"table": "orders", "since": "2023-06-01", "kind": "x", "limit": "10"},
+			"SELECT * FROM orders WHERE created > '2023-06-01' AND kind = 'x' LIMIT 10 -- orders",
+		},
+		{
+			"defaults",
+			map[string]string{"table": "orders", "since": ""},
+			"SELECT * FROM orders WHERE created > '2024-01-01' AND kind = 'a b' LIMIT  -- orders",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Expand(snippet, tc.values); got != tc.want {
+				t.Errorf("Expand() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	if got := Expand("SELECT '{{not closed' , '{{1st}}'", nil); got != "SELECT '{{not closed' , '{{1st}}'" {
+		t.Errorf("Expand() changed text that isn't a placeholder: %q", got)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	all := []SavedQuery{
+		{Name: "Locks", Description: "Blocked sessions", Tags: []string{"Diagnostics"}},
+		{Name: "Sizes", ConnectionName: "production", Tags: []string{"admin"}},
+		{Name: "Vacuum", ConnectionName: "staging", Description: "Dead tuples by table", Tags: []string{"admin", "diagnostics"}},
+	}
+
+	names := func(queries []SavedQuery) []string {
+		names := []string{}
+		for _, q := range queries {
+			names = append(names, q.Name)
+		}
+		return names
+	}
+
+	production := ForConnection(all, "production")
+	if got, want := names(production), []string{"Locks", "Sizes"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ForConnection(production) = %v, want %v", got, want)
+	}
+	if got, want := names(ForConnection(all, "")), []string{"Locks"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ForConnection(\"\") = %v, want %v", got, want)
+	}
+
+	cases := []struct {
+		term string
+		want []string
+	}{
+		{"", []string{"Locks", "Sizes", "Vacuum"}},
+		{"  ", []string{"Locks", "Sizes", "Vacuum"}},
+		{"#diagnostics", []string{"Locks", "Vacuum"}},
+		{"#DIAG", []string{}},
+		{"admin", []string{"Sizes", "Vacuum"}},
+		{"TABLE", []string{"Vacuum"}},
+		{"blocked", []string{"Locks"}},
+		{"#locks", []string{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.term, func(t *testing.T) {
+			if got := names(Filter(all, tc.term)); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Filter(%q) = %v, want %v", tc.term, got, tc.want)
+			}
+		})
+	}
+
+	if got, want := names(Filter(production, "#admin")), []string{"Sizes"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Filter(production, #admin) = %v, want %v", got, want)
+	}
+}
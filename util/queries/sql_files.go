@@ -0,0 +1,162 @@
+package queries
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Saved queries are exchanged as plain .sql files. Metadata lives in a
+// leading comment header so the files still run as-is in any other client:
+//
+//	-- name: Long running queries
+//	-- description: Anything running longer than five minutes
+//	-- tags: diagnostics, postgres
+//	-- connection: production
+//	SELECT ...
+
+var fileNameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+func ExportSQLFile(query SavedQuery, path string) error {
+	content := strings.Builder{}
+	content.WriteString("-- name: " + query.Name + "\n")
+	if query.Description != "" {
+		content.WriteString("-- description: " + query.Description + "\n")
+	}
+	if len(query.Tags) > 0 {
+		content.WriteString("-- tags: " + strings.Join(query.Tags, ", ") + "\n")
+	}
+	if query.ConnectionName != "" {
+		content.WriteString("-- connection: " + query.ConnectionName + "\n")
+	}
+	content.WriteString(strings.TrimRight(query.Query, "\n") + "\n")
+
+	return os.WriteFile(path, []byte(content.String()), 0644)
+}
+
+// ExportSQLDir writes each query to its own file in dir and returns the paths
+// written.
+func ExportSQLDir(queries []SavedQuery, dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	written := []string{}
+	used := map[string]bool{}
+	for _, q := range queries {
+		base := strings.Trim(fileNameChars.ReplaceAllString(strings.ToLower(q.Name), "_"), "_")
+		if base == "" {
+			base = "query"
+		}
+		// a numbered name can be taken by a query that's really called that
+		name := base
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s_%d", base, n)
+		}
+		used[name] = true
+
+		path := filepath.Join(dir, name+".sql")
+		if err := ExportSQLFile(q, path); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+
+	return written, nil
+}
+
+// ImportSQLFile reads a query written by ExportSQLFile. Files without a header
+// are named after the file.
+func ImportSQLFile(path string) (SavedQuery, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return SavedQuery{}, err
+	}
+	defer f.Close()
+
+	query := SavedQuery{}
+	body := []string{}
+	inHeader := true
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if inHeader {
+			if key, value, ok := headerField(line); ok {
+				switch key {
+				case "name":
+					query.Name = value
+				case "description":
+					query.Description = value
+				case "tags":
+					for _, tag := range strings.Split(value, ",") {
+						if tag = strings.TrimSpace(tag); tag != "" {
+							query.Tags = append(query.Tags, tag)
+						}
+					}
+				case "connection":
+					query.ConnectionName = value
+				}
+				continue
+			}
+			inHeader = false
+		}
+		body = append(body, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return SavedQuery{}, err
+	}
+
+	if query.Name == "" {
+		query.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	query.Query = strings.TrimSpace(strings.Join(body, "\n"))
+
+	return query, nil
+}
+
+// ImportSQLDir reads every .sql file in dir and saves it, returning the
+// imported queries.
+func ImportSQLDir(dir string) ([]SavedQuery, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+
+	imported := []SavedQuery{}
+	for _, path := range paths {
+		query, err := ImportSQLFile(path)
+		if err != nil {
+			return imported, err
+		}
+		if err := SaveQuery(query); err != nil {
+			return imported, err
+		}
+		imported = append(imported, query)
+	}
+
+	return imported, nil
+}
+
+func headerField(line string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(line), "--")
+	if !ok {
+		return "", "", false
+	}
+
+	key, value, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", "", false
+	}
+
+	key = strings.ToLower(strings.TrimSpace(key))
+	switch key {
+	case "name", "description", "tags", "connection":
+		return key, strings.TrimSpace(value), true
+	}
+
+	return "", "", false
+}
@@ -0,0 +1,142 @@
+package resultset
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Op is how a filter tests a column's values.
+type Op string
+
+const (
+	OpContains     Op = "contains"
+	OpEquals       Op = "="
+	OpNotEquals    Op = "!="
+	OpLess         Op = "<"
+	OpLessEqual    Op = "<="
+	OpGreater      Op = ">"
+	OpGreaterEqual Op = ">="
+	OpBetween      Op = "between"
+	OpMatches      Op = "matches"
+)
+
+// Filter keeps the rows whose value in Column passes the test.
+type Filter struct {
+	Column int
+	// Expr is what was typed, for editing the filter later.
+	Expr  string
+	Op    Op
+	Value string
+	// Upper is the top of the range for OpBetween; Value is the bottom.
+	// Either can be empty to leave that end open.
+	Upper string
+
+	pattern *regexp.Regexp
+	value   key
+	upper   key
+}
+
+// ParseFilter reads a quick filter typed for a column:
+//
+//	text       contains text, ignoring case
+//	=v  !=v    equals, or doesn't equal, v; =NULL matches NULLs
+//	<v  <=v    less than v, or less than or equal
+//	>v  >=v    greater than v, or greater than or equal
+//	a..b       between a and b inclusive; either end can be left off
+//	/re/       matches the regular expression
+func ParseFilter(column int, expr string) (Filter, error) {
+	expr = strings.TrimSpace(expr)
+	f := Filter{Column: column, Expr: expr, Op: OpContains, Value: expr}
+
+	switch {
+	case expr == "":
+		return f, errors.New("the filter is empty")
+	case len(expr) >= 2 && strings.HasPrefix(expr, "/") && strings.HasSuffix(expr, "/"):
+		pattern, err := regexp.Compile(expr[1 : len(expr)-1])
+		if err != nil {
+			return f, fmt.Errorf("bad regular expression: %w", err)
+		}
+		f.Op, f.Value, f.pattern = OpMatches, expr[1:len(expr)-1], pattern
+	case strings.Contains(expr, ".."):
+		lower, upper, _ := strings.Cut(expr, "..")
+		f.Op, f.Value, f.Upper = OpBetween, strings.TrimSpace(lower), strings.TrimSpace(upper)
+		if f.Value == "" && f.Upper == "" {
+			return f, errors.New("a range needs at least one end")
+		}
+	default:
+		// longest operators first so <= isn't read as <
+		for _, op := range []Op{OpNotEquals, OpLessEqual, OpGreaterEqual, OpEquals, OpLess, OpGreater} {
+			if strings.HasPrefix(expr, string(op)) {
+				f.Op, f.Value = op, strings.TrimSpace(strings.TrimPrefix(expr, string(op)))
+				break
+			}
+		}
+	}
+
+	f.value, f.upper = textKey(f.Value), textKey(f.Upper)
+	return f, nil
+}
+
+// compareOperand compares a value with something typed into a filter. The
+// typed text is read the same way as values, so 10 compares numerically with
+// numbers, but when the kinds don't agree both are compared as text.
+func compareOperand(value key, operand key) int {
+	if value.kind == operand.kind {
+		return compareKeys(value, operand)
+	}
+	return strings.Compare(value.text, operand.text)
+}
+
+// Match reports whether a value passes the filter. Text is the value as it's
+// displayed, which contains and regular expression filters search.
+func (f Filter) Match(value any, text string) bool {
+	v := keyOf(value)
+	if v.null {
+		isNull := strings.EqualFold(f.Value, "null")
+		return (f.Op == OpEquals && isNull) || (f.Op == OpNotEquals && !isNull)
+	}
+
+	switch f.Op {
+	case OpContains:
+		return strings.Contains(strings.ToLower(text), strings.ToLower(f.Value))
+	case OpMatches:
+		return f.pattern.MatchString(text)
+	case OpEquals:
+		return compareOperand(v, f.value) == 0
+	case OpNotEquals:
+		return strings.EqualFold(f.Value, "null") || compareOperand(v, f.value) != 0
+	case OpLess:
+		return compareOperand(v, f.value) < 0
+	case OpLessEqual:
+		return compareOperand(v, f.value) <= 0
+	case OpGreater:
+		return compareOperand(v, f.value) > 0
+	case OpGreaterEqual:
+		return compareOperand(v, f.value) >= 0
+	case OpBetween:
+		return (f.Value == "" || compareOperand(v, f.value) >= 0) &&
+			(f.Upper == "" || compareOperand(v, f.upper) <= 0)
+	}
+	return false
+}
+
+func (f Filter) String() string {
+	switch f.Op {
+	case OpContains:
+		return fmt.Sprintf("contains %q", f.Value)
+	case OpMatches:
+		return "matches /" + f.Value + "/"
+	case OpBetween:
+		return fmt.Sprintf("between %s and %s", orAny(f.Value), orAny(f.Upper))
+	}
+	return string(f.Op) + " " + f.Value
+}
+
+func orAny(end string) string {
+	if end == "" {
+		return "any"
+	}
+	return end
+}
@@ -0,0 +1,113 @@
+package resultset
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSort(t *testing.T) {
+	rows := [][]any{
+		{[]byte("10")},
+		{nil},
+		{[]byte("9")},
+		{[]byte("-2.5")},
+		{nil},
+		{[]byte("100")},
+	}
+
+	cases := []struct {
+		name       string
+		descending bool
+		nullsFirst bool
+		want       []int
+	}{
+		{"ascending, nulls last", false, false, []int{3, 2, 0, 5, 1, 4}},
+		{"ascending, nulls first", false, true, []int{1, 4, 3, 2, 0, 5}},
+		{"descending, nulls last", true, false, []int{5, 0, 2, 3, 1, 4}},
+		{"descending, nulls first", true, true, []int{1, 4, 5, 0, 2, 3}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			indexes := []int{0, 1, 2, 3, 4, 5}
+			Sort(rows, indexes, 0, tc.descending, tc.nullsFirst)
+			if !slices.Equal(indexes, tc.want) {
+				t.Errorf("Sort() = %v, want %v", indexes, tc.want)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		a, b any
+		want int
+	}{
+		{int64(2), int64(10), -1},
+		{"2", "10", -1},
+		{[]byte("2024-03-01"), []byte("2024-02-28 23:00"), 1},
+		{day, day.Add(time.Hour), -1},
+		{"apple", "banana", -1},
+		{nil, int64(0), -1},
+		{false, true, -1},
+		{"inf", "nan", -1},
+	}
+
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("%v vs %v", tc.a, tc.b), func(t *testing.T) {
+			if got := Compare(tc.a, tc.b); got != tc.want {
+				t.Errorf("Compare(%v, %v) = %d, want %d", tc.a, tc.b, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	cases := []struct {
+		expr  string
+		value any
+		match bool
+	}{
+		{"ORD", "order-17", true},
+		{"ord", nil, false},
+		{"=10", []byte("10.0"), true},
+		{"=abc", int64(10), false},
+		{"=null", nil, true},
+		{"!=null", int64(1), true},
+		{"!=null", nil, false},
+		{"!=3", int64(4), true},
+		{">9", []byte("10"), true},
+		{"<=2024-01-31", time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), true},
+		{"<=2024-01-31", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), false},
+		{"5..10", int64(10), true},
+		{"5..10", 10.5, false},
+		{"..10", int64(-3), true},
+		{"b..", "cherry", true},
+		{"/^ord-\\d+$/", "ord-12", true},
+		{"/^ord-\\d+$/", "order-12", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.expr, func(t *testing.T) {
+			f, err := ParseFilter(0, tc.expr)
+			if err != nil {
+				t.Fatalf("ParseFilter(%q) error: %v", tc.expr, err)
+			}
+			if got := f.Match(tc.value, fmt.Sprint(tc.value)); got != tc.match {
+				t.Errorf("%s Match(%v) = %v, want %v", f, tc.value, got, tc.match)
+			}
+		})
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	for _, expr := range []string{"", "  ", "..", "/(/"} {
+		if _, err := ParseFilter(0, expr); err == nil {
+			t.Errorf("ParseFilter(%q) should fail", expr)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package resultset
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/therealphatmike/squeal/util/params"
+)
+
+type kind int
+
+// Kinds in the order they sort when a column mixes them.
+const (
+	kindBool kind = iota
+	kindNumber
+	kindTime
+	kindText
+)
+
+// key is a value read once for comparing, so sorting doesn't parse the same
+// text over and over. Drivers hand numbers and timestamps back as text often
+// enough (MySQL does for everything) that text is checked for both.
+type key struct {
+	null   bool
+	kind   kind
+	number float64
+	time   time.Time
+	text   string
+}
+
+func keyOf(value any) key {
+	switch v := value.(type) {
+	case nil:
+		return key{null: true}
+	case bool:
+		number := 0.0
+		if v {
+			number = 1
+		}
+		return key{kind: kindBool, number: number, text: strconv.FormatBool(v)}
+	case int64:
+		return key{kind: kindNumber, number: float64(v), text: strconv.FormatInt(v, 10)}
+	case float64:
+		return key{kind: kindNumber, number: v, text: strconv.FormatFloat(v, 'g', -1, 64)}
+	case time.Time:
+		return key{kind: kindTime, time: v, text: v.String()}
+	case []byte:
+		return textKey(string(v))
+	case string:
+		return textKey(v)
+	}
+	return textKey(fmt.Sprint(value))
+}
+
+func textKey(text string) key {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" || strings.IndexByte("+-.0123456789", trimmed[0]) < 0 {
+		// ParseFloat also takes words like inf and nan
+		return key{kind: kindText, text: text}
+	}
+	if n, err := strconv.ParseFloat(trimmed, 64); err == nil {
+		return key{kind: kindNumber, number: n, text: text}
+	}
+	if len(trimmed) >= len("2006-01-02") {
+		if t, err := params.Convert(params.Value{Raw: trimmed, Type: params.TypeTimestamp}); err == nil {
+			return key{kind: kindTime, time: t.(time.Time), text: text}
+		}
+	}
+	return key{kind: kindText, text: text}
+}
+
+func compareKeys(a key, b key) int {
+	if a.kind != b.kind {
+		return cmp.Compare(a.kind, b.kind)
+	}
+	switch a.kind {
+	case kindBool, kindNumber:
+		return cmp.Compare(a.number, b.number)
+	case kindTime:
+		return a.time.Compare(b.time)
+	}
+	return strings.Compare(a.text, b.text)
+}
+
+// Compare orders two values from the same column: numbers numerically,
+// timestamps chronologically and everything else as text. NULL sorts first.
+func Compare(a any, b any) int {
+	x, y := keyOf(a), keyOf(b)
+	switch {
+	case x.null && y.null:
+		return 0
+	case x.null:
+		return -1
+	case y.null:
+		return 1
+	}
+	return compareKeys(x, y)
+}
+
+// Sort orders indexes into rows by the values in a column. NULLs go first or
+// last whichever the direction; rows that compare equal keep their order.
+func Sort(rows [][]any, indexes []int, column int, descending bool, nullsFirst bool) {
+	keys := make([]key, len(rows))
+	for _, i := range indexes {
+		keys[i] = keyOf(rows[i][column])
+	}
+
+	slices.SortStableFunc(indexes, func(a int, b int) int {
+		x, y := keys[a], keys[b]
+		if x.null || y.null {
+			switch {
+			case x.null == y.null:
+				return 0
+			case x.null == nullsFirst:
+				return -1
+			}
+			return 1
+		}
+
+		order := compareKeys(x, y)
+		if descending {
+			return -order
+		}
+		return order
+	})
+}
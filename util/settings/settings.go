@@ -0,0 +1,76 @@
+package settings
+
+import (
+	"os"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Keymap values for Settings.Keymap.
+const (
+	KeymapDefault = "default"
+	KeymapVim     = "vim"
+)
+
+type Settings struct {
+	// Editor overrides $VISUAL and $EDITOR when opening queries externally.
+	Editor string `toml:"editor"`
+	// Keymap is "vim" for modal editing in the query editor and hjkl in
+	// results, or "default".
+	Keymap string `toml:"keymap"`
+	// ResultBufferRows caps how many rows of a result are held in memory;
+	// older rows are released as more are fetched.
+	ResultBufferRows int `toml:"resultBufferRows"`
+	// ThousandsSeparator groups the digits of numbers in results, or is empty
+	// to leave them ungrouped.
+	ThousandsSeparator string `toml:"thousandsSeparator"`
+	// TimeZone is the IANA zone, or "Local", that timestamps with a time zone
+	// are shown in. Empty shows them as the server sent them.
+	TimeZone string `toml:"timeZone"`
+	// BrowsePageSize is how many rows a page holds when browsing a table.
+	BrowsePageSize int `toml:"browsePageSize"`
+}
+
+func Defaults() Settings {
+	return Settings{Keymap: KeymapDefault, ResultBufferRows: 20000, ThousandsSeparator: ",", BrowsePageSize: 500}
+}
+
+func settingsFile() (string, error) {
+	userHome, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return userHome + "/.squeal/settings.toml", nil
+}
+
+func InitSettingsFile() error {
+	file, err := settingsFile()
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		if err := os.WriteFile(file, []byte(""), 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadSettings returns the user's settings layered over the defaults.
+func ReadSettings() (Settings, error) {
+	s := Defaults()
+
+	file, err := settingsFile()
+	if err != nil {
+		return s, err
+	}
+
+	if _, err := toml.DecodeFile(file, &s); err != nil && !os.IsNotExist(err) {
+		return Defaults(), err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,63 @@
+package statements
+
+// Keyword returns the upper cased first keyword of the statement, skipping
+// comments and opening parentheses.
+func Keyword(src string, engine string) string {
+	for _, tok := range Tokenize(src, engine) {
+		switch {
+		case tok.Kind == Comment, tok.Is(Punctuation, "("):
+			continue
+		case tok.Kind == Word:
+			return tok.Upper()
+		}
+		return ""
+	}
+
+	return ""
+}
+
+// ReturnsRows reports whether the statement produces a result set rather than
+// just an affected row count.
+func ReturnsRows(src string, engine string) bool {
+	switch Keyword(src, engine) {
+	case "SELECT", "WITH", "SHOW", "EXPLAIN", "DESCRIBE", "DESC", "VALUES", "TABLE", "CALL", "FETCH":
+		return true
+	}
+
+	for _, tok := range Tokenize(src, engine) {
+		if tok.Is(Word, "RETURNING") {
+			return true
+		}
+	}
+
+	return false
+}
+
+type TransactionEffect int
+
+const (
+	NoTransactionEffect TransactionEffect = iota
+	OpensTransaction
+	ClosesTransaction
+)
+
+// Transaction reports whether the statement starts or ends a transaction, so
+// transactions the user controls by hand are tracked too. Rolling back to a
+// savepoint keeps the transaction open.
+func Transaction(src string, engine string) TransactionEffect {
+	switch Keyword(src, engine) {
+	case "BEGIN", "START":
+		return OpensTransaction
+	case "COMMIT", "END", "ABORT":
+		return ClosesTransaction
+	case "ROLLBACK":
+		for _, tok := range Tokenize(src, engine) {
+			if tok.Is(Word, "TO") {
+				return NoTransactionEffect
+			}
+		}
+		return ClosesTransaction
+	}
+
+	return NoTransactionEffect
+}
@@ -0,0 +1,33 @@
+package statements
+
+import (
+	"testing"
+
+	"github.com/therealphatmike/squeal/util/databases"
+)
+
+func TestTransaction(t *testing.T) {
+	cases := []struct {
+		name   string
+		engine string
+		sql    string
+		want   TransactionEffect
+	}{
+		{"postgres begin", databases.EnginePostgres, "BEGIN", OpensTransaction},
+		{"mysql start transaction", databases.EngineMySQL, "start transaction read only", OpensTransaction},
+		{"commit after a comment", databases.EnginePostgres, "-- done\ncommit", ClosesTransaction},
+		{"postgres end", databases.EnginePostgres, "END", ClosesTransaction},
+		{"rollback", databases.EngineMariaDB, "ROLLBACK WORK", ClosesTransaction},
+		{"rollback to savepoint", databases.EnginePostgres, "rollback to savepoint fix_1", NoTransactionEffect},
+		{"mysql rollback work to", databases.EngineMySQL, "ROLLBACK WORK TO fix_1", NoTransactionEffect},
+		{"plain statement", databases.EngineMySQL, "update t set a = 1", NoTransactionEffect},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Transaction(tc.sql, tc.engine); got != tc.want {
+				t.Errorf("Transaction(%q) = %v, want %v", tc.sql, got, tc.want)
+			}
+		})
+	}
+}
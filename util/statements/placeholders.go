@@ -0,0 +1,213 @@
+package statements
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/therealphatmike/squeal/util/databases"
+)
+
+type PlaceholderKind int
+
+const (
+	// Named placeholders are written :name and may repeat.
+	Named PlaceholderKind = iota
+	// Positional placeholders are PostgreSQL's $1, $2, ...
+	Positional
+	// Anonymous placeholders are MySQL's ?, numbered by position.
+	Anonymous
+)
+
+// Placeholder is a bind parameter found in a statement. Name is the name of a
+// :name parameter, the number of a $n parameter, or the 1-based position of a
+// ? parameter.
+type Placeholder struct {
+	Name  string
+	Kind  PlaceholderKind
+	Start int
+	End   int
+}
+
+func (p Placeholder) Label() string {
+	switch p.Kind {
+	case Positional:
+		return "$" + p.Name
+	case Anonymous:
+		return "?" + p.Name
+	}
+	return ":" + p.Name
+}
+
+// maxPositional is the highest $n PostgreSQL accepts, as many parameters as
+// its protocol can carry.
+const maxPositional = 65535
+
+// Placeholders returns every bind parameter in the statement, skipping
+// strings, quoted identifiers, comments and PostgreSQL :: casts. A $n that
+// PostgreSQL couldn't bind, $0 or past maxPositional, is an error.
+func Placeholders(src string, engine string) ([]Placeholder, error) {
+	s := &splitter{src: src, d: dialectFor(engine), engine: engine, delim: ";"}
+	found := []Placeholder{}
+	anonymous := 0
+
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		switch {
+		case c == '\'':
+			s.skipString('\'', s.d.backslashEscapes || s.escapeStringPrefix())
+		case c == '"':
+			s.skipString('"', s.d.doubleQuoteString && s.d.backslashEscapes)
+		case c == '`' && s.d.backticks:
+			s.skipString('`', false)
+		case c == '-' && s.lineCommentStart():
+			s.skipLine()
+		case c == '#' && s.d.hashComments:
+			s.skipLine()
+		case c == '/' && s.peek(1) == '*':
+			s.skipBlockComment()
+		case c == '$' && s.d.dollarQuotes && s.dollarQuote():
+		case c == '$' && engine == databases.EnginePostgres && isDigit(s.peek(1)):
+			start := s.pos
+			s.pos++
+			for s.pos < len(s.src) && isDigit(s.src[s.pos]) {
+				s.pos++
+			}
+			name := s.src[start+1 : s.pos]
+			if n, err := strconv.Atoi(name); err != nil || n < 1 || n > maxPositional {
+				return nil, fmt.Errorf("$%s isn't a parameter number, they run from $1 to $%d", name, maxPositional)
+			}
+			found = append(found, Placeholder{Name: name, Kind: Positional, Start: start, End: s.pos})
+		case c == ':' && s.peek(1) == ':':
+			s.pos += 2
+		case c == ':' && isIdentStart(s.peek(1)) && (s.pos == 0 || !isIdentChar(s.src[s.pos-1])):
+			start := s.pos
+			s.pos++
+			for s.pos < len(s.src) && isIdentChar(s.src[s.pos]) && s.src[s.pos] != '$' {
+				s.pos++
+			}
+			found = append(found, Placeholder{Name: s.src[start+1 : s.pos], Kind: Named, Start: start, End: s.pos})
+		case c == '?' && engine != databases.EnginePostgres:
+			// ? is a jsonb operator in postgres
+			anonymous++
+			found = append(found, Placeholder{Name: strconv.Itoa(anonymous), Kind: Anonymous, Start: s.pos, End: s.pos + 1})
+			s.pos++
+		case isIdentStart(c):
+			s.readWord()
+		default:
+			s.pos++
+		}
+	}
+
+	return found, nil
+}
+
+// UniquePlaceholders returns the distinct parameters in the order a user
+// should be asked for them.
+func UniquePlaceholders(placeholders []Placeholder) []Placeholder {
+	seen := map[string]bool{}
+	unique := []Placeholder{}
+	for _, p := range placeholders {
+		if seen[p.Label()] {
+			continue
+		}
+		seen[p.Label()] = true
+		unique = append(unique, p)
+	}
+
+	return unique
+}
+
+// Bind rewrites the statement into the engine's native bind syntax and
+// returns the driver arguments in order. values is keyed by Placeholder.Label.
+func Bind(src string, engine string, values map[string]any) (string, []any, error) {
+	placeholders, err := Placeholders(src, engine)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(placeholders) == 0 {
+		return src, nil, nil
+	}
+
+	for _, p := range placeholders {
+		if _, ok := values[p.Label()]; !ok {
+			return "", nil, fmt.Errorf("no value given for %s", p.Label())
+		}
+	}
+
+	if engine == databases.EnginePostgres {
+		return bindNumbered(src, placeholders, values)
+	}
+
+	return bindAnonymous(src, placeholders, values)
+}
+
+func bindNumbered(src string, placeholders []Placeholder, values map[string]any) (string, []any, error) {
+	highest := 0
+	for _, p := range placeholders {
+		if p.Kind == Positional {
+			n, _ := strconv.Atoi(p.Name)
+			highest = max(highest, n)
+		}
+	}
+
+	numbers := map[string]int{}
+	args := make([]any, highest)
+	for _, p := range placeholders {
+		if p.Kind == Positional {
+			n, _ := strconv.Atoi(p.Name)
+			numbers[p.Label()] = n
+			args[n-1] = values[p.Label()]
+		}
+	}
+	for _, p := range placeholders {
+		if _, ok := numbers[p.Label()]; !ok {
+			args = append(args, values[p.Label()])
+			numbers[p.Label()] = len(args)
+		}
+	}
+	for i, arg := range args {
+		if arg == nil && !hasNumber(numbers, i+1) {
+			return "", nil, fmt.Errorf("$%d is never used in the query", i+1)
+		}
+	}
+
+	return rewrite(src, placeholders, func(p Placeholder) string {
+		return "$" + strconv.Itoa(numbers[p.Label()])
+	}), args, nil
+}
+
+func bindAnonymous(src string, placeholders []Placeholder, values map[string]any) (string, []any, error) {
+	args := []any{}
+	for _, p := range placeholders {
+		args = append(args, values[p.Label()])
+	}
+
+	return rewrite(src, placeholders, func(Placeholder) string { return "?" }), args, nil
+}
+
+func rewrite(src string, placeholders []Placeholder, replacement func(Placeholder) string) string {
+	out := strings.Builder{}
+	last := 0
+	for _, p := range placeholders {
+		out.WriteString(src[last:p.Start])
+		out.WriteString(replacement(p))
+		last = p.End
+	}
+	out.WriteString(src[last:])
+
+	return out.String()
+}
+
+func hasNumber(numbers map[string]int, n int) bool {
+	for _, used := range numbers {
+		if used == n {
+			return true
+		}
+	}
+	return false
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,115 @@
+package statements
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/therealphatmike/squeal/util/databases"
+)
+
+func TestPlaceholders(t *testing.T) {
+	cases := []struct {
+		name   string
+		engine string
+		sql    string
+		want   []string
+	}{
+		{
+			name:   "postgres named and positional",
+			engine: databases.EnginePostgres,
+			sql:    "select * from t where a = :id and b = $2 and c = :id",
+			want:   []string{":id", "$2", ":id"},
+		},
+		{
+			name:   "postgres skips casts, strings, comments and jsonb ?",
+			engine: databases.EnginePostgres,
+			sql:    "select ':x', $$ :y $$, a::int, doc ? 'k' -- :z\nfrom t where a = :w",
+			want:   []string{":w"},
+		},
+		{
+			name:   "mysql anonymous and named",
+			engine: databases.EngineMySQL,
+			sql:    "select * from t where a = ? and b = :name and c = ? and d = '?'",
+			want:   []string{"?1", ":name", "?2"},
+		},
+		{
+			name:   "mysql has no positional placeholders",
+			engine: databases.EngineMariaDB,
+			sql:    "select $1, `:x` from t # :y",
+			want:   []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := []string{}
+			placeholders, err := Placeholders(tc.sql, tc.engine)
+			if err != nil {
+				t.Fatalf("Placeholders(%q) returned %v", tc.sql, err)
+			}
+			for _, p := range placeholders {
+				got = append(got, p.Label())
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Placeholders(%q)\n got: %q\nwant: %q", tc.sql, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBind(t *testing.T) {
+	cases := []struct {
+		name     string
+		engine   string
+		sql      string
+		values   map[string]any
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:     "postgres named become numbered",
+			engine:   databases.EnginePostgres,
+			sql:      "select :a, :b, :a",
+			values:   map[string]any{":a": 1, ":b": "x"},
+			wantSQL:  "select $1, $2, $1",
+			wantArgs: []any{1, "x"},
+		},
+		{
+			name:     "postgres named follow positional",
+			engine:   databases.EnginePostgres,
+			sql:      "select $1, :a",
+			values:   map[string]any{"$1": true, ":a": nil},
+			wantSQL:  "select $1, $2",
+			wantArgs: []any{true, nil},
+		},
+		{
+			name:     "mysql repeats values per occurrence",
+			engine:   databases.EngineMySQL,
+			sql:      "select :a, ?, :a",
+			values:   map[string]any{":a": 1, "?1": 2},
+			wantSQL:  "select ?, ?, ?",
+			wantArgs: []any{1, 2, 1},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sql, args, err := Bind(tc.sql, tc.engine, tc.values)
+			if err != nil {
+				t.Fatalf("Bind returned %v", err)
+			}
+			if sql != tc.wantSQL || !reflect.DeepEqual(args, tc.wantArgs) {
+				t.Errorf("Bind(%q) = %q %v; want %q %v", tc.sql, sql, args, tc.wantSQL, tc.wantArgs)
+			}
+		})
+	}
+
+	if _, _, err := Bind("select :a", databases.EnginePostgres, map[string]any{}); err == nil {
+		t.Errorf("Bind without a value should fail")
+	}
+	for _, sql := range []string{"select $0", "select $99999999999", "select $65536"} {
+		if _, _, err := Bind(sql, databases.EnginePostgres, map[string]any{}); err == nil {
+			t.Errorf("Bind(%q) should fail", sql)
+		}
+	}
+}
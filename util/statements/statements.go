@@ -0,0 +1,403 @@
+package statements
+
+import (
+	"strings"
+
+	"github.com/therealphatmike/squeal/util/databases"
+)
+
+// Statement is one statement of a script. Start and End are byte offsets into
+// the source; End includes the delimiter when there was one.
+type Statement struct {
+	Text      string
+	Start     int
+	End       int
+	Delimiter string
+}
+
+type dialect struct {
+	dollarQuotes      bool
+	nestedComments    bool
+	backslashEscapes  bool
+	hashComments      bool
+	backticks         bool
+	doubleQuoteString bool
+	delimiterCommand  bool
+	strictDashComment bool
+}
+
+func dialectFor(engine string) dialect {
+	switch engine {
+	case databases.EnginePostgres:
+		return dialect{
+			dollarQuotes:   true,
+			nestedComments: true,
+		}
+	case databases.EngineMySQL, databases.EngineMariaDB:
+		return dialect{
+			backslashEscapes:  true,
+			hashComments:      true,
+			backticks:         true,
+			doubleQuoteString: true,
+			delimiterCommand:  true,
+			strictDashComment: true,
+		}
+	}
+
+	return dialect{}
+}
+
+type splitter struct {
+	src     string
+	d       dialect
+	engine  string
+	pos     int
+	delim   string
+	results []Statement
+
+	// state for the statement currently being read
+	start   int
+	hasCode bool
+	head    []string
+	depth   int
+}
+
+// Split breaks a script into statements using the quoting, comment and
+// delimiter rules of the given engine. Statements made up only of comments
+// and whitespace are dropped.
+func Split(src string, engine string) []Statement {
+	s := &splitter{
+		src:    src,
+		d:      dialectFor(engine),
+		engine: engine,
+		delim:  ";",
+	}
+	s.run()
+
+	return s.results
+}
+
+// AtCursor returns the statement the cursor offset sits in. A cursor in the
+// whitespace after a statement belongs to that statement.
+func AtCursor(src string, engine string, offset int) (Statement, bool) {
+	found := Statement{}
+	ok := false
+	for _, stmt := range Split(src, engine) {
+		if stmt.Start > offset && ok {
+			break
+		}
+		found = stmt
+		ok = true
+	}
+
+	return found, ok
+}
+
+func (s *splitter) run() {
+	s.reset(0)
+	for s.pos < len(s.src) {
+		if !s.hasCode && s.d.delimiterCommand && s.delimiterDirective() {
+			continue
+		}
+
+		// like the mysql client, a custom delimiter always ends the statement
+		if (s.depth == 0 || s.delim != ";") && strings.HasPrefix(s.src[s.pos:], s.delim) {
+			s.pos += len(s.delim)
+			s.emit(s.pos, s.delim)
+			s.reset(s.pos)
+			continue
+		}
+
+		c := s.src[s.pos]
+		switch {
+		case c == '\'':
+			s.code()
+			s.skipString('\'', s.d.backslashEscapes || s.escapeStringPrefix())
+		case c == '"':
+			s.code()
+			s.skipString('"', s.d.doubleQuoteString && s.d.backslashEscapes)
+		case c == '`' && s.d.backticks:
+			s.code()
+			s.skipString('`', false)
+		case c == '-' && s.lineCommentStart():
+			s.skipLine()
+		case c == '#' && s.d.hashComments:
+			s.skipLine()
+		case c == '/' && s.peek(1) == '*':
+			s.skipBlockComment()
+		case c == '$' && s.d.dollarQuotes && s.dollarQuote():
+			s.code()
+		case isIdentStart(c):
+			s.code()
+			s.word()
+		case isSpace(c):
+			s.pos++
+		default:
+			s.code()
+			s.pos++
+		}
+	}
+
+	s.emit(len(s.src), "")
+}
+
+func (s *splitter) reset(at int) {
+	s.start = at
+	s.hasCode = false
+	s.head = nil
+	s.depth = 0
+}
+
+func (s *splitter) code() {
+	s.hasCode = true
+}
+
+func (s *splitter) emit(end int, delimiter string) {
+	if !s.hasCode {
+		return
+	}
+
+	body := s.src[s.start:end]
+	if delimiter != "" {
+		body = body[:len(body)-len(delimiter)]
+	}
+
+	leading := len(body) - len(strings.TrimLeft(body, " \t\r\n"))
+	s.results = append(s.results, Statement{
+		Text:      strings.TrimSpace(body),
+		Start:     s.start + leading,
+		End:       end,
+		Delimiter: delimiter,
+	})
+}
+
+func (s *splitter) peek(ahead int) byte {
+	if s.pos+ahead >= len(s.src) {
+		return 0
+	}
+	return s.src[s.pos+ahead]
+}
+
+// delimiterDirective handles the mysql client's DELIMITER command, which is
+// only recognised at the start of a statement and runs to the end of the line.
+func (s *splitter) delimiterDirective() bool {
+	rest := s.src[s.pos:]
+	trimmed := strings.TrimLeft(rest, " \t\r\n")
+	skipped := len(rest) - len(trimmed)
+
+	if len(trimmed) < len("DELIMITER") || !strings.EqualFold(trimmed[:len("DELIMITER")], "DELIMITER") {
+		return false
+	}
+	after := trimmed[len("DELIMITER"):]
+	if after == "" || (after[0] != ' ' && after[0] != '\t') {
+		return false
+	}
+
+	line, _, _ := strings.Cut(after, "\n")
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false
+	}
+
+	s.delim = fields[0]
+	s.pos += skipped + len("DELIMITER") + len(line)
+	if s.pos < len(s.src) {
+		s.pos++
+	}
+	s.reset(s.pos)
+
+	return true
+}
+
+func (s *splitter) escapeStringPrefix() bool {
+	if s.pos == 0 || (s.src[s.pos-1] != 'E' && s.src[s.pos-1] != 'e') {
+		return false
+	}
+	return s.pos < 2 || !isIdentChar(s.src[s.pos-2])
+}
+
+func (s *splitter) skipString(quote byte, backslash bool) {
+	s.pos++
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		switch {
+		case backslash && c == '\\':
+			s.pos += 2
+		case c == quote && s.peek(1) == quote:
+			s.pos += 2
+		case c == quote:
+			s.pos++
+			return
+		default:
+			s.pos++
+		}
+	}
+}
+
+func (s *splitter) lineCommentStart() bool {
+	if s.peek(1) != '-' {
+		return false
+	}
+	if !s.d.strictDashComment {
+		return true
+	}
+
+	// mysql needs whitespace (or the end of input) after the dashes
+	next := s.peek(2)
+	return next == 0 || isSpace(next)
+}
+
+func (s *splitter) skipLine() {
+	end := strings.IndexByte(s.src[s.pos:], '\n')
+	if end < 0 {
+		s.pos = len(s.src)
+		return
+	}
+	s.pos += end + 1
+}
+
+func (s *splitter) skipBlockComment() {
+	level := 0
+	for s.pos < len(s.src) {
+		switch {
+		case s.src[s.pos] == '/' && s.peek(1) == '*' && (level == 0 || s.d.nestedComments):
+			level++
+			s.pos += 2
+		case s.src[s.pos] == '*' && s.peek(1) == '/':
+			level--
+			s.pos += 2
+			if level == 0 {
+				return
+			}
+		default:
+			s.pos++
+		}
+	}
+}
+
+// dollarQuote skips a $tag$ ... $tag$ string when one starts at pos. $1 style
+// parameters and identifiers containing $ are left alone.
+func (s *splitter) dollarQuote() bool {
+	if s.pos > 0 && isIdentChar(s.src[s.pos-1]) {
+		return false
+	}
+
+	end := s.pos + 1
+	for end < len(s.src) && s.src[end] != '$' {
+		c := s.src[end]
+		if !isIdentChar(c) || (end == s.pos+1 && c >= '0' && c <= '9') {
+			return false
+		}
+		end++
+	}
+	if end >= len(s.src) {
+		return false
+	}
+
+	tag := s.src[s.pos : end+1]
+	closing := strings.Index(s.src[end+1:], tag)
+	if closing < 0 {
+		s.pos = len(s.src)
+	} else {
+		s.pos = end + 1 + closing + len(tag)
+	}
+
+	return true
+}
+
+func (s *splitter) readWord() string {
+	start := s.pos
+	for s.pos < len(s.src) && isIdentChar(s.src[s.pos]) {
+		if s.pos > start && strings.HasPrefix(s.src[s.pos:], s.delim) {
+			break
+		}
+		s.pos++
+	}
+	return strings.ToUpper(s.src[start:s.pos])
+}
+
+func (s *splitter) peekWord() string {
+	i := s.pos
+	for i < len(s.src) && isSpace(s.src[i]) {
+		i++
+	}
+	start := i
+	for i < len(s.src) && isIdentChar(s.src[i]) {
+		i++
+	}
+	return strings.ToUpper(s.src[start:i])
+}
+
+// word tracks BEGIN ... END nesting so delimiters inside routine bodies don't
+// end the statement.
+func (s *splitter) word() {
+	w := s.readWord()
+	if len(s.head) < 6 {
+		s.head = append(s.head, w)
+	}
+
+	switch w {
+	case "BEGIN":
+		if s.opensBlock() {
+			s.depth++
+		}
+	case "CASE":
+		if s.depth > 0 {
+			s.depth++
+		}
+	case "END":
+		if s.depth == 0 {
+			return
+		}
+		switch s.peekWord() {
+		case "IF", "LOOP", "WHILE", "REPEAT":
+			s.readWordAfterSpace()
+		case "CASE":
+			s.readWordAfterSpace()
+			s.depth--
+		default:
+			s.depth--
+		}
+	}
+}
+
+func (s *splitter) readWordAfterSpace() {
+	for s.pos < len(s.src) && isSpace(s.src[s.pos]) {
+		s.pos++
+	}
+	s.readWord()
+}
+
+func (s *splitter) opensBlock() bool {
+	if s.engine == databases.EnginePostgres {
+		return s.peekWord() == "ATOMIC"
+	}
+
+	if s.depth > 0 || s.peekWord() == "NOT" {
+		// BEGIN NOT ATOMIC is a mariadb anonymous block
+		return true
+	}
+	if len(s.head) == 0 || s.head[0] != "CREATE" {
+		return false
+	}
+	for _, w := range s.head[1:] {
+		switch w {
+		case "PROCEDURE", "FUNCTION", "TRIGGER", "EVENT":
+			return true
+		}
+	}
+
+	return false
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9') || c == '$'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
@@ -0,0 +1,254 @@
+package statements
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/therealphatmike/squeal/util/databases"
+)
+
+type splitCase struct {
+	name string
+	sql  string
+	want []string
+}
+
+func runSplitCases(t *testing.T, engine string, cases []splitCase) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := []string{}
+			for _, stmt := range Split(tc.sql, engine) {
+				got = append(got, stmt.Text)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Split(%q, %q)\n got: %q\nwant: %q", tc.sql, engine, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSplitPostgres(t *testing.T) {
+	runSplitCases(t, databases.EnginePostgres, []splitCase{
+		{
+			name: "simple statements",
+			sql:  "select 1; select 2;",
+			want: []string{"select 1", "select 2"},
+		},
+		{
+			name: "missing trailing delimiter",
+			sql:  "select 1;\nselect 2",
+			want: []string{"select 1", "select 2"},
+		},
+		{
+			name: "empty statements and comments are dropped",
+			sql:  ";;\n-- just a comment\n;select 1;\n/* trailing */",
+			want: []string{"select 1"},
+		},
+		{
+			name: "semicolon in string",
+			sql:  "select 'a;b'; select 2",
+			want: []string{"select 'a;b'", "select 2"},
+		},
+		{
+			name: "doubled quote escape",
+			sql:  "select 'it''s; fine'; select 2",
+			want: []string{"select 'it''s; fine'", "select 2"},
+		},
+		{
+			name: "backslash is literal in standard strings",
+			sql:  `select 'C:\'; select 2`,
+			want: []string{`select 'C:\'`, "select 2"},
+		},
+		{
+			name: "backslash escapes in E strings",
+			sql:  `select E'it\'s; fine'; select 2`,
+			want: []string{`select E'it\'s; fine'`, "select 2"},
+		},
+		{
+			name: "quoted identifier",
+			sql:  `select 1 as "a;b"; select 2`,
+			want: []string{`select 1 as "a;b"`, "select 2"},
+		},
+		{
+			name: "line comment",
+			sql:  "select 1 -- not here;\n; select 2",
+			want: []string{"select 1 -- not here;", "select 2"},
+		},
+		{
+			name: "nested block comments",
+			sql:  "select /* outer /* inner; */ still; */ 1; select 2",
+			want: []string{"select /* outer /* inner; */ still; */ 1", "select 2"},
+		},
+		{
+			name: "anonymous dollar quote",
+			sql:  "create function f() returns int as $$ begin return 1; end; $$ language plpgsql; select f();",
+			want: []string{
+				"create function f() returns int as $$ begin return 1; end; $$ language plpgsql",
+				"select f()",
+			},
+		},
+		{
+			name: "tagged dollar quote containing $$",
+			sql:  "do $body$ begin perform '$$;'; end $body$; select 1",
+			want: []string{"do $body$ begin perform '$$;'; end $body$", "select 1"},
+		},
+		{
+			name: "positional parameters are not dollar quotes",
+			sql:  "select $1; select $2",
+			want: []string{"select $1", "select $2"},
+		},
+		{
+			name: "begin atomic body",
+			sql:  "create function f() returns int language sql begin atomic select case when true then 1 end; select 2; end; select 3;",
+			want: []string{
+				"create function f() returns int language sql begin atomic select case when true then 1 end; select 2; end",
+				"select 3",
+			},
+		},
+		{
+			name: "transaction begin splits normally",
+			sql:  "begin; update t set a = 1; commit;",
+			want: []string{"begin", "update t set a = 1", "commit"},
+		},
+		{
+			name: "delimiter is not a postgres command",
+			sql:  "DELIMITER //\nselect 1;",
+			want: []string{"DELIMITER //\nselect 1"},
+		},
+	})
+}
+
+func mysqlCases() []splitCase {
+	return []splitCase{
+		{
+			name: "simple statements",
+			sql:  "select 1; select 2;",
+			want: []string{"select 1", "select 2"},
+		},
+		{
+			name: "backslash escapes",
+			sql:  `select 'it\'s; fine'; select "a\";b"; select 3`,
+			want: []string{`select 'it\'s; fine'`, `select "a\";b"`, "select 3"},
+		},
+		{
+			name: "backtick identifiers",
+			sql:  "select 1 as `a;b`; select 2",
+			want: []string{"select 1 as `a;b`", "select 2"},
+		},
+		{
+			name: "hash comments",
+			sql:  "select 1 # not here;\n; select 2",
+			want: []string{"select 1 # not here;", "select 2"},
+		},
+		{
+			name: "double dash needs whitespace",
+			sql:  "select 1--1; select 2 -- comment;\n;",
+			want: []string{"select 1--1", "select 2 -- comment;"},
+		},
+		{
+			name: "block comments do not nest",
+			sql:  "select /* a /* b */ 1; select 2",
+			want: []string{"select /* a /* b */ 1", "select 2"},
+		},
+		{
+			name: "dollar signs are not quotes",
+			sql:  "select '$$'; select $$a; select 3",
+			want: []string{"select '$$'", "select $$a", "select 3"},
+		},
+		{
+			name: "delimiter directive",
+			sql: "DELIMITER $$\n" +
+				"CREATE PROCEDURE p()\nBEGIN\n  SELECT 1;\n  SELECT 2;\nEND$$\n" +
+				"DELIMITER ;\n" +
+				"CALL p();",
+			want: []string{
+				"CREATE PROCEDURE p()\nBEGIN\n  SELECT 1;\n  SELECT 2;\nEND",
+				"CALL p()",
+			},
+		},
+		{
+			name: "delimiter directive is case insensitive",
+			sql:  "delimiter //\nselect 1; select 2//\ndelimiter ;\nselect 3;",
+			want: []string{"select 1; select 2", "select 3"},
+		},
+		{
+			name: "routine body without delimiter directive",
+			sql: "CREATE DEFINER=`root`@`%` PROCEDURE p(IN x INT)\nBEGIN\n" +
+				"  IF x > 0 THEN SELECT 1; END IF;\n" +
+				"  CASE x WHEN 1 THEN SELECT 2; ELSE BEGIN SELECT 3; END; END CASE;\n" +
+				"  lbl: LOOP LEAVE lbl; END LOOP lbl;\n" +
+				"END;\nSELECT 4;",
+			want: []string{
+				"CREATE DEFINER=`root`@`%` PROCEDURE p(IN x INT)\nBEGIN\n" +
+					"  IF x > 0 THEN SELECT 1; END IF;\n" +
+					"  CASE x WHEN 1 THEN SELECT 2; ELSE BEGIN SELECT 3; END; END CASE;\n" +
+					"  lbl: LOOP LEAVE lbl; END LOOP lbl;\n" +
+					"END",
+				"SELECT 4",
+			},
+		},
+		{
+			name: "trigger without compound body",
+			sql:  "CREATE TRIGGER t BEFORE INSERT ON x FOR EACH ROW SET NEW.a = 1; SELECT 2;",
+			want: []string{"CREATE TRIGGER t BEFORE INSERT ON x FOR EACH ROW SET NEW.a = 1", "SELECT 2"},
+		},
+		{
+			name: "transaction begin splits normally",
+			sql:  "BEGIN; UPDATE t SET a = 1; COMMIT;",
+			want: []string{"BEGIN", "UPDATE t SET a = 1", "COMMIT"},
+		},
+	}
+}
+
+func TestSplitMySQL(t *testing.T) {
+	runSplitCases(t, databases.EngineMySQL, mysqlCases())
+}
+
+func TestSplitMariaDB(t *testing.T) {
+	runSplitCases(t, databases.EngineMariaDB, append(mysqlCases(), splitCase{
+		name: "anonymous block",
+		sql:  "BEGIN NOT ATOMIC SELECT 1; SELECT 2; END; SELECT 3;",
+		want: []string{"BEGIN NOT ATOMIC SELECT 1; SELECT 2; END", "SELECT 3"},
+	}))
+}
+
+func TestStatementOffsets(t *testing.T) {
+	sql := "  select 1;\n\nselect 2"
+	got := Split(sql, databases.EnginePostgres)
+	want := []Statement{
+		{Text: "select 1", Start: 2, End: 11, Delimiter: ";"},
+		{Text: "select 2", Start: 13, End: 21, Delimiter: ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Split offsets\n got: %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestAtCursor(t *testing.T) {
+	sql := "select 1;\n\nselect 2;\nselect 3"
+	cases := []struct {
+		name   string
+		offset int
+		want   string
+	}{
+		{name: "start of input", offset: 0, want: "select 1"},
+		{name: "inside first", offset: 4, want: "select 1"},
+		{name: "after first delimiter", offset: 10, want: "select 1"},
+		{name: "start of second", offset: 11, want: "select 2"},
+		{name: "end of input", offset: len(sql), want: "select 3"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := AtCursor(sql, databases.EnginePostgres, tc.offset)
+			if !ok || got.Text != tc.want {
+				t.Errorf("AtCursor(%d) = %q, %v; want %q", tc.offset, got.Text, ok, tc.want)
+			}
+		})
+	}
+
+	if _, ok := AtCursor("  -- nothing\n", databases.EnginePostgres, 0); ok {
+		t.Errorf("AtCursor on a script without statements should report false")
+	}
+}
@@ -0,0 +1,135 @@
+package statements
+
+import "strings"
+
+// SourceTable returns the table a query reads from when it's a plain SELECT
+// of a single table, possibly aliased and schema qualified, with no joins,
+// set operations or grouping that would stop a row mapping back to one row
+// of the table. The name parts are returned unquoted.
+func SourceTable(src string, engine string) ([]string, bool) {
+	if Keyword(src, engine) != "SELECT" {
+		return nil, false
+	}
+
+	tokens := []Token{}
+	for _, tok := range Tokenize(src, engine) {
+		if tok.Kind != Comment {
+			tokens = append(tokens, tok)
+		}
+	}
+
+	// find FROM outside any parentheses
+	depth, from := 0, -1
+	for i, tok := range tokens {
+		switch {
+		case tok.Is(Punctuation, "("):
+			depth++
+		case tok.Is(Punctuation, ")"):
+			depth--
+		case depth > 0:
+		case tok.Is(Word, "UNION"), tok.Is(Word, "INTERSECT"), tok.Is(Word, "EXCEPT"),
+			tok.Is(Word, "GROUP"), tok.Is(Word, "DISTINCT"), tok.Is(Word, "HAVING"):
+			return nil, false
+		case tok.Is(Word, "FROM") && from < 0:
+			from = i
+		}
+	}
+	if from < 0 {
+		return nil, false
+	}
+
+	name := []string{}
+	i := from + 1
+	for i < len(tokens) {
+		part, ok := identifier(tokens[i])
+		if !ok {
+			return nil, false
+		}
+		name = append(name, part)
+		i++
+		if i == len(tokens) || !tokens[i].Is(Punctuation, ".") {
+			break
+		}
+		i++
+	}
+	if len(name) == 0 {
+		return nil, false
+	}
+
+	// an alias may follow, then only clauses that keep rows whole
+	if i < len(tokens) && tokens[i].Is(Word, "AS") {
+		i++
+	}
+	if i < len(tokens) && (tokens[i].Kind == QuotedIdentifier || tokens[i].Kind == Word && !clauseKeyword(tokens[i].Upper())) {
+		i++
+	}
+	if i < len(tokens) && !tokens[i].Is(Punctuation, ";") && !(tokens[i].Kind == Word && clauseKeyword(tokens[i].Upper())) {
+		return nil, false
+	}
+
+	return name, true
+}
+
+// PlainColumns reports whether a SELECT's columns are all * or bare column
+// references, possibly qualified, so each result column is the table column
+// of the same name. Aliases and expressions can't be written back: with
+// SELECT other_id AS id, an edit to id would land on the wrong column or row.
+func PlainColumns(src string, engine string) bool {
+	tokens := []Token{}
+	for _, tok := range Tokenize(src, engine) {
+		if tok.Kind != Comment {
+			tokens = append(tokens, tok)
+		}
+	}
+	if len(tokens) == 0 || !tokens[0].Is(Word, "SELECT") {
+		return false
+	}
+
+	// each item is a name, or names joined by dots, possibly ending in .*
+	expectName := true
+	for _, tok := range tokens[1:] {
+		switch {
+		case tok.Is(Word, "FROM"):
+			return !expectName
+		case expectName && tok.Is(Operator, "*"):
+			expectName = false
+		case expectName && tok.Kind == QuotedIdentifier,
+			expectName && tok.Kind == Word && !literalWord(tok.Upper()):
+			expectName = false
+		case !expectName && (tok.Is(Punctuation, ",") || tok.Is(Punctuation, ".")):
+			expectName = true
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+// literalWord is a word that reads like a column but is a value.
+func literalWord(word string) bool {
+	switch word {
+	case "NULL", "TRUE", "FALSE", "DEFAULT":
+		return true
+	}
+	return strings.HasPrefix(word, "CURRENT_") || strings.HasPrefix(word, "LOCALTIME")
+}
+
+func clauseKeyword(word string) bool {
+	switch word {
+	case "WHERE", "ORDER", "LIMIT", "OFFSET", "FETCH", "FOR", "WINDOW":
+		return true
+	}
+	return false
+}
+
+func identifier(tok Token) (string, bool) {
+	switch {
+	case tok.Kind == Word:
+		return tok.Text, true
+	case tok.Kind == QuotedIdentifier && len(tok.Text) >= 2:
+		quote := tok.Text[:1]
+		return strings.ReplaceAll(tok.Text[1:len(tok.Text)-1], quote+quote, quote), true
+	}
+	return "", false
+}
@@ -0,0 +1,63 @@
+package statements
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/therealphatmike/squeal/util/databases"
+)
+
+func TestSourceTable(t *testing.T) {
+	cases := []struct {
+		name   string
+		engine string
+		sql    string
+		want   string
+	}{
+		{"plain", databases.EnginePostgres, "select * from users", "users"},
+		{"qualified and quoted", databases.EnginePostgres, `SELECT id FROM public."Order Items" oi WHERE oi.id > 3`, "public.Order Items"},
+		{"backticks and limit", databases.EngineMySQL, "select * from `shop`.`orders` as o order by id limit 10;", "shop.orders"},
+		{"subquery in where", databases.EnginePostgres, "select * from t where id in (select id from u join v using (id))", "t"},
+		{"join", databases.EnginePostgres, "select * from a join b on a.id = b.id", ""},
+		{"comma join", databases.EnginePostgres, "select * from a, b", ""},
+		{"group by", databases.EnginePostgres, "select kind, count(*) from a group by kind", ""},
+		{"union", databases.EnginePostgres, "select id from a union select id from b", ""},
+		{"from a subquery", databases.EnginePostgres, "select * from (select 1) s", ""},
+		{"not a select", databases.EnginePostgres, "delete from a", ""},
+		{"no from", databases.EnginePostgres, "select 1", ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			name, ok := SourceTable(tc.sql, tc.engine)
+			if got := strings.Join(name, "."); got != tc.want || ok != (tc.want != "") {
+				t.Errorf("SourceTable(%q) = %q, %v, want %q", tc.sql, got, ok, tc.want)
+			}
+		})
+	}
+}
+
+func TestPlainColumns(t *testing.T) {
+	cases := []struct {
+		sql  string
+		want bool
+	}{
+		{"select * from users", true},
+		{`SELECT id, u."Name", public.users.email FROM public.users u`, true},
+		{"select u.*, id from users u where id = 1", true},
+		{"select other_id as id from users", false},
+		{"select other_id id from users", false},
+		{"select id, lower(name) from users", false},
+		{"select id, price * 2 from items", false},
+		{"select id, null from users", false},
+		{"select id, 'x' from users", false},
+		{"select id, from users", false},
+		{"delete from users", false},
+	}
+
+	for _, tc := range cases {
+		if got := PlainColumns(tc.sql, databases.EnginePostgres); got != tc.want {
+			t.Errorf("PlainColumns(%q) = %v, want %v", tc.sql, got, tc.want)
+		}
+	}
+}
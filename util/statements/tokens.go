@@ -0,0 +1,169 @@
+package statements
+
+import (
+	"strings"
+
+	"github.com/therealphatmike/squeal/util/databases"
+)
+
+type TokenKind int
+
+const (
+	// Word is a keyword or bare identifier.
+	Word TokenKind = iota
+	QuotedIdentifier
+	String
+	Number
+	Parameter
+	Comment
+	// Punctuation is one of ( ) [ ] , ; .
+	Punctuation
+	Operator
+)
+
+// Token is a lexical token of a statement. Whitespace is not returned;
+// SpaceBefore records whether any preceded the token in the source.
+type Token struct {
+	Kind        TokenKind
+	Text        string
+	Start       int
+	End         int
+	SpaceBefore bool
+}
+
+// Upper returns the token text upper cased, which is how keywords compare.
+func (t Token) Upper() string {
+	return strings.ToUpper(t.Text)
+}
+
+func (t Token) Is(kind TokenKind, text string) bool {
+	return t.Kind == kind && strings.EqualFold(t.Text, text)
+}
+
+const operatorChars = "+-*/<>=~!@#%^&|`?:"
+
+// Tokenize splits src into tokens using the engine's quoting and comment
+// rules.
+func Tokenize(src string, engine string) []Token {
+	s := &splitter{src: src, d: dialectFor(engine), engine: engine, delim: ";"}
+	tokens := []Token{}
+	space := false
+
+	add := func(kind TokenKind, start int) {
+		tokens = append(tokens, Token{Kind: kind, Text: src[start:s.pos], Start: start, End: s.pos, SpaceBefore: space})
+		space = false
+	}
+
+	for s.pos < len(s.src) {
+		start := s.pos
+		c := s.src[s.pos]
+		switch {
+		case isSpace(c):
+			s.pos++
+			space = true
+		case c == '\'':
+			s.skipString('\'', s.d.backslashEscapes || s.escapeStringPrefix())
+			add(String, start)
+		case c == '"' && s.d.doubleQuoteString:
+			s.skipString('"', s.d.backslashEscapes)
+			add(String, start)
+		case c == '"':
+			s.skipString('"', false)
+			add(QuotedIdentifier, start)
+		case c == '`' && s.d.backticks:
+			s.skipString('`', false)
+			add(QuotedIdentifier, start)
+		case c == '-' && s.lineCommentStart(), c == '#' && s.d.hashComments:
+			s.skipLine()
+			s.pos = start + len(strings.TrimRight(src[start:s.pos], "\r\n"))
+			add(Comment, start)
+		case c == '/' && s.peek(1) == '*':
+			s.skipBlockComment()
+			add(Comment, start)
+		case c == '$' && s.d.dollarQuotes && s.dollarQuote():
+			add(String, start)
+		case c == '$' && engine == databases.EnginePostgres && isDigit(s.peek(1)):
+			s.pos++
+			for s.pos < len(s.src) && isDigit(s.src[s.pos]) {
+				s.pos++
+			}
+			add(Parameter, start)
+		case c == ':' && isIdentStart(s.peek(1)) && (s.pos == 0 || !isIdentChar(s.src[s.pos-1])):
+			s.pos++
+			for s.pos < len(s.src) && isIdentChar(s.src[s.pos]) && s.src[s.pos] != '$' {
+				s.pos++
+			}
+			add(Parameter, start)
+		case c == '?' && engine != databases.EnginePostgres:
+			s.pos++
+			add(Parameter, start)
+		case isDigit(c) || (c == '.' && isDigit(s.peek(1)) && (s.pos == 0 || !isIdentChar(s.src[s.pos-1]))):
+			s.number()
+			add(Number, start)
+		case isIdentStart(c):
+			s.readWord()
+			// typed string literals such as E'..', N'..', X'..' and B'..'
+			if s.pos-start == 1 && s.pos < len(s.src) && s.src[s.pos] == '\'' {
+				s.skipString('\'', s.d.backslashEscapes || s.escapeStringPrefix())
+				add(String, start)
+				continue
+			}
+			add(Word, start)
+		case strings.IndexByte("()[],;.", c) >= 0:
+			s.pos++
+			add(Punctuation, start)
+		case strings.IndexByte(operatorChars, c) >= 0:
+			s.operator()
+			add(Operator, start)
+		default:
+			s.pos++
+			add(Operator, start)
+		}
+	}
+
+	return tokens
+}
+
+func (s *splitter) number() {
+	seenDot := false
+	seenExp := false
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		switch {
+		case isDigit(c):
+			s.pos++
+		case c == '.' && !seenDot && !seenExp:
+			seenDot = true
+			s.pos++
+		case (c == 'e' || c == 'E') && !seenExp && (isDigit(s.peek(1)) || ((s.peek(1) == '+' || s.peek(1) == '-') && isDigit(s.peek(2)))):
+			seenExp = true
+			s.pos += 2
+		default:
+			return
+		}
+	}
+}
+
+// operator reads a run of operator characters, stopping before comments. Like
+// postgres, a multi-character operator only ends in + or - when it also
+// contains one of ~!@#%^&|`? so that "a=-1" reads as = followed by -.
+func (s *splitter) operator() {
+	start := s.pos
+	for s.pos < len(s.src) && strings.IndexByte(operatorChars, s.src[s.pos]) >= 0 {
+		if s.pos > start && (s.lineCommentStart() || (s.src[s.pos] == '/' && s.peek(1) == '*') || (s.src[s.pos] == '#' && s.d.hashComments)) {
+			break
+		}
+		if s.src[s.pos] == '?' && s.engine != databases.EnginePostgres {
+			break
+		}
+		s.pos++
+	}
+
+	for s.pos-start > 1 {
+		last := s.src[s.pos-1]
+		if (last != '+' && last != '-') || strings.ContainsAny(s.src[start:s.pos-1], "~!@#%^&|`?") {
+			break
+		}
+		s.pos--
+	}
+}